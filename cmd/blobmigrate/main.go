@@ -0,0 +1,120 @@
+// Command blobmigrate: pindahkan foto absensi & bukti sakit base64 lama dari
+// Postgres ke blob store (STORAGE_BACKEND), lalu kosongkan kolom base64-nya.
+// Aman dijalankan berulang; baris yang sudah punya key dilewati.
+//
+//	blobmigrate [-batch 200] [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"absensi/internal/db"
+	"absensi/internal/repo"
+	"absensi/internal/storage"
+	"absensi/internal/util/imgutil"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	batch := flag.Int("batch", 200, "jumlah baris per query")
+	dryRun := flag.Bool("dry-run", false, "hitung saja, tidak memindahkan apa pun")
+	flag.Parse()
+	_ = godotenv.Load()
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		log.Fatal("DATABASE_URL is not set")
+	}
+	sqlDB, err := db.Connect(dsn)
+	if err != nil {
+		log.Fatal("connect db:", err)
+	}
+	defer sqlDB.Close()
+
+	store, err := storage.FromEnv()
+	if err != nil {
+		log.Fatal("storage:", err)
+	}
+
+	ctx := context.Background()
+	att := repo.NewAttendanceRepo(sqlDB)
+	leaves := repo.NewLeaveRepo(sqlDB)
+
+	moved, failed := 0, 0
+	migrate := func(b repo.LegacyBlob, prefix string, save func(key string) error) {
+		raw, err := imgutil.DecodeRaw(b.Base64)
+		if err != nil {
+			log.Printf("skip %s %s: %v", b.ID, b.Column, err)
+			failed++
+			return
+		}
+		if *dryRun {
+			moved++
+			return
+		}
+		ct := http.DetectContentType(raw)
+		key, err := storage.NewKey(prefix, storage.ExtFor(ct))
+		if err == nil {
+			if ct == "image/jpeg" {
+				err = storage.PutImage(ctx, store, key, raw) // + varian thumb/medium
+			} else {
+				err = store.Put(ctx, key, raw, ct)
+			}
+		}
+		if err == nil {
+			err = save(key)
+		}
+		if err != nil {
+			log.Printf("fail %s %s: %v", b.ID, b.Column, err)
+			failed++
+			return
+		}
+		moved++
+	}
+
+	after := ""
+	for {
+		blobs, err := att.NextLegacyPhotos(ctx, after, *batch)
+		if err != nil {
+			log.Fatal("query attendance: ", err)
+		}
+		if len(blobs) == 0 {
+			break
+		}
+		for _, b := range blobs {
+			b := b
+			migrate(b, "attendance/"+b.UserID+"/"+b.Date+"/"+b.Column, func(key string) error {
+				return att.SetPhotoKey(ctx, b.ID, b.Column, key)
+			})
+		}
+		after = blobs[len(blobs)-1].ID
+	}
+
+	after = ""
+	for {
+		blobs, err := leaves.NextLegacyProofs(ctx, after, *batch)
+		if err != nil {
+			log.Fatal("query leave: ", err)
+		}
+		if len(blobs) == 0 {
+			break
+		}
+		for _, b := range blobs {
+			b := b
+			migrate(b, "leave/"+b.UserID, func(key string) error {
+				return leaves.SetProofKey(ctx, b.ID, key)
+			})
+		}
+		after = blobs[len(blobs)-1].ID
+	}
+
+	log.Printf("moved=%d failed=%d dry_run=%v", moved, failed, *dryRun)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
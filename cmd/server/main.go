@@ -7,6 +7,7 @@ import (
 
 	"absensi/internal/db"
 	router "absensi/internal/http/router"
+	"absensi/internal/storage"
 
 	"github.com/joho/godotenv"
 )
@@ -27,7 +28,12 @@ func main() {
 	}
 	defer sqlDB.Close()
 
-	mux := router.New(sqlDB)
+	store, err := storage.FromEnv()
+	if err != nil {
+		log.Fatal("storage:", err)
+	}
+
+	mux := router.New(sqlDB, store)
 	addr := ":8080"
 	log.Println("listening on", addr)
 	log.Fatal(http.ListenAndServe(addr, mux))
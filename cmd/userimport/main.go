@@ -0,0 +1,105 @@
+// Command userimport: import / export data pegawai lewat CSV atau XLSX.
+//
+//	userimport [-dry-run] pegawai.csv
+//	userimport -export pegawai.xlsx
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"absensi/internal/db"
+	"absensi/internal/repo"
+	"absensi/internal/userimport"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "validasi saja, tidak menyimpan apa pun")
+	exportPath := flag.String("export", "", "tulis semua user ke file ini (.csv / .xlsx)")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: userimport [-dry-run] <file.csv|file.xlsx>")
+		fmt.Fprintln(os.Stderr, "       userimport -export <file.csv|file.xlsx>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	_ = godotenv.Load()
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		log.Fatal("DATABASE_URL is not set")
+	}
+	sqlDB, err := db.Connect(dsn)
+	if err != nil {
+		log.Fatal("connect db:", err)
+	}
+	defer sqlDB.Close()
+	users := repo.NewUserRepo(sqlDB)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if *exportPath != "" {
+		if err := export(ctx, users, *exportPath); err != nil {
+			log.Fatal("export: ", err)
+		}
+		return
+	}
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	recs, err := userimport.Parse(bytes.NewReader(raw), userimport.FormatFromName(path))
+	if err != nil {
+		log.Fatal("parse: ", err)
+	}
+
+	res, err := userimport.Run(ctx, users, recs, *dryRun)
+	if err != nil {
+		log.Fatal("import: ", err)
+	}
+
+	for _, row := range res.Rows {
+		line := fmt.Sprintf("line %4d  %-7s %s", row.Line, row.Action, row.Username)
+		if len(row.Errors) > 0 {
+			line += "  → " + strings.Join(row.Errors, "; ")
+		}
+		fmt.Println(line)
+	}
+	s := res.Summary
+	fmt.Printf("\ntotal=%d created=%d updated=%d errors=%d dry_run=%v committed=%v\n",
+		s.Total, s.Created, s.Updated, s.Errors, res.DryRun, res.Committed)
+	if s.Errors > 0 {
+		os.Exit(1)
+	}
+}
+
+func export(ctx context.Context, users *repo.UserRepo, path string) error {
+	all, _, err := users.List(ctx, repo.UserFilter{})
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := userimport.Export(f, userimport.FormatFromName(path), all, nil); err != nil {
+		f.Close()
+		return err
+	}
+	log.Printf("exported %d users to %s", len(all), path)
+	return f.Close()
+}
@@ -0,0 +1,84 @@
+// Package devicesig: tanda tangan ed25519 untuk event absensi yang direkam
+// offline di perangkat terdaftar lalu diunggah belakangan.
+//
+// Pesan yang ditandatangani (UTF-8, dipisah "\n", tanpa newline di akhir):
+//
+//	absensi-offline:1
+//	<device_id>
+//	<user_id>
+//	<event>                  check_in | check_out
+//	<captured_at>            RFC3339 UTC, presisi detik
+//	<lat>                    7 desimal, mis. -7.6882600
+//	<lng>
+//	<photo_sha256>           hex lowercase dari byte selfie asli yang diunggah
+package devicesig
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrBadKey = errors.New("invalid ed25519 public key")
+
+// Event: isi event offline yang ditandatangani.
+type Event struct {
+	DeviceID    string
+	UserID      string
+	Event       string
+	CapturedAt  time.Time
+	Lat, Lng    float64
+	PhotoSHA256 string
+}
+
+func (e Event) Message() []byte {
+	return []byte(strings.Join([]string{
+		"absensi-offline:1",
+		e.DeviceID,
+		e.UserID,
+		e.Event,
+		e.CapturedAt.UTC().Truncate(time.Second).Format(time.RFC3339),
+		strconv.FormatFloat(e.Lat, 'f', 7, 64),
+		strconv.FormatFloat(e.Lng, 'f', 7, 64),
+		strings.ToLower(e.PhotoSHA256),
+	}, "\n"))
+}
+
+// Verify: signature ed25519 atas e.Message().
+func Verify(pub []byte, e Event, sig []byte) bool {
+	if len(pub) != ed25519.PublicKeySize || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(ed25519.PublicKey(pub), e.Message(), sig)
+}
+
+// ParsePublicKey: base64 (std / url, dengan atau tanpa padding) → 32 byte.
+func ParsePublicKey(s string) ([]byte, error) {
+	b, err := decodeB64(s)
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return nil, ErrBadKey
+	}
+	return b, nil
+}
+
+// ParseSignature: base64 → 64 byte.
+func ParseSignature(s string) ([]byte, error) {
+	b, err := decodeB64(s)
+	if err != nil || len(b) != ed25519.SignatureSize {
+		return nil, errors.New("invalid signature")
+	}
+	return b, nil
+}
+
+func decodeB64(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if b, err := enc.DecodeString(s); err == nil {
+			return b, nil
+		}
+	}
+	return nil, errors.New("invalid base64")
+}
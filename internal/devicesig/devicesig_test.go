@@ -0,0 +1,98 @@
+package devicesig
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func testEvent() Event {
+	return Event{
+		DeviceID:    "dev-1",
+		UserID:      "user-1",
+		Event:       "check_in",
+		CapturedAt:  time.Date(2025, 8, 1, 7, 58, 12, 0, time.UTC),
+		Lat:         -7.68826,
+		Lng:         110.4,
+		PhotoSHA256: "ABCDEF",
+	}
+}
+
+func TestMessage(t *testing.T) {
+	want := "absensi-offline:1\ndev-1\nuser-1\ncheck_in\n2025-08-01T07:58:12Z\n-7.6882600\n110.4000000\nabcdef"
+	if got := string(testEvent().Message()); got != want {
+		t.Fatalf("Message =\n%s\nwant\n%s", got, want)
+	}
+
+	// zona waktu & sub-detik tidak mengubah pesan
+	e := testEvent()
+	e.CapturedAt = e.CapturedAt.Add(900 * time.Millisecond).In(time.FixedZone("WIB", 7*3600))
+	if string(e.Message()) != want {
+		t.Fatalf("Message not normalized: %s", e.Message())
+	}
+}
+
+func TestVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	sig := ed25519.Sign(priv, testEvent().Message())
+
+	tests := []struct {
+		name   string
+		pub    []byte
+		modify func(*Event)
+		sig    []byte
+		want   bool
+	}{
+		{"valid", pub, nil, sig, true},
+		{"other device key", otherPub, nil, sig, false},
+		{"different user", pub, func(e *Event) { e.UserID = "user-2" }, sig, false},
+		{"check_out instead of check_in", pub, func(e *Event) { e.Event = "check_out" }, sig, false},
+		{"moved one second", pub, func(e *Event) { e.CapturedAt = e.CapturedAt.Add(time.Second) }, sig, false},
+		{"moved location", pub, func(e *Event) { e.Lat += 0.0001 }, sig, false},
+		{"other photo", pub, func(e *Event) { e.PhotoSHA256 = "abcdee" }, sig, false},
+		{"short key", pub[:31], nil, sig, false},
+		{"short signature", pub, nil, sig[:63], false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := testEvent()
+			if tt.modify != nil {
+				tt.modify(&e)
+			}
+			if got := Verify(tt.pub, e, tt.sig); got != tt.want {
+				t.Fatalf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	tests := []struct {
+		name string
+		in   string
+		ok   bool
+	}{
+		{"std", base64.StdEncoding.EncodeToString(pub), true},
+		{"raw url", base64.RawURLEncoding.EncodeToString(pub), true},
+		{"surrounding space", " " + base64.StdEncoding.EncodeToString(pub) + "\n", true},
+		{"wrong length", base64.StdEncoding.EncodeToString(pub[:16]), false},
+		{"not base64", "not-a-key!", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePublicKey(tt.in)
+			if (err == nil) != tt.ok {
+				t.Fatalf("err = %v, want ok=%v", err, tt.ok)
+			}
+			if tt.ok && string(got) != string(pub) {
+				t.Fatal("decoded key differs")
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Package face: verifikasi wajah selfie absensi terhadap foto referensi
+// yang di-enroll per user. Implementasi bisa diganti lewat interface Verifier
+// (mis. model ONNX / layanan eksternal); default-nya LBPVerifier (pure Go, CPU).
+package face
+
+import (
+	"context"
+	"image"
+	"os"
+	"strconv"
+)
+
+// Verifier membandingkan selfie (probe) dengan foto referensi.
+// Score 0..1, makin tinggi makin mirip.
+type Verifier interface {
+	Compare(ctx context.Context, reference, probe image.Image) (float64, error)
+}
+
+// Status hasil verifikasi yang disimpan di baris absensi.
+const (
+	StatusMatch       = "match"
+	StatusMismatch    = "mismatch"
+	StatusNotEnrolled = "not_enrolled"
+)
+
+// Aksi saat wajah tidak cocok (FACE_MISMATCH_ACTION).
+const (
+	ActionFlag   = "flag"   // absensi tetap tercatat, masuk antrian review admin
+	ActionReject = "reject" // absensi ditolak 422
+)
+
+// Threshold: skor minimal dianggap cocok (FACE_MATCH_THRESHOLD, default 0.70).
+func Threshold() float64 {
+	if v, err := strconv.ParseFloat(os.Getenv("FACE_MATCH_THRESHOLD"), 64); err == nil && v > 0 && v <= 1 {
+		return v
+	}
+	return 0.70
+}
+
+func MismatchAction() string {
+	if os.Getenv("FACE_MISMATCH_ACTION") == ActionReject {
+		return ActionReject
+	}
+	return ActionFlag
+}
+
+// RequireEnrollment: kalau true, user tanpa foto referensi tidak bisa absen
+// (FACE_REQUIRE_ENROLLMENT=true).
+func RequireEnrollment() bool {
+	b, _ := strconv.ParseBool(os.Getenv("FACE_REQUIRE_ENROLLMENT"))
+	return b
+}
@@ -0,0 +1,136 @@
+package face
+
+import (
+	"context"
+	"errors"
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	lbpSide  = 98 // sisi crop wajah setelah resize (96 piksel efektif untuk LBP)
+	lbpGrid  = 6  // 6x6 sel histogram
+	lbpBins  = 59 // 58 pola uniform + 1 bin non-uniform
+	cropFrac = 0.7
+)
+
+// uniformIdx: kode LBP 8-bit → indeks bin (pola uniform = ≤ 2 transisi bit).
+var uniformIdx = func() [256]uint8 {
+	var m [256]uint8
+	next := uint8(0)
+	for c := 0; c < 256; c++ {
+		trans := 0
+		for i := 0; i < 8; i++ {
+			if (c>>i)&1 != (c>>((i+1)%8))&1 {
+				trans++
+			}
+		}
+		if trans <= 2 {
+			m[c] = next
+			next++
+		} else {
+			m[c] = lbpBins - 1
+		}
+	}
+	return m
+}()
+
+// LBPVerifier: Local Binary Pattern Histograms (LBPH) di atas crop tengah
+// selfie. Tanpa deteksi wajah, jadi mengandalkan wajah berada kira-kira di
+// tengah frame (sesuai panduan kamera di aplikasi). Cukup untuk menyaring
+// foto orang lain / objek lain; threshold perlu dikalibrasi dengan data nyata.
+type LBPVerifier struct{}
+
+func NewLBPVerifier() *LBPVerifier { return &LBPVerifier{} }
+
+func (LBPVerifier) Compare(ctx context.Context, reference, probe image.Image) (float64, error) {
+	a, err := lbpFeatures(reference)
+	if err != nil {
+		return 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	b, err := lbpFeatures(probe)
+	if err != nil {
+		return 0, err
+	}
+
+	// chi-square per sel ∈ [0,2] (histogram sel dinormalisasi) → skor 0..1
+	var chi float64
+	for i := range a {
+		if s := a[i] + b[i]; s > 0 {
+			d := a[i] - b[i]
+			chi += d * d / s
+		}
+	}
+	chi /= lbpGrid * lbpGrid
+	score := 1 - chi/2
+	if score < 0 {
+		score = 0
+	}
+	return score, nil
+}
+
+func lbpFeatures(img image.Image) ([]float64, error) {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	side = int(float64(side) * cropFrac)
+	if side < 32 {
+		return nil, errors.New("face: image too small")
+	}
+
+	// crop persegi di tengah, sedikit ke atas (posisi wajah di selfie)
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)*2/5
+	g := imaging.Grayscale(imaging.Crop(img, image.Rect(x0, y0, x0+side, y0+side)))
+	g = imaging.Resize(g, lbpSide, lbpSide, imaging.Lanczos)
+
+	// ambil kanal gray + histogram equalization (tahan perubahan cahaya)
+	px := make([]uint8, lbpSide*lbpSide)
+	var hist [256]int
+	for y := 0; y < lbpSide; y++ {
+		for x := 0; x < lbpSide; x++ {
+			v := g.Pix[y*g.Stride+x*4]
+			px[y*lbpSide+x] = v
+			hist[v]++
+		}
+	}
+	var lut [256]uint8
+	cdf, total := 0, lbpSide*lbpSide
+	for i := 0; i < 256; i++ {
+		cdf += hist[i]
+		lut[i] = uint8(cdf * 255 / total)
+	}
+	for i, v := range px {
+		px[i] = lut[v]
+	}
+
+	feat := make([]float64, lbpGrid*lbpGrid*lbpBins)
+	inner := lbpSide - 2
+	cell := inner / lbpGrid
+	dx := [8]int{-1, 0, 1, 1, 1, 0, -1, -1}
+	dy := [8]int{-1, -1, -1, 0, 1, 1, 1, 0}
+	for y := 1; y <= cell*lbpGrid; y++ {
+		for x := 1; x <= cell*lbpGrid; x++ {
+			c := px[y*lbpSide+x]
+			code := 0
+			for k := 0; k < 8; k++ {
+				if px[(y+dy[k])*lbpSide+x+dx[k]] >= c {
+					code |= 1 << k
+				}
+			}
+			ci := ((y-1)/cell)*lbpGrid + (x-1)/cell
+			feat[ci*lbpBins+int(uniformIdx[code])]++
+		}
+	}
+	norm := float64(cell * cell)
+	for i := range feat {
+		feat[i] /= norm
+	}
+	return feat, nil
+}
@@ -0,0 +1,102 @@
+package face
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// synth: gambar abu-abu w×h dari fungsi piksel.
+func synth(w, h int, f func(x, y int) uint8) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetGray(x, y, color.Gray{Y: f(x, y)})
+		}
+	}
+	return img
+}
+
+func rings(x, y int) uint8 {
+	d := math.Hypot(float64(x-120), float64(y-110))
+	return uint8(128 + 100*math.Sin(d/6))
+}
+
+func TestUniformBins(t *testing.T) {
+	uniform := map[uint8]bool{}
+	for _, idx := range uniformIdx {
+		if idx != lbpBins-1 {
+			uniform[idx] = true
+		}
+	}
+	if len(uniform) != lbpBins-1 {
+		t.Fatalf("uniform patterns = %d, want %d", len(uniform), lbpBins-1)
+	}
+}
+
+func TestLBPCompare(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	noise := make([]uint8, 240*240)
+	for i := range noise {
+		noise[i] = uint8(rng.Intn(256))
+	}
+
+	ref := synth(240, 240, rings)
+	tests := []struct {
+		name     string
+		probe    image.Image
+		min, max float64
+	}{
+		{"identical", synth(240, 240, rings), 1, 1},
+		{"darker lighting", synth(240, 240, func(x, y int) uint8 { return rings(x, y)/2 + 10 }), 0.9, 1},
+		{"larger frame same content", synth(480, 480, func(x, y int) uint8 { return rings(x/2, y/2) }), 0.9, 1},
+		{"noise", synth(240, 240, func(x, y int) uint8 { return noise[y*240+x] }), 0, 0.5},
+		{"flat", synth(240, 240, func(x, y int) uint8 { return 90 }), 0, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, err := LBPVerifier{}.Compare(context.Background(), ref, tt.probe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if score < tt.min-1e-9 || score > tt.max+1e-9 {
+				t.Fatalf("score = %.3f, want [%.2f, %.2f]", score, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestLBPCompareErrors(t *testing.T) {
+	ref := synth(240, 240, rings)
+	if _, err := (LBPVerifier{}).Compare(context.Background(), ref, synth(40, 40, rings)); err == nil {
+		t.Fatal("small probe: want error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (LBPVerifier{}).Compare(ctx, ref, ref); err == nil {
+		t.Fatal("canceled ctx: want error")
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	tests := []struct {
+		env  string
+		want float64
+	}{
+		{"", 0.70},
+		{"0.85", 0.85},
+		{"1", 1},
+		{"0", 0.70},
+		{"1.5", 0.70},
+		{"abc", 0.70},
+	}
+	for _, tt := range tests {
+		t.Setenv("FACE_MATCH_THRESHOLD", tt.env)
+		if got := Threshold(); got != tt.want {
+			t.Errorf("Threshold(%q) = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
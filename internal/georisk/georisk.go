@@ -0,0 +1,167 @@
+// Package georisk: menilai seberapa bisa dipercaya lokasi yang dikirim
+// klien saat absen (mock location, koordinat "terlalu sempurna", fix lama,
+// perpindahan yang mustahil). Hasilnya skor 0..100 + alasan, disimpan per
+// event untuk review HR.
+package georisk
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signals: data lokasi dari perangkat.
+type Signals struct {
+	Lat, Lng  float64
+	AccuracyM *float64 // radius akurasi dari OS; nil = tidak dikirim
+	AltitudeM *float64
+	Provider  string    // gps | network | fused | ...
+	Mock      bool      // Android isFromMockProvider / iOS isSimulatedBySoftware
+	FixTime   time.Time // waktu fix lokasi di perangkat; zero = tidak dikirim
+}
+
+// Previous: lokasi absen terakhir user.
+type Previous struct {
+	Lat, Lng float64
+	At       time.Time
+}
+
+// Alasan risiko. Yang "hard" langsung ditolak berapa pun skornya.
+const (
+	ReasonMock             = "mock_location"
+	ReasonExactOffice      = "exact_office_coordinates"
+	ReasonRounded          = "rounded_coordinates"
+	ReasonImpossibleTravel = "impossible_travel"
+	ReasonNoAccuracy       = "no_accuracy"
+	ReasonTooAccurate      = "implausible_accuracy"
+	ReasonLowAccuracy      = "low_accuracy"
+	ReasonStaleFix         = "stale_fix"
+)
+
+var hard = map[string]bool{ReasonMock: true, ReasonExactOffice: true, ReasonRounded: true}
+
+var weight = map[string]int{
+	ReasonMock:             100,
+	ReasonExactOffice:      100,
+	ReasonRounded:          60,
+	ReasonImpossibleTravel: 60,
+	ReasonTooAccurate:      25,
+	ReasonStaleFix:         20,
+	ReasonLowAccuracy:      15,
+	ReasonNoAccuracy:       10,
+}
+
+const (
+	maxSpeedKmh   = 250.0 // lebih cepat dari ini antar absen = mustahil (kecuali pesawat)
+	minTravelM    = 1000  // abaikan jitter GPS jarak dekat
+	maxFixSkew    = 2 * time.Minute
+	minAccuracyM  = 1.0
+	lowAccuracyM  = 100.0
+	roundDecimals = 4 // ≤ 4 desimal (~11 m) di lat DAN lng = diketik manual
+)
+
+// Assessment: hasil penilaian.
+type Assessment struct {
+	Score    int
+	Reasons  []string
+	SpeedKmh float64 // kecepatan dari lokasi absen sebelumnya (0 kalau tidak ada)
+}
+
+// Reject: ada alasan hard atau skor ≥ RejectScore().
+func (a Assessment) Reject() bool {
+	for _, r := range a.Reasons {
+		if hard[r] {
+			return true
+		}
+	}
+	return a.Score >= RejectScore()
+}
+
+func (a Assessment) Flag() bool { return a.Score >= FlagScore() }
+
+// Assess: nilai s dibanding lokasi kantor & absen sebelumnya (prev boleh nil).
+func Assess(s Signals, officeLat, officeLng float64, prev *Previous, now time.Time) Assessment {
+	var a Assessment
+	add := func(r string) { a.Reasons = append(a.Reasons, r) }
+
+	if s.Mock {
+		add(ReasonMock)
+	}
+	if s.Lat == officeLat && s.Lng == officeLng {
+		add(ReasonExactOffice)
+	} else if decimals(s.Lat) <= roundDecimals && decimals(s.Lng) <= roundDecimals {
+		add(ReasonRounded)
+	}
+
+	switch {
+	case s.AccuracyM == nil:
+		add(ReasonNoAccuracy)
+	case *s.AccuracyM < minAccuracyM:
+		add(ReasonTooAccurate)
+	case *s.AccuracyM > lowAccuracyM:
+		add(ReasonLowAccuracy)
+	}
+
+	if !s.FixTime.IsZero() {
+		skew := now.Sub(s.FixTime)
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > maxFixSkew {
+			add(ReasonStaleFix)
+		}
+	}
+
+	if prev != nil && now.After(prev.At) {
+		d := haversine(prev.Lat, prev.Lng, s.Lat, s.Lng)
+		hours := now.Sub(prev.At).Hours()
+		if hours > 0 {
+			a.SpeedKmh = d / 1000 / hours
+		}
+		if d >= minTravelM && a.SpeedKmh > maxSpeedKmh {
+			add(ReasonImpossibleTravel)
+		}
+	}
+
+	for _, r := range a.Reasons {
+		a.Score += weight[r]
+	}
+	if a.Score > 100 {
+		a.Score = 100
+	}
+	return a
+}
+
+// decimals: jumlah digit desimal representasi terpendek float.
+func decimals(f float64) int {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	const R = 6371000.0
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * R * math.Asin(math.Sqrt(h))
+}
+
+// FlagScore: skor minimal masuk antrian review (GEO_RISK_FLAG_SCORE, default 40).
+func FlagScore() int { return envInt("GEO_RISK_FLAG_SCORE", 40) }
+
+// RejectScore: skor minimal absensi ditolak (GEO_RISK_REJECT_SCORE, default 80).
+func RejectScore() int { return envInt("GEO_RISK_REJECT_SCORE", 80) }
+
+func envInt(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 && n <= 100 {
+		return n
+	}
+	return def
+}
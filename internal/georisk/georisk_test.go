@@ -0,0 +1,85 @@
+package georisk
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAssess(t *testing.T) {
+	const officeLat, officeLng = -6.200000, 106.816666
+	now := time.Date(2025, 8, 1, 8, 0, 0, 0, time.UTC)
+	acc := func(m float64) *float64 { return &m }
+	good := Signals{Lat: -6.2001234, Lng: 106.8165432, AccuracyM: acc(12), FixTime: now.Add(-10 * time.Second)}
+
+	tests := []struct {
+		name    string
+		s       func(Signals) Signals
+		prev    *Previous
+		reasons []string
+		score   int
+		reject  bool
+		flag    bool
+	}{
+		{name: "clean", s: func(s Signals) Signals { return s }},
+		{name: "mock", s: func(s Signals) Signals { s.Mock = true; return s },
+			reasons: []string{ReasonMock}, score: 100, reject: true, flag: true},
+		{name: "exact office", s: func(s Signals) Signals { s.Lat, s.Lng = officeLat, officeLng; return s },
+			reasons: []string{ReasonExactOffice}, score: 100, reject: true, flag: true},
+		{name: "rounded", s: func(s Signals) Signals { s.Lat, s.Lng = -6.2001, 106.8165; return s },
+			reasons: []string{ReasonRounded}, score: 60, reject: true, flag: true},
+		{name: "one coordinate precise is not rounded", s: func(s Signals) Signals { s.Lat = -6.2001; return s }},
+		{name: "no accuracy", s: func(s Signals) Signals { s.AccuracyM = nil; return s },
+			reasons: []string{ReasonNoAccuracy}, score: 10},
+		{name: "implausible accuracy", s: func(s Signals) Signals { s.AccuracyM = acc(0.5); return s },
+			reasons: []string{ReasonTooAccurate}, score: 25},
+		{name: "low accuracy", s: func(s Signals) Signals { s.AccuracyM = acc(250); return s },
+			reasons: []string{ReasonLowAccuracy}, score: 15},
+		{name: "stale fix", s: func(s Signals) Signals { s.FixTime = now.Add(-5 * time.Minute); return s },
+			reasons: []string{ReasonStaleFix}, score: 20},
+		{name: "fix in the future", s: func(s Signals) Signals { s.FixTime = now.Add(3 * time.Minute); return s },
+			reasons: []string{ReasonStaleFix}, score: 20},
+		{name: "no fix time", s: func(s Signals) Signals { s.FixTime = time.Time{}; return s }},
+		{name: "impossible travel", s: func(s Signals) Signals { return s },
+			prev:    &Previous{Lat: -7.2575, Lng: 112.7521, At: now.Add(-30 * time.Minute)}, // Surabaya
+			reasons: []string{ReasonImpossibleTravel}, score: 60, flag: true},
+		{name: "short hop ignored", s: func(s Signals) Signals { return s },
+			prev: &Previous{Lat: -6.2005, Lng: 106.8170, At: now.Add(-time.Second)}},
+		{name: "stacked reasons capped", s: func(s Signals) Signals { s.Lat, s.Lng, s.AccuracyM = -6.2001, 106.8165, nil; return s },
+			prev:    &Previous{Lat: -7.2575, Lng: 112.7521, At: now.Add(-30 * time.Minute)},
+			reasons: []string{ReasonRounded, ReasonNoAccuracy, ReasonImpossibleTravel}, score: 100, reject: true, flag: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Assess(tt.s(good), officeLat, officeLng, tt.prev, now)
+			if !reflect.DeepEqual(a.Reasons, tt.reasons) {
+				t.Fatalf("reasons = %v, want %v", a.Reasons, tt.reasons)
+			}
+			if a.Score != tt.score {
+				t.Errorf("score = %d, want %d", a.Score, tt.score)
+			}
+			if a.Reject() != tt.reject || a.Flag() != tt.flag {
+				t.Errorf("reject/flag = %v/%v, want %v/%v", a.Reject(), a.Flag(), tt.reject, tt.flag)
+			}
+		})
+	}
+}
+
+func TestScoreEnv(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int
+	}{
+		{"", 40},
+		{"55", 55},
+		{"0", 40},
+		{"101", 40},
+		{"x", 40},
+	}
+	for _, tt := range tests {
+		t.Setenv("GEO_RISK_FLAG_SCORE", tt.env)
+		if got := FlagScore(); got != tt.want {
+			t.Errorf("FlagScore(%q) = %d, want %d", tt.env, got, tt.want)
+		}
+	}
+}
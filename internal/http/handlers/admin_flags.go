@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ===== GET /admin/attendance/flags?status=pending|approved|rejected|all&reason=&page=&page_size=
+
+func (h *AttendanceHandler) ListFlags(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	status := q.Get("status")
+	if status == "" {
+		status = "pending"
+	}
+	switch status {
+	case "all", "pending", "approved", "rejected":
+	default:
+		http.Error(w, "invalid status", http.StatusBadRequest)
+		return
+	}
+	page, pageSize := 1, 50
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid page", http.StatusBadRequest)
+			return
+		}
+		page = n
+	}
+	if v := q.Get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 200 {
+			http.Error(w, "invalid page_size", http.StatusBadRequest)
+			return
+		}
+		pageSize = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	flags, err := h.Flags.List(ctx, status, q.Get("reason"), pageSize, (page-1)*pageSize)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]map[string]any, 0, len(flags))
+	for _, f := range flags {
+		items = append(items, map[string]any{
+			"id":          f.ID,
+			"user_id":     f.UserID,
+			"username":    f.Username,
+			"date":        f.Date.Format("2006-01-02"),
+			"event":       f.Event,
+			"reason":      f.Reason,
+			"details":     f.Details,
+			"status":      f.Status,
+			"note":        f.Note.String,
+			"created_at":  f.CreatedAt.UTC().Format(time.RFC3339),
+			"resolved_at": toRFC3339(optTime(f.ResolvedAt)),
+			"resolved_by": f.ResolvedBy.String,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"page":          page,
+		"page_size":     pageSize,
+		"status_filter": status,
+		"items":         items,
+	})
+}
+
+// ===== POST /admin/attendance/flags/{id}/resolve  {"decision":"approved|rejected","note":"..."}
+
+type resolveFlagReq struct {
+	Decision string `json:"decision"`
+	Note     string `json:"note,omitempty"`
+}
+
+func (h *AttendanceHandler) ResolveFlag(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r, h.Users)
+	if !ok {
+		return
+	}
+
+	var req resolveFlagReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if req.Decision != "approved" && req.Decision != "rejected" {
+		http.Error(w, "invalid decision", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	id := r.PathValue("id")
+	done, err := h.Flags.Resolve(ctx, id, req.Decision, admin.ID, strings.TrimSpace(req.Note))
+	if err != nil && !isNotFound(err) {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !done {
+		http.Error(w, "not found or already resolved", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"result": req.Decision, "id": id})
+}
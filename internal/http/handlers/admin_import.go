@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"absensi/internal/repo"
+	"absensi/internal/userimport"
+)
+
+const maxImportBytes = 10 << 20 // 10 MB
+
+// ===== POST /admin/users/import?dry_run=true&format=csv|xlsx
+// Body: multipart (field "file") atau file mentah (Content-Type text/csv / xlsx).
+
+func (h *AdminHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	dryRun := false
+	if v := q.Get("dry_run"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "invalid dry_run", http.StatusBadRequest)
+			return
+		}
+		dryRun = b
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	var (
+		body   io.Reader = r.Body
+		format           = q.Get("format")
+	)
+	if file, header, err := r.FormFile("file"); err == nil {
+		defer file.Close()
+		body = file
+		if format == "" {
+			format = userimport.FormatFromName(header.Filename)
+		}
+	} else if format == "" {
+		format = userimport.FormatFromName(r.Header.Get("Content-Type"))
+	}
+
+	// excelize butuh ReaderAt → baca penuh dulu (sudah dibatasi MaxBytesReader)
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, "file too large or unreadable", http.StatusRequestEntityTooLarge)
+		return
+	}
+	recs, err := userimport.Parse(bytes.NewReader(raw), format)
+	if err != nil {
+		http.Error(w, "invalid file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(recs) == 0 {
+		http.Error(w, "no rows", http.StatusBadRequest)
+		return
+	}
+
+	// bcrypt ratusan baris butuh waktu lebih lama dari handler biasa
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	res, err := userimport.Run(ctx, h.Users, recs, dryRun)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	code := http.StatusOK
+	if !dryRun && !res.Committed {
+		code = 422
+	}
+	writeJSON(w, code, res)
+}
+
+// ===== GET /admin/users/export?format=csv|xlsx (+ filter sama dengan list)
+
+func (h *AdminHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = userimport.FormatCSV
+	}
+	contentType := map[string]string{
+		userimport.FormatCSV:  "text/csv; charset=utf-8",
+		userimport.FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	}[format]
+	if contentType == "" {
+		http.Error(w, "invalid format", http.StatusBadRequest)
+		return
+	}
+	status := q.Get("status")
+	switch status {
+	case "", "all", "active", "inactive":
+	default:
+		http.Error(w, "invalid status", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	users, usernames, err := h.Users.ListWithManagers(ctx, repo.UserFilter{
+		Query:   q.Get("q"),
+		Jabatan: q.Get("jabatan"),
+		Office:  q.Get("office"),
+		Status:  status,
+	})
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := userimport.Export(&buf, format, users, usernames); err != nil {
+		http.Error(w, "export failed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="users-`+time.Now().Format("20060102")+`.`+format+`"`)
+	_, _ = w.Write(buf.Bytes())
+}
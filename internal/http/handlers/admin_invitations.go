@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"absensi/internal/models"
+	"absensi/internal/repo"
+	"absensi/internal/util"
+)
+
+func invitationJSON(inv models.Invitation, now time.Time) map[string]any {
+	p := userJSON(inv.Profile)
+	profile := map[string]any{}
+	for _, k := range []string{
+		"username", "jabatan", "role", "office", "full_name", "nip", "email", "phone",
+		"department", "hire_date", "employment_type", "contract_end_date", "manager_id", "schedule_code",
+	} {
+		profile[k] = p[k]
+	}
+	return map[string]any{
+		"id":         inv.ID,
+		"status":     inv.Status(now),
+		"profile":    profile,
+		"created_by": inv.CreatedBy,
+		"created_at": inv.CreatedAt.UTC().Format(time.RFC3339),
+		"expires_at": inv.ExpiresAt.UTC().Format(time.RFC3339),
+		"used_at":    toRFC3339(optTime(inv.UsedAt)),
+		"used_by":    inv.UsedBy,
+		"revoked_at": toRFC3339(optTime(inv.RevokedAt)),
+	}
+}
+
+// ===== POST /admin/invitations
+
+type createInvitationReq struct {
+	Username       string `json:"username,omitempty"` // opsional: kunci username
+	Jabatan        string `json:"jabatan"`
+	Office         string `json:"office,omitempty"`
+	Role           string `json:"role,omitempty"`
+	ExpiresInHours int    `json:"expires_in_hours,omitempty"` // default INVITE_TTL_HOURS
+	profileFields
+	hrFields
+}
+
+func (h *AdminHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r, h.Users)
+	if !ok {
+		return
+	}
+
+	var req createInvitationReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Role == "" {
+		req.Role = models.RoleEmployee
+	}
+	if (req.Username != "" && len(req.Username) < 3) || strings.TrimSpace(req.Jabatan) == "" || !validRole(req.Role) {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	if req.PhotoBase64 != nil {
+		http.Error(w, "photo_base64 not supported on invitation", http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresInHours < 0 || req.ExpiresInHours > 24*30 {
+		http.Error(w, "invalid expires_in_hours", http.StatusBadRequest)
+		return
+	}
+
+	var patch repo.UserPatch
+	if err := req.profileFields.apply(&patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.hrFields.apply(&patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := checkContract(models.User{}, patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ttl := util.InviteTTL()
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	token, err := randomToken(32)
+	if err != nil {
+		http.Error(w, "token error", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	if !h.managerExists(ctx, patch.ManagerID) {
+		http.Error(w, "unknown manager_id", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC()
+	inv, err := h.Invites.Create(ctx, util.HashToken(token), models.Invitation{
+		Profile: patchToUser(patch, models.User{
+			Username: req.Username,
+			Jabatan:  strings.TrimSpace(req.Jabatan),
+			Role:     req.Role,
+			Office:   strings.TrimSpace(req.Office),
+		}),
+		CreatedBy: admin.ID,
+		ExpiresAt: now.Add(ttl),
+	})
+	if err != nil {
+		if isFKViolation(err) {
+			http.Error(w, "unknown schedule_code", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := invitationJSON(inv, now)
+	// token asli hanya ditampilkan sekali di sini
+	resp["token"] = token
+	resp["url"] = util.InviteURL(token)
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// ===== GET /admin/invitations?status=pending|used|expired|revoked|all&page=&page_size=
+
+func (h *AdminHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	status := q.Get("status")
+	if status == "" {
+		status = "all"
+	}
+	switch status {
+	case "all", "pending", "used", "expired", "revoked":
+	default:
+		http.Error(w, "invalid status", http.StatusBadRequest)
+		return
+	}
+	page, pageSize := 1, 20
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid page", http.StatusBadRequest)
+			return
+		}
+		page = n
+	}
+	if v := q.Get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			http.Error(w, "invalid page_size", http.StatusBadRequest)
+			return
+		}
+		pageSize = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	invs, err := h.Invites.List(ctx, status, pageSize, (page-1)*pageSize)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	items := make([]map[string]any, 0, len(invs))
+	for _, inv := range invs {
+		items = append(items, invitationJSON(inv, now))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"page":          page,
+		"page_size":     pageSize,
+		"status_filter": status,
+		"items":         items,
+	})
+}
+
+// ===== POST /admin/invitations/{id}/revoke
+
+func (h *AdminHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	id := r.PathValue("id")
+	done, err := h.Invites.Revoke(ctx, id)
+	if err != nil && !isNotFound(err) {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !done {
+		http.Error(w, "not found or already used/revoked", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"result": "revoked", "id": id})
+}
+
+// ===== GET /invitations?token=...  (publik: isi form pendaftaran)
+
+func (h *AuthHandler) GetInvitation(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSpace(r.URL.Query().Get("token"))
+	if token == "" {
+		http.Error(w, "missing token", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	inv, err := h.Invites.GetByTokenHash(ctx, util.HashToken(token))
+	if err != nil {
+		if errors.Is(err, repo.ErrInviteNotFound) {
+			http.Error(w, "invalid invitation", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	if st := inv.Status(now); st != "pending" {
+		http.Error(w, "invitation "+st, http.StatusGone)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"username":       inv.Profile.Username, // "" = bebas dipilih
+		"username_fixed": inv.Profile.Username != "",
+		"full_name":      inv.Profile.FullName,
+		"jabatan":        inv.Profile.Jabatan,
+		"office":         inv.Profile.Office,
+		"email":          inv.Profile.Email,
+		"expires_at":     inv.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"absensi/internal/models"
+	"absensi/internal/repo"
+	"absensi/internal/util"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AdminHandler struct {
+	Users       *repo.UserRepo
+	RefreshRepo *repo.RefreshRepo
+	Invites     *repo.InvitationRepo
+}
+
+// RequireActive: middleware untuk semua route. Access token (JWT, hidup
+// sampai exp) milik user yang sudah dinonaktifkan ("account deactivated") atau
+// dihapus ("invalid token") ditolak di sini; refresh token-nya sudah dicabut
+// saat dinonaktifkan.
+func (h *AdminHandler) RequireActive(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authz := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authz, "Bearer ") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		uid, _, err := util.ParseAccessToken(strings.TrimSpace(strings.TrimPrefix(authz, "Bearer ")))
+		if err != nil {
+			next.ServeHTTP(w, r) // token invalid → ditolak handler (mustAuth)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		active, err := h.Users.IsActive(ctx, uid)
+		cancel()
+		if isNotFound(err) {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+		if !active {
+			http.Error(w, "account deactivated", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAdmin: seperti mustAuth, tapi user harus aktif & role admin.
+func requireAdmin(w http.ResponseWriter, r *http.Request, users *repo.UserRepo) (models.User, bool) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return models.User{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	u, err := users.GetByID(ctx, uid)
+	if err != nil {
+		http.Error(w, "user not found", http.StatusUnauthorized)
+		return models.User{}, false
+	}
+	if !u.IsActive || !u.IsAdmin() {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return models.User{}, false
+	}
+	return u, true
+}
+
+// isNotFound: ErrNoRows atau id yang bukan UUID valid.
+func isNotFound(err error) bool {
+	if errors.Is(err, sql.ErrNoRows) {
+		return true
+	}
+	return err != nil && strings.Contains(err.Error(), "invalid input syntax")
+}
+
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	low := strings.ToLower(err.Error())
+	return strings.Contains(low, "duplicate key") || strings.Contains(low, "unique")
+}
+
+// managerExists: nil / "" dianggap valid (tidak diubah / dikosongkan).
+func (h *AdminHandler) managerExists(ctx context.Context, id *string) bool {
+	if id == nil || *id == "" {
+		return true
+	}
+	_, err := h.Users.GetByID(ctx, *id)
+	return err == nil
+}
+
+func validRole(role string) bool {
+	return role == models.RoleEmployee || role == models.RoleAdmin
+}
+
+// ===== GET /admin/users?q=&jabatan=&office=&status=active|inactive|all&page=1&page_size=20
+
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	status := q.Get("status")
+	if status == "" {
+		status = "all"
+	}
+	switch status {
+	case "all", "active", "inactive":
+	default:
+		http.Error(w, "invalid status", http.StatusBadRequest)
+		return
+	}
+
+	page, pageSize := 1, 20
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid page", http.StatusBadRequest)
+			return
+		}
+		page = n
+	}
+	if v := q.Get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			http.Error(w, "invalid page_size", http.StatusBadRequest)
+			return
+		}
+		pageSize = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	users, total, err := h.Users.List(ctx, repo.UserFilter{
+		Query:   strings.TrimSpace(q.Get("q")),
+		Jabatan: strings.TrimSpace(q.Get("jabatan")),
+		Office:  strings.TrimSpace(q.Get("office")),
+		Status:  status,
+		Limit:   pageSize,
+		Offset:  (page - 1) * pageSize,
+	})
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]map[string]any, 0, len(users))
+	for _, u := range users {
+		items = append(items, userJSON(u))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"items":     items,
+	})
+}
+
+// ===== POST /admin/users
+
+type adminCreateUserReq struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Jabatan  string `json:"jabatan"`
+	Office   string `json:"office,omitempty"`
+	Role     string `json:"role,omitempty"` // default employee
+	profileFields
+	hrFields
+}
+
+func (h *AdminHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	var req adminCreateUserReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Role == "" {
+		req.Role = models.RoleEmployee
+	}
+	if len(req.Username) < 3 || len(req.Password) < 6 || strings.TrimSpace(req.Jabatan) == "" || !validRole(req.Role) {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	var patch repo.UserPatch
+	if err := req.profileFields.apply(&patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.hrFields.apply(&patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := checkContract(models.User{}, patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	photo := ""
+	if req.PhotoBase64 != nil {
+		var err error
+		if photo, err = normalizePhoto(*req.PhotoBase64); err != nil {
+			http.Error(w, "invalid photo: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "hash err", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.managerExists(ctx, patch.ManagerID) {
+		http.Error(w, "unknown manager_id", http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.Users.InsertWithPhoto(ctx, patchToUser(patch, models.User{
+		Username:     req.Username,
+		PasswordHash: string(hash),
+		Jabatan:      strings.TrimSpace(req.Jabatan),
+		Role:         req.Role,
+		Office:       strings.TrimSpace(req.Office),
+	}), photo)
+	if err != nil {
+		if isUniqueViolation(err) {
+			http.Error(w, conflictMessage(err), http.StatusConflict)
+			return
+		}
+		if isFKViolation(err) {
+			http.Error(w, "unknown schedule_code", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, userJSON(u))
+}
+
+// ===== GET /admin/users/{id}
+
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	u, err := h.Users.GetByID(ctx, r.PathValue("id"))
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	resp, err := userJSONWithPhoto(ctx, h.Users, u)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ===== PATCH /admin/users/{id}
+
+type adminUpdateUserReq struct {
+	Jabatan  *string `json:"jabatan,omitempty"`
+	Office   *string `json:"office,omitempty"` // "" = kosongkan
+	Role     *string `json:"role,omitempty"`
+	Password *string `json:"password,omitempty"`
+	profileFields
+	hrFields
+}
+
+func (h *AdminHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r, h.Users)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+
+	var req adminUpdateUserReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	var patch repo.UserPatch
+	if err := req.profileFields.apply(&patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.hrFields.apply(&patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var photo *string
+	if req.PhotoBase64 != nil {
+		norm, err := normalizePhoto(*req.PhotoBase64)
+		if err != nil {
+			http.Error(w, "invalid photo: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		photo = &norm
+	}
+	if req.Jabatan != nil {
+		v := strings.TrimSpace(*req.Jabatan)
+		if v == "" {
+			http.Error(w, "invalid jabatan", http.StatusBadRequest)
+			return
+		}
+		patch.Jabatan = &v
+	}
+	if req.Office != nil {
+		v := strings.TrimSpace(*req.Office)
+		patch.Office = &v
+	}
+	if req.Role != nil {
+		if !validRole(*req.Role) {
+			http.Error(w, "invalid role", http.StatusBadRequest)
+			return
+		}
+		// jangan sampai admin mencabut akses admin-nya sendiri
+		if id == admin.ID && *req.Role != models.RoleAdmin {
+			http.Error(w, "cannot demote yourself", http.StatusConflict)
+			return
+		}
+		patch.Role = req.Role
+	}
+	if req.Password != nil {
+		if len(*req.Password) < 6 {
+			http.Error(w, "invalid password", http.StatusBadRequest)
+			return
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			http.Error(w, "hash err", http.StatusInternalServerError)
+			return
+		}
+		s := string(hash)
+		patch.PasswordHash = &s
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	cur, err := h.Users.GetByID(ctx, id)
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if err := checkContract(cur, patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if patch.ManagerID != nil && *patch.ManagerID == id {
+		http.Error(w, "user cannot be their own manager", http.StatusBadRequest)
+		return
+	}
+	if !h.managerExists(ctx, patch.ManagerID) {
+		http.Error(w, "unknown manager_id", http.StatusBadRequest)
+		return
+	}
+	// foto ditulis bersama field lain dalam satu UPDATE
+	patch.PhotoBase64 = photo
+
+	u, err := h.Users.Update(ctx, id, patch)
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if isUniqueViolation(err) {
+			http.Error(w, conflictMessage(err), http.StatusConflict)
+			return
+		}
+		if isFKViolation(err) {
+			http.Error(w, "unknown schedule_code", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	// password diganti admin → paksa login ulang
+	if patch.PasswordHash != nil {
+		_ = h.RefreshRepo.RevokeAllForUser(ctx, u.ID)
+	}
+	writeJSON(w, http.StatusOK, userJSON(u))
+}
+
+// ===== POST /admin/users/{id}/deactivate
+
+func (h *AdminHandler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r, h.Users)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	if id == admin.ID {
+		http.Error(w, "cannot deactivate yourself", http.StatusConflict)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	u, err := h.Users.SetActive(ctx, id, false)
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	// refresh token lama tidak boleh dipakai lagi
+	if err := h.RefreshRepo.RevokeAllForUser(ctx, u.ID); err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, userJSON(u))
+}
+
+// ===== POST /admin/users/{id}/reactivate
+
+func (h *AdminHandler) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	u, err := h.Users.SetActive(ctx, r.PathValue("id"), true)
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, userJSON(u))
+}
@@ -1,15 +1,26 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"image"
+	"io"
 	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"absensi/internal/face"
+	"absensi/internal/georisk"
+	"absensi/internal/liveness"
 	"absensi/internal/repo"
+	"absensi/internal/storage"
 	"absensi/internal/util"
 	"absensi/internal/util/imgutil"
 )
@@ -17,6 +28,18 @@ import (
 type AttendanceHandler struct {
 	Users      *repo.UserRepo
 	Attendance *repo.AttendanceRepo
+	Faces      *repo.FaceRepo
+	Flags      *repo.FlagRepo
+	Face       face.Verifier
+	Liveness   *repo.LivenessRepo
+	Live       liveness.Checker
+	Store      storage.Store
+	Hashes     *repo.PhotoHashRepo
+	Presence   *repo.PresenceRepo
+	QR         *repo.QRKioskRepo
+	Devices    *repo.DeviceRepo
+	Schedules  *repo.ScheduleRepo
+	Leaves     *repo.LeaveRepo
 }
 
 type officeCfgResp struct {
@@ -43,6 +66,36 @@ type posReq struct {
 	Lat          float64 `json:"lat"`
 	Lng          float64 `json:"lng"`
 	SelfieBase64 string  `json:"selfie_base64"` // wajib
+
+	// jawaban challenge liveness dari /attendance/status
+	// (multipart: field challenge_id + file selfie & frame)
+	ChallengeID string   `json:"challenge_id,omitempty"`
+	Frames      []string `json:"frames,omitempty"` // base64, urut sesuai waktu ambil
+
+	// sinyal lokasi dari OS perangkat (opsional, dipakai untuk skor risiko spoofing)
+	AccuracyM *float64 `json:"accuracy_m,omitempty"`
+	AltitudeM *float64 `json:"altitude_m,omitempty"`
+	Provider  string   `json:"provider,omitempty"` // gps | network | fused
+	IsMock    bool     `json:"is_mock,omitempty"`
+	FixTime   string   `json:"fix_time,omitempty"` // RFC3339, waktu fix lokasi di perangkat
+
+	// hasil scan perangkat; cocok dengan daftar kantor → sah walau di luar radius
+	WiFiBSSIDs []string `json:"wifi_bssids,omitempty"`
+	BLEBeacons []string `json:"ble_beacons,omitempty"`
+
+	// isi QR kiosk yang discan; lat/lng boleh kosong (perangkat bersama tanpa GPS)
+	QRCode string `json:"qr_code,omitempty"`
+
+	// event offline (hanya POST /attendance/offline), lihat internal/devicesig
+	DeviceID   string `json:"device_id,omitempty"`
+	Event      string `json:"event,omitempty"`
+	CapturedAt string `json:"captured_at,omitempty"` // RFC3339
+	Signature  string `json:"signature,omitempty"`   // base64 ed25519
+
+	// kejadian tambahan (hanya POST /attendance/events); selfie opsional
+	Type    string `json:"type,omitempty"` // visit | break_start | break_end
+	Note    string `json:"note,omitempty"`
+	Outside bool   `json:"outside,omitempty"` // istirahat di luar kantor (kalau jadwal mengizinkan)
 }
 
 func (h *AttendanceHandler) Status(w http.ResponseWriter, r *http.Request) {
@@ -63,7 +116,6 @@ func (h *AttendanceHandler) Status(w http.ResponseWriter, r *http.Request) {
 	}
 
 	now := time.Now().UTC()
-	officeDate := util.OfficeDate(now).Format("2006-01-02")
 
 	dist := util.HaversineMeters(req.Lat, req.Lng, util.OfficeLat, util.OfficeLng)
 	inside := util.InsideRadius(dist)
@@ -77,16 +129,6 @@ func (h *AttendanceHandler) Status(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var checkInAt, checkOutAt *time.Time
-	if day.CheckInAt.Valid {
-		t := day.CheckInAt.Time
-		checkInAt = &t
-	}
-	if day.CheckOutAt.Valid {
-		t := day.CheckOutAt.Time
-		checkOutAt = &t
-	}
-
 	next := ""
 	switch {
 	case !day.CheckInAt.Valid:
@@ -97,16 +139,26 @@ func (h *AttendanceHandler) Status(w http.ResponseWriter, r *http.Request) {
 		next = ""
 	}
 
+	// challenge liveness hanya kalau masih ada absensi yang perlu dilakukan;
+	// token impersonation read-only tidak boleh menulis apa pun
+	var challenge map[string]any
+	if next != "" && !readOnlyToken(r) {
+		challenge, err = h.issueChallenge(ctx, uid)
+		if err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	today := todayJSON(util.OfficeDate(now), day)
+	today["punctuality"] = h.punctualityJSON(ctx, uid, util.OfficeDate(now), day.CheckInAt, day.CheckOutAt)
+
 	writeJSON(w, http.StatusOK, map[string]any{
 		"inside_radius": inside,
 		"distance_m":    round1(dist),
-		"today": map[string]any{
-			"date":           officeDate,
-			"check_in_at":    toRFC3339(checkInAt),
-			"check_out_at":   toRFC3339(checkOutAt),
-			"worked_seconds": day.WorkedSeconds,
-		},
-		"next_action": next,
+		"today":         today,
+		"next_action":   next,
+		"challenge":     challenge,
 	})
 }
 
@@ -121,55 +173,84 @@ func (h *AttendanceHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req posReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+	req, ok := readPunch(w, r)
+	if !ok {
 		return
 	}
-	if strings.TrimSpace(req.SelfieBase64) == "" {
-		http.Error(w, "selfie required", http.StatusBadRequest)
+	jpg := req.Selfie
+
+	dist := util.HaversineMeters(req.Lat, req.Lng, util.OfficeLat, util.OfficeLng)
+	now := time.Now().UTC()
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second) // decode frame + verifikasi wajah
+	defer cancel()
+
+	pr, ok := h.checkPresence(ctx, w, uid, req, dist, util.OfficeDate(now))
+	if !ok {
 		return
 	}
 
-	// normalisasi + resize → JPEG base64
-	normB64, err := imgutil.NormalizeBase64(req.SelfieBase64)
+	gc, err := h.checkGeo(ctx, uid, req, now)
 	if err != nil {
-		http.Error(w, "invalid selfie: "+err.Error(), http.StatusBadRequest)
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if rejectGeo(w, gc) {
 		return
 	}
 
-	dist := util.HaversineMeters(req.Lat, req.Lng, util.OfficeLat, util.OfficeLng)
-	if !util.InsideRadius(dist) {
-		writeJSON(w, 422, map[string]any{
-			"error": map[string]any{
-				"code":    "outside_radius",
-				"details": map[string]any{"distance_m": round1(dist), "radius_m": util.OfficeRadiusM},
-			},
-		})
+	ec := checkExif(req.Meta, now)
+	if rejectExif(w, ec) {
 		return
 	}
 
-	now := time.Now().UTC()
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
-	defer cancel()
+	live, ok := h.checkLiveness(ctx, w, uid, req.ChallengeID, jpg, req.Frames)
+	if !ok {
+		return
+	}
 
-	// NOTE: repo perlu diubah terima argumen foto (lihat catatan di bawah)
-	ad, err := h.Attendance.DoCheckIn(ctx, uid, util.OfficeDate(now), now, req.Lat, req.Lng, dist, normB64)
+	fc, err := h.checkFace(ctx, uid, jpg)
 	if err != nil {
+		http.Error(w, "face verification error", http.StatusInternalServerError)
+		return
+	}
+	if rejectFace(w, fc) {
+		return
+	}
+
+	photoKey, err := h.putPhoto(ctx, uid, util.OfficeDate(now), "check_in", jpg)
+	if err != nil {
+		http.Error(w, "storage error", http.StatusInternalServerError)
+		return
+	}
+
+	ad, err := h.Attendance.DoCheckIn(ctx, uid, util.OfficeDate(now), now, repo.Punch{
+		Lat: req.Lat, Lng: req.Lng, DistanceM: dist, NoLocation: !req.HasLoc, PhotoKey: photoKey,
+		FaceScore: fc.Score, FaceStatus: fc.Status, Liveness: live,
+		Exif: ec.json(), RiskScore: gc.Score, Geo: gc.json(),
+		Presence: pr.Method, PresenceRef: pr.Ref, Mode: pr.mode(),
+	})
+	if err != nil {
+		_ = storage.DeleteImage(ctx, h.Store, photoKey)
 		// kemungkinan sudah check-in
 		writeJSON(w, 409, map[string]any{"error": map[string]any{"code": "already_checked_in"}})
 		return
 	}
+	h.flagFace(ctx, uid, util.OfficeDate(now), "check_in", fc)
+	h.flagExif(ctx, uid, util.OfficeDate(now), "check_in", ec)
+	h.flagGeo(ctx, uid, util.OfficeDate(now), "check_in", gc)
+	h.flagDuplicate(ctx, uid, util.OfficeDate(now), "check_in", jpg)
+
+	today := todayJSON(util.OfficeDate(now), ad)
+	today["punctuality"] = h.punctualityJSON(ctx, uid, util.OfficeDate(now), ad.CheckInAt, ad.CheckOutAt)
 
 	writeJSON(w, http.StatusCreated, map[string]any{
-		"result":     "checked_in",
-		"distance_m": round1(dist),
-		"today": map[string]any{
-			"date":           util.OfficeDate(now).Format("2006-01-02"),
-			"check_in_at":    toRFC3339(optTime(ad.CheckInAt)),
-			"check_out_at":   toRFC3339(optTime(ad.CheckOutAt)),
-			"worked_seconds": ad.WorkedSeconds,
-		},
+		"result":      "checked_in",
+		"distance_m":  distJSON(req, dist),
+		"presence":    pr.json(),
+		"face":        fc.json(),
+		"exif":        ec.json(),
+		"location":    gc.json(),
+		"today":       today,
 		"next_action": "check_out",
 	})
 }
@@ -184,58 +265,361 @@ func (h *AttendanceHandler) CheckOut(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req posReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+	req, ok := readPunch(w, r)
+	if !ok {
 		return
 	}
-	if strings.TrimSpace(req.SelfieBase64) == "" {
-		http.Error(w, "selfie required", http.StatusBadRequest)
+	jpg := req.Selfie
+
+	dist := util.HaversineMeters(req.Lat, req.Lng, util.OfficeLat, util.OfficeLng)
+	now := time.Now().UTC()
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	pr, ok := h.checkPresence(ctx, w, uid, req, dist, util.OfficeDate(now))
+	if !ok {
 		return
 	}
 
-	normB64, err := imgutil.NormalizeBase64(req.SelfieBase64)
+	gc, err := h.checkGeo(ctx, uid, req, now)
 	if err != nil {
-		http.Error(w, "invalid selfie: "+err.Error(), http.StatusBadRequest)
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if rejectGeo(w, gc) {
 		return
 	}
 
-	dist := util.HaversineMeters(req.Lat, req.Lng, util.OfficeLat, util.OfficeLng)
-	if !util.InsideRadius(dist) {
-		writeJSON(w, 422, map[string]any{
-			"error": map[string]any{
-				"code":    "outside_radius",
-				"details": map[string]any{"distance_m": round1(dist), "radius_m": util.OfficeRadiusM},
-			},
-		})
+	ec := checkExif(req.Meta, now)
+	if rejectExif(w, ec) {
 		return
 	}
 
-	now := time.Now().UTC()
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
-	defer cancel()
+	live, ok := h.checkLiveness(ctx, w, uid, req.ChallengeID, jpg, req.Frames)
+	if !ok {
+		return
+	}
 
-	// NOTE: repo perlu diubah terima argumen foto (lihat catatan di bawah)
-	ad, err := h.Attendance.DoCheckOut(ctx, uid, util.OfficeDate(now), now, req.Lat, req.Lng, dist, normB64)
+	fc, err := h.checkFace(ctx, uid, jpg)
 	if err != nil {
+		http.Error(w, "face verification error", http.StatusInternalServerError)
+		return
+	}
+	if rejectFace(w, fc) {
+		return
+	}
+
+	photoKey, err := h.putPhoto(ctx, uid, util.OfficeDate(now), "check_out", jpg)
+	if err != nil {
+		http.Error(w, "storage error", http.StatusInternalServerError)
+		return
+	}
+
+	ad, err := h.Attendance.DoCheckOut(ctx, uid, util.OfficeDate(now), now, repo.Punch{
+		Lat: req.Lat, Lng: req.Lng, DistanceM: dist, NoLocation: !req.HasLoc, PhotoKey: photoKey,
+		FaceScore: fc.Score, FaceStatus: fc.Status, Liveness: live,
+		Exif: ec.json(), RiskScore: gc.Score, Geo: gc.json(),
+		Presence: pr.Method, PresenceRef: pr.Ref, Mode: pr.mode(),
+	})
+	if err != nil {
+		_ = storage.DeleteImage(ctx, h.Store, photoKey)
 		// belum check-in atau sudah check-out
 		writeJSON(w, 409, map[string]any{"error": map[string]any{"code": "not_checked_in_yet_or_already_checked_out"}})
 		return
 	}
+	h.flagFace(ctx, uid, util.OfficeDate(now), "check_out", fc)
+	h.flagExif(ctx, uid, util.OfficeDate(now), "check_out", ec)
+	h.flagGeo(ctx, uid, util.OfficeDate(now), "check_out", gc)
+	h.flagDuplicate(ctx, uid, util.OfficeDate(now), "check_out", jpg)
+	h.flagOpenBreak(ctx, uid, util.OfficeDate(now), ad)
+
+	today := todayJSON(util.OfficeDate(now), ad)
+	today["punctuality"] = h.punctualityJSON(ctx, uid, util.OfficeDate(now), ad.CheckInAt, ad.CheckOutAt)
 
 	writeJSON(w, http.StatusOK, map[string]any{
-		"result":     "checked_out",
-		"distance_m": round1(dist),
-		"today": map[string]any{
-			"date":           util.OfficeDate(now).Format("2006-01-02"),
-			"check_in_at":    toRFC3339(optTime(ad.CheckInAt)),
-			"check_out_at":   toRFC3339(optTime(ad.CheckOutAt)),
-			"worked_seconds": ad.WorkedSeconds,
-		},
+		"result":      "checked_out",
+		"distance_m":  distJSON(req, dist),
+		"presence":    pr.json(),
+		"face":        fc.json(),
+		"exif":        ec.json(),
+		"location":    gc.json(),
+		"today":       today,
 		"next_action": nil,
 	})
 }
 
+// todayJSON: ringkasan hari ini di response status / absen.
+// worked_seconds = bruto (dipertahankan untuk klien lama).
+func todayJSON(date time.Time, ad repo.AttendanceDay) map[string]any {
+	return map[string]any{
+		"date":                 date.Format("2006-01-02"),
+		"check_in_at":          toRFC3339(optTime(ad.CheckInAt)),
+		"check_out_at":         toRFC3339(optTime(ad.CheckOutAt)),
+		"worked_seconds":       ad.WorkedSeconds,
+		"gross_worked_seconds": ad.WorkedSeconds,
+		"break_seconds":        ad.BreakSeconds,
+		"net_worked_seconds":   ad.NetWorkedSeconds,
+		"mode":                 modeJSON(ad.Mode),
+	}
+}
+
+// modeJSON: "" (belum check-in) → null.
+func modeJSON(mode string) any {
+	if mode == "" {
+		return nil
+	}
+	return mode
+}
+
+// punchInput: isi request check-in / check-out, dari JSON atau multipart.
+type punchInput struct {
+	Lat, Lng    float64
+	Geo         georisk.Signals // Lat/Lng ikut diisi saat readPunch selesai
+	WiFi, BLE   []string        // BSSID / beacon hasil scan, sudah dinormalisasi
+	QRCode      string          // payload QR kiosk
+	HasLoc      bool            // lat/lng dikirim (bukan 0,0)
+	Offline     offlineFields   // hanya /attendance/offline
+	Type, Note  string          // hanya /attendance/events
+	Outside     bool            // hanya /attendance/events
+	ChallengeID string
+	Selfie      []byte        // JPEG hasil normalisasi
+	SelfieSHA   string        // sha256 hex byte selfie asli (sebelum normalisasi)
+	Meta        imgutil.Meta  // EXIF selfie asli
+	Frames      []image.Image // jawaban challenge liveness
+}
+
+// readPunch: terima JSON posReq (base64) atau multipart/form-data dengan
+// field lat, lng, challenge_id + file selfie dan frame (boleh berulang).
+// ok=false → response sudah ditulis.
+func readPunch(w http.ResponseWriter, r *http.Request) (punchInput, bool) {
+	return readPunchInput(w, r, true)
+}
+
+func readPunchInput(w http.ResponseWriter, r *http.Request, selfieRequired bool) (punchInput, bool) {
+	limitBody(w, r)
+	var in punchInput
+
+	if isMultipart(r) {
+		err := readMultipart(r, func(name, v string) error {
+			switch name {
+			case "lat", "lng":
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					return badUpload("invalid %s", name)
+				}
+				if name == "lat" {
+					in.Lat = f
+				} else {
+					in.Lng = f
+				}
+			case "challenge_id":
+				in.ChallengeID = v
+			case "accuracy_m", "altitude_m":
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					return badUpload("invalid %s", name)
+				}
+				if name == "accuracy_m" {
+					in.Geo.AccuracyM = &f
+				} else {
+					in.Geo.AltitudeM = &f
+				}
+			case "provider":
+				in.Geo.Provider = v
+			case "is_mock":
+				b, err := strconv.ParseBool(v)
+				if err != nil {
+					return badUpload("invalid is_mock")
+				}
+				in.Geo.Mock = b
+			case "fix_time":
+				t, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					return badUpload("invalid fix_time")
+				}
+				in.Geo.FixTime = t
+			case "wifi_bssid", "ble_beacon":
+				return in.addScanned(name, v)
+			case "qr_code":
+				in.QRCode = v
+			case "device_id", "event", "captured_at", "signature":
+				return in.Offline.set(name, v)
+			case "type":
+				in.Type = v
+			case "note":
+				in.Note = v
+			case "outside":
+				b, err := strconv.ParseBool(v)
+				if err != nil {
+					return badUpload("invalid outside")
+				}
+				in.Outside = b
+			}
+			return nil
+		}, func(name string, body io.Reader) error {
+			switch name {
+			case "selfie":
+				sum := sha256.New()
+				tee := io.TeeReader(body, sum)
+				jpg, meta, err := imgutil.NormalizeReaderMeta(tee, util.OfficeTZ)
+				if err != nil {
+					return imageUploadErr("selfie", err)
+				}
+				if _, err := io.Copy(io.Discard, tee); err != nil { // sisa file setelah decoder berhenti
+					return err
+				}
+				in.Selfie, in.Meta = jpg, meta
+				in.SelfieSHA = hex.EncodeToString(sum.Sum(nil))
+			case "frame":
+				if len(in.Frames) >= liveness.MaxFrames {
+					return badUpload("too many frames")
+				}
+				img, err := imgutil.DecodeReader(body)
+				if err != nil {
+					return imageUploadErr("frame", err)
+				}
+				in.Frames = append(in.Frames, img)
+			}
+			return nil
+		})
+		if err != nil {
+			writeUploadErr(w, err)
+			return in, false
+		}
+	} else {
+		var req posReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			var tooBig *http.MaxBytesError
+			if errors.As(err, &tooBig) {
+				writeUploadErr(w, err)
+				return in, false
+			}
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return in, false
+		}
+		in.Lat, in.Lng, in.ChallengeID, in.QRCode = req.Lat, req.Lng, req.ChallengeID, req.QRCode
+		in.Type, in.Note, in.Outside = req.Type, req.Note, req.Outside
+		in.Geo = georisk.Signals{
+			AccuracyM: req.AccuracyM, AltitudeM: req.AltitudeM,
+			Provider: req.Provider, Mock: req.IsMock,
+		}
+		if req.FixTime != "" {
+			t, err := time.Parse(time.RFC3339, req.FixTime)
+			if err != nil {
+				http.Error(w, "invalid fix_time", http.StatusBadRequest)
+				return in, false
+			}
+			in.Geo.FixTime = t
+		}
+		for name, v := range map[string]string{
+			"device_id": req.DeviceID, "event": req.Event, "captured_at": req.CapturedAt, "signature": req.Signature,
+		} {
+			if v == "" {
+				continue
+			}
+			if err := in.Offline.set(name, v); err != nil {
+				writeUploadErr(w, err)
+				return in, false
+			}
+		}
+		for _, v := range req.WiFiBSSIDs {
+			if err := in.addScanned("wifi_bssid", v); err != nil {
+				writeUploadErr(w, err)
+				return in, false
+			}
+		}
+		for _, v := range req.BLEBeacons {
+			if err := in.addScanned("ble_beacon", v); err != nil {
+				writeUploadErr(w, err)
+				return in, false
+			}
+		}
+
+		if strings.TrimSpace(req.SelfieBase64) != "" {
+			// normalisasi + resize → JPEG (EXIF dibaca dulu sebelum hilang)
+			raw, err := imgutil.DecodeRaw(req.SelfieBase64)
+			if err != nil {
+				http.Error(w, "invalid selfie: "+err.Error(), http.StatusBadRequest)
+				return in, false
+			}
+			jpg, meta, err := imgutil.NormalizeReaderMeta(bytes.NewReader(raw), util.OfficeTZ)
+			if err != nil {
+				http.Error(w, "invalid selfie: "+err.Error(), http.StatusBadRequest)
+				return in, false
+			}
+			in.Selfie, in.Meta = jpg, meta
+			sum := sha256.Sum256(raw)
+			in.SelfieSHA = hex.EncodeToString(sum[:])
+		}
+		if len(req.Frames) > liveness.MaxFrames {
+			http.Error(w, "too many frames", http.StatusBadRequest)
+			return in, false
+		}
+		for _, f := range req.Frames {
+			img, err := imgutil.DecodeBase64(f)
+			if err != nil {
+				http.Error(w, "invalid frame: "+err.Error(), http.StatusBadRequest)
+				return in, false
+			}
+			in.Frames = append(in.Frames, img)
+		}
+	}
+
+	if selfieRequired && len(in.Selfie) == 0 {
+		http.Error(w, "selfie required", http.StatusBadRequest)
+		return in, false
+	}
+	in.Geo.Lat, in.Geo.Lng = in.Lat, in.Lng
+	in.HasLoc = in.Lat != 0 || in.Lng != 0
+	return in, true
+}
+
+// distJSON: jarak ke kantor, null kalau perangkat tidak mengirim lokasi.
+func distJSON(in punchInput, dist float64) any {
+	if !in.HasLoc {
+		return nil
+	}
+	return round1(dist)
+}
+
+// addScanned: normalisasi + simpan satu BSSID (wifi_bssid) / beacon (ble_beacon).
+func (in *punchInput) addScanned(field, v string) error {
+	kind, list := presenceWiFi, &in.WiFi
+	if field == "ble_beacon" {
+		kind, list = presenceBLE, &in.BLE
+	}
+	if len(*list) >= maxScanned {
+		return badUpload("too many %s", field)
+	}
+	id, err := normProof(kind, v)
+	if err != nil {
+		return badUpload("invalid %s", field)
+	}
+	*list = append(*list, id)
+	return nil
+}
+
+// imageUploadErr: gambar tidak valid → 400, kecuali body melebihi batas.
+func imageUploadErr(field string, err error) error {
+	var tooBig *http.MaxBytesError
+	if errors.As(err, &tooBig) {
+		return err
+	}
+	return badUpload("invalid %s: %v", field, err)
+}
+
+// putPhoto: simpan selfie (+ varian thumb/medium) ke blob store, key per user & tanggal.
+func (h *AttendanceHandler) putPhoto(ctx context.Context, userID string, date time.Time, event string, jpg []byte) (string, error) {
+	key, err := storage.NewKey("attendance/"+userID+"/"+date.Format("2006-01-02")+"/"+event, "jpg")
+	if err != nil {
+		return "", err
+	}
+	if err := storage.PutImage(ctx, h.Store, key, jpg); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
 // ===== helpers (auth & json) =====
 
 func mustAuth(w http.ResponseWriter, r *http.Request) (userID, username string, ok bool) {
@@ -253,6 +637,13 @@ func mustAuth(w http.ResponseWriter, r *http.Request) (userID, username string,
 	return uid, usr, true
 }
 
+// readOnlyToken: bearer token impersonation read-only (lihat ImpersonationHandler).
+func readOnlyToken(r *http.Request) bool {
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	c, err := util.ParseAccessClaims(token)
+	return err == nil && c.ReadOnly
+}
+
 func writeJSON(w http.ResponseWriter, code int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
@@ -276,3 +667,7 @@ func optTime(nt sql.NullTime) *time.Time {
 func round1(f float64) float64 {
 	return math.Round(f*10) / 10
 }
+
+func round3(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"absensi/internal/repo"
+	"absensi/internal/util"
+)
+
+// checkDinas: selama perjalanan dinas yang disetujui, lokasi harus dalam
+// geofence tujuan (bukan kantor).
+func checkDinas(w http.ResponseWriter, in punchInput, dr repo.DinasRequest) (presence, bool) {
+	if !in.HasLoc {
+		writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "location_required"}})
+		return presence{}, false
+	}
+	d := util.HaversineMeters(in.Lat, in.Lng, dr.Lat, dr.Lng)
+	if d > dr.RadiusM {
+		writeJSON(w, 422, map[string]any{
+			"error": map[string]any{
+				"code": "outside_trip_radius",
+				"details": map[string]any{
+					"distance_m":       round1(d),
+					"radius_m":         dr.RadiusM,
+					"destination":      dr.Destination,
+					"dinas_request_id": dr.ID,
+				},
+			},
+		})
+		return presence{}, false
+	}
+	return presence{Method: presenceDinas, Ref: dr.ID}, true
+}
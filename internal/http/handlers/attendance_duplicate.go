@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"absensi/internal/util"
+	"absensi/internal/util/imgutil"
+)
+
+// flagDuplicate: bandingkan dHash selfie dengan foto absensi sebelumnya
+// (user mana pun); yang terlalu mirip masuk antrian review sebagai
+// duplicate_photo. Hash foto ini lalu disimpan untuk pembanding berikutnya.
+// Tidak pernah menggagalkan absensi; error cukup di-log.
+func (h *AttendanceHandler) flagDuplicate(ctx context.Context, userID string, date time.Time, event string, jpg []byte) {
+	hash, err := imgutil.DHashJPEG(jpg)
+	if err != nil {
+		log.Println("photo hash:", err)
+		return
+	}
+
+	matches, err := h.Hashes.FindSimilar(ctx, hash, util.PhotoHashMaxDistance(), time.Now().Add(-util.PhotoHashLookback()), 5)
+	if err != nil {
+		log.Println("find similar photos:", err)
+	} else if len(matches) > 0 {
+		items := make([]map[string]any, 0, len(matches))
+		for _, m := range matches {
+			items = append(items, map[string]any{
+				"user_id":   m.UserID,
+				"username":  m.Username,
+				"date":      m.Date.Format("2006-01-02"),
+				"event":     m.Event,
+				"distance":  m.Distance,
+				"same_user": m.UserID == userID,
+			})
+		}
+		if _, err := h.Flags.Create(ctx, userID, date, event, "duplicate_photo", map[string]any{
+			"max_distance": util.PhotoHashMaxDistance(),
+			"matches":      items,
+		}); err != nil {
+			log.Println("create duplicate flag:", err)
+		}
+	}
+
+	if err := h.Hashes.Insert(ctx, userID, date, event, hash); err != nil {
+		log.Println("store photo hash:", err)
+	}
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"absensi/internal/models"
+	"absensi/internal/repo"
+	"absensi/internal/storage"
+	"absensi/internal/util"
+)
+
+const maxEventNote = 500
+
+// ===== POST /attendance/events
+// JSON posReq / multipart dengan type=visit|break_start|break_end, note,
+// lat/lng, selfie (opsional, foto lokasi / bukti kunjungan).
+// Istirahat harus dari lokasi kantor (sama seperti check-in) kecuali
+// outside=true dan jadwal kerja user mengizinkan (break_outside); kunjungan
+// klien boleh di mana saja. Keduanya wajib mengirim lokasi kalau di luar.
+// Lama istirahat mengikuti break_min_minutes / break_max_minutes jadwal.
+
+func (h *AttendanceHandler) PostEvent(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+	req, ok := readPunchInput(w, r, false)
+	if !ok {
+		return
+	}
+	switch req.Type {
+	case repo.EventVisit, repo.EventBreakStart, repo.EventBreakEnd:
+	default:
+		http.Error(w, "invalid type", http.StatusBadRequest)
+		return
+	}
+	note := strings.TrimSpace(req.Note)
+	if len(note) > maxEventNote {
+		http.Error(w, "note too long", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC()
+	date := util.OfficeDate(now)
+	dist := util.HaversineMeters(req.Lat, req.Lng, util.OfficeLat, util.OfficeLng)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	ws, hasSchedule, err := h.userSchedule(ctx, uid)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	isBreak := req.Type != repo.EventVisit
+	if isBreak && req.Outside && !(hasSchedule && ws.BreakOutside) {
+		writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "break_outside_not_allowed"}})
+		return
+	}
+
+	var pr presence
+	if !isBreak || req.Outside {
+		if !req.HasLoc {
+			writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "location_required"}})
+			return
+		}
+		pr = presence{Method: presenceGPS}
+	} else {
+		if pr, ok = h.checkPresence(ctx, w, uid, req, dist, date); !ok {
+			return
+		}
+	}
+
+	gc, err := h.checkGeo(ctx, uid, req, now)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if rejectGeo(w, gc) {
+		return
+	}
+
+	ev := repo.AttendanceEvent{
+		Type:     req.Type,
+		At:       now,
+		Note:     sql.NullString{String: note, Valid: note != ""},
+		Presence: sql.NullString{String: pr.Method, Valid: true},
+		Details:  gc.json(),
+	}
+	if req.HasLoc {
+		ev.Lat = sql.NullFloat64{Float64: req.Lat, Valid: true}
+		ev.Lng = sql.NullFloat64{Float64: req.Lng, Valid: true}
+		ev.DistanceM = sql.NullFloat64{Float64: dist, Valid: true}
+	}
+	if len(req.Selfie) > 0 {
+		key, err := h.putPhoto(ctx, uid, date, req.Type, req.Selfie)
+		if err != nil {
+			http.Error(w, "storage error", http.StatusInternalServerError)
+			return
+		}
+		ev.PhotoKey = sql.NullString{String: key, Valid: true}
+	}
+
+	var minBreak time.Duration
+	if hasSchedule {
+		minBreak = ws.BreakMin()
+	}
+	ev, dur, err := h.Attendance.AddEvent(ctx, uid, date, ev, minBreak)
+	if err != nil {
+		if ev.PhotoKey.Valid {
+			_ = storage.DeleteImage(ctx, h.Store, ev.PhotoKey.String)
+		}
+		code := ""
+		switch {
+		case errors.Is(err, repo.ErrNotCheckedIn):
+			code = "not_checked_in_yet"
+		case errors.Is(err, repo.ErrAlreadyCheckedOut):
+			code = "already_checked_out"
+		case errors.Is(err, repo.ErrBreakOpen):
+			code = "break_in_progress"
+		case errors.Is(err, repo.ErrNoOpenBreak):
+			code = "no_break_in_progress"
+		case errors.Is(err, repo.ErrBreakTooShort):
+			writeJSON(w, 422, map[string]any{
+				"error": map[string]any{
+					"code": "break_too_short",
+					"details": map[string]any{
+						"min_minutes":     ws.BreakMinMinutes,
+						"elapsed_seconds": int64(dur.Seconds()),
+						"earliest_end_at": now.Add(minBreak - dur).Format(time.RFC3339),
+					},
+				},
+			})
+			return
+		default:
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, 409, map[string]any{"error": map[string]any{"code": code}})
+		return
+	}
+	h.flagGeo(ctx, uid, date, req.Type, gc)
+
+	resp := map[string]any{
+		"result":     req.Type,
+		"id":         ev.ID,
+		"at":         ev.At.UTC().Format(time.RFC3339),
+		"distance_m": distJSON(req, dist),
+		"presence":   pr.json(),
+		"location":   gc.json(),
+		"note":       note,
+	}
+	if req.Type == repo.EventBreakEnd {
+		resp["break_seconds"] = int64(dur.Seconds())
+		if hasSchedule && ws.BreakMaxMinutes > 0 && dur > ws.BreakMax() {
+			over := int64((dur - ws.BreakMax()).Seconds())
+			resp["overrun_seconds"] = over
+			h.flagLongBreak(ctx, uid, date, ev, dur, ws)
+		}
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// userSchedule: jadwal kerja user; ok=false kalau belum diatur.
+func (h *AttendanceHandler) userSchedule(ctx context.Context, uid string) (models.WorkSchedule, bool, error) {
+	u, err := h.Users.GetByID(ctx, uid)
+	if err != nil {
+		return models.WorkSchedule{}, false, err
+	}
+	if u.ScheduleCode == "" {
+		return models.WorkSchedule{}, false, nil
+	}
+	ws, err := h.Schedules.GetByCode(ctx, u.ScheduleCode)
+	if err != nil {
+		if isNotFound(err) {
+			return models.WorkSchedule{}, false, nil
+		}
+		return models.WorkSchedule{}, false, err
+	}
+	return ws, true, nil
+}
+
+// flagLongBreak: istirahat melebihi break_max_minutes → antrian review.
+func (h *AttendanceHandler) flagLongBreak(ctx context.Context, userID string, date time.Time, ev repo.AttendanceEvent, dur time.Duration, ws models.WorkSchedule) {
+	details := map[string]any{
+		"event_id":      ev.ID,
+		"break_seconds": int64(dur.Seconds()),
+		"max_minutes":   ws.BreakMaxMinutes,
+		"schedule":      ws.Code,
+	}
+	if _, err := h.Flags.Create(ctx, userID, date, ev.Type, "long_break", details); err != nil {
+		log.Println("create long break flag:", err)
+	}
+}
+
+// flagOpenBreak: istirahat yang belum ditutup saat check-out berakhir di jam
+// check-out; dinilai terhadap break_max_minutes / break_min_minutes jadwal
+// (check-out tidak ditolak, tapi masuk antrian review).
+func (h *AttendanceHandler) flagOpenBreak(ctx context.Context, userID string, date time.Time, ad repo.AttendanceDay) {
+	if !ad.OpenBreakAt.Valid || !ad.CheckOutAt.Valid {
+		return
+	}
+	ws, ok, err := h.userSchedule(ctx, userID)
+	if err != nil {
+		log.Println("open break schedule:", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	dur := ad.CheckOutAt.Time.Sub(ad.OpenBreakAt.Time)
+	details := map[string]any{
+		"break_start_at": ad.OpenBreakAt.Time.UTC().Format(time.RFC3339),
+		"break_seconds":  int64(dur.Seconds()),
+		"closed_by":      repo.EventCheckOut,
+		"schedule":       ws.Code,
+	}
+	var reason string
+	switch {
+	case ws.BreakMaxMinutes > 0 && dur > ws.BreakMax():
+		reason = "long_break"
+		details["max_minutes"] = ws.BreakMaxMinutes
+	case ws.BreakMinMinutes > 0 && dur < ws.BreakMin():
+		reason = "short_break"
+		details["min_minutes"] = ws.BreakMinMinutes
+	default:
+		return
+	}
+	if _, err := h.Flags.Create(ctx, userID, date, repo.EventCheckOut, reason, details); err != nil {
+		log.Println("create open break flag:", err)
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"absensi/internal/util"
+	"absensi/internal/util/imgutil"
+)
+
+// Status pemeriksaan EXIF selfie.
+const (
+	exifOK      = "ok"
+	exifSkewed  = "skewed"  // capture time terlalu jauh dari waktu server
+	exifMissing = "missing" // tanpa EXIF / tanpa capture time (umum di kamera web)
+)
+
+type exifCheck struct {
+	Meta    imgutil.Meta
+	Status  string
+	SkewSec int64
+}
+
+func checkExif(meta imgutil.Meta, now time.Time) exifCheck {
+	ec := exifCheck{Meta: meta, Status: exifMissing}
+	if meta.CaptureTime.IsZero() {
+		return ec
+	}
+	skew := now.Sub(meta.CaptureTime)
+	if skew < 0 {
+		skew = -skew
+	}
+	ec.SkewSec = int64(skew.Seconds())
+	ec.Status = exifOK
+	if skew > util.ExifMaxSkew() {
+		ec.Status = exifSkewed
+	}
+	return ec
+}
+
+// json: bentuk yang disimpan di kolom *_exif dan dikembalikan ke klien.
+func (ec exifCheck) json() map[string]any {
+	m := map[string]any{"status": ec.Status}
+	if !ec.Meta.CaptureTime.IsZero() {
+		m["capture_time"] = ec.Meta.CaptureTime.Format(time.RFC3339)
+		m["capture_tz_known"] = ec.Meta.HasOffset
+		m["skew_seconds"] = ec.SkewSec
+	}
+	if ec.Meta.Make != "" {
+		m["make"] = ec.Meta.Make
+	}
+	if ec.Meta.Model != "" {
+		m["model"] = ec.Meta.Model
+	}
+	if ec.Meta.Software != "" {
+		m["software"] = ec.Meta.Software
+	}
+	if ec.Meta.Orientation != 0 {
+		m["orientation"] = ec.Meta.Orientation
+	}
+	return m
+}
+
+// rejectExif: tulis 422 kalau EXIF_SKEW_ACTION=reject. true = sudah direspon.
+func rejectExif(w http.ResponseWriter, ec exifCheck) bool {
+	if ec.Status != exifSkewed || util.ExifSkewAction() != "reject" {
+		return false
+	}
+	writeJSON(w, 422, map[string]any{
+		"error": map[string]any{
+			"code": "stale_photo",
+			"details": map[string]any{
+				"capture_time":     ec.Meta.CaptureTime.Format(time.RFC3339),
+				"skew_seconds":     ec.SkewSec,
+				"max_skew_seconds": int64(util.ExifMaxSkew().Seconds()),
+			},
+		},
+	})
+	return true
+}
+
+// flagExif: foto lama tapi absensi diterima → antrian review.
+func (h *AttendanceHandler) flagExif(ctx context.Context, userID string, date time.Time, event string, ec exifCheck) {
+	if ec.Status != exifSkewed {
+		return
+	}
+	details := ec.json()
+	details["max_skew_seconds"] = int64(util.ExifMaxSkew().Seconds())
+	if _, err := h.Flags.Create(ctx, userID, date, event, "stale_photo", details); err != nil {
+		log.Println("create exif flag:", err)
+	}
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"absensi/internal/face"
+	"absensi/internal/util/imgutil"
+)
+
+// faceCheck: hasil verifikasi wajah satu selfie.
+type faceCheck struct {
+	Score  sql.NullFloat64
+	Status string
+}
+
+func (fc faceCheck) json() map[string]any {
+	var score any
+	if fc.Score.Valid {
+		score = round3(fc.Score.Float64)
+	}
+	return map[string]any{"status": fc.Status, "score": score}
+}
+
+// checkFace: bandingkan selfie (hasil imgutil.NormalizeJPEG) dengan foto
+// referensi user. Tanpa enrollment → StatusNotEnrolled.
+func (h *AttendanceHandler) checkFace(ctx context.Context, userID string, jpg []byte) (faceCheck, error) {
+	ref, ok, err := h.Faces.Get(ctx, userID)
+	if err != nil {
+		return faceCheck{}, err
+	}
+	if !ok {
+		return faceCheck{Status: face.StatusNotEnrolled}, nil
+	}
+
+	refImg, err := imgutil.DecodeBase64(ref.PhotoB64)
+	if err != nil {
+		return faceCheck{}, err
+	}
+	probe, err := imgutil.Decode(jpg)
+	if err != nil {
+		return faceCheck{}, err
+	}
+	score, err := h.Face.Compare(ctx, refImg, probe)
+	if err != nil {
+		return faceCheck{}, err
+	}
+
+	fc := faceCheck{Score: sql.NullFloat64{Float64: score, Valid: true}, Status: face.StatusMatch}
+	if score < face.Threshold() {
+		fc.Status = face.StatusMismatch
+	}
+	return fc, nil
+}
+
+// rejectFace: tulis 422 kalau kebijakan menolak hasil ini. true = sudah direspon.
+func rejectFace(w http.ResponseWriter, fc faceCheck) bool {
+	switch {
+	case fc.Status == face.StatusNotEnrolled && face.RequireEnrollment():
+		writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "face_not_enrolled"}})
+		return true
+	case fc.Status == face.StatusMismatch && face.MismatchAction() == face.ActionReject:
+		writeJSON(w, 422, map[string]any{
+			"error": map[string]any{
+				"code":    "face_mismatch",
+				"details": map[string]any{"score": round3(fc.Score.Float64), "threshold": face.Threshold()},
+			},
+		})
+		return true
+	}
+	return false
+}
+
+// flagFace: wajah tidak cocok tapi absensi diterima → masuk antrian review.
+func (h *AttendanceHandler) flagFace(ctx context.Context, userID string, date time.Time, event string, fc faceCheck) {
+	if fc.Status != face.StatusMismatch {
+		return
+	}
+	if _, err := h.Flags.Create(ctx, userID, date, event, "face_mismatch", map[string]any{
+		"score":     round3(fc.Score.Float64),
+		"threshold": face.Threshold(),
+	}); err != nil {
+		log.Println("create face flag:", err)
+	}
+}
+
+type faceEnrollReq struct {
+	PhotoBase64 string `json:"photo_base64"`
+}
+
+func decodeEnrollPhoto(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req faceEnrollReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return "", false
+	}
+	if strings.TrimSpace(req.PhotoBase64) == "" {
+		http.Error(w, "photo_base64 required", http.StatusBadRequest)
+		return "", false
+	}
+	norm, err := imgutil.NormalizeBase64(req.PhotoBase64)
+	if err != nil {
+		http.Error(w, "invalid photo: "+err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+	return norm, true
+}
+
+// ===== GET /me/face
+
+func (h *AttendanceHandler) GetMyFace(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	fe, enrolled, err := h.Faces.Get(ctx, uid)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]any{"enrolled": enrolled, "enrolled_at": nil}
+	if enrolled {
+		resp["enrolled_at"] = fe.EnrolledAt.UTC().Format(time.RFC3339)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ===== POST /me/face  (hanya sekali; ganti foto harus lewat admin)
+
+func (h *AttendanceHandler) EnrollMyFace(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+	norm, ok := decodeEnrollPhoto(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	created, err := h.Faces.Enroll(ctx, uid, norm, uid, false)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !created {
+		writeJSON(w, 409, map[string]any{"error": map[string]any{"code": "already_enrolled"}})
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"result": "enrolled"})
+}
+
+// ===== PUT /admin/users/{id}/face
+
+func (h *AttendanceHandler) AdminEnrollFace(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r, h.Users)
+	if !ok {
+		return
+	}
+	norm, ok := decodeEnrollPhoto(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	if _, err := h.Users.GetByID(ctx, id); err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if _, err := h.Faces.Enroll(ctx, id, norm, admin.ID, true); err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"result": "enrolled", "user_id": id})
+}
+
+// ===== DELETE /admin/users/{id}/face
+
+func (h *AttendanceHandler) AdminDeleteFace(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	deleted, err := h.Faces.Delete(ctx, r.PathValue("id"))
+	if err != nil && !isNotFound(err) {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
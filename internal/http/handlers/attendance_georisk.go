@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"absensi/internal/georisk"
+	"absensi/internal/util"
+)
+
+// geoCheck: sinyal lokasi perangkat + penilaian risikonya.
+type geoCheck struct {
+	Signals georisk.Signals
+	Skipped bool // perangkat tidak mengirim lokasi (QR / Wi-Fi tanpa GPS)
+	georisk.Assessment
+}
+
+// checkGeo: nilai lokasi dibanding kantor & lokasi absen terakhir user.
+func (h *AttendanceHandler) checkGeo(ctx context.Context, userID string, in punchInput, now time.Time) (geoCheck, error) {
+	if !in.HasLoc {
+		return geoCheck{Skipped: true}, nil
+	}
+	s := in.Geo
+	var prev *georisk.Previous
+	lat, lng, at, ok, err := h.Attendance.LastLocation(ctx, userID, now)
+	if err != nil {
+		return geoCheck{}, err
+	}
+	if ok {
+		prev = &georisk.Previous{Lat: lat, Lng: lng, At: at}
+	}
+	return geoCheck{
+		Signals:    s,
+		Assessment: georisk.Assess(s, util.OfficeLat, util.OfficeLng, prev, now),
+	}, nil
+}
+
+// json: bentuk yang disimpan di kolom *_geo dan dikembalikan ke klien.
+func (gc geoCheck) json() map[string]any {
+	if gc.Skipped {
+		return nil
+	}
+	reasons := gc.Reasons
+	if reasons == nil {
+		reasons = []string{}
+	}
+	m := map[string]any{
+		"risk_score": gc.Score,
+		"reasons":    reasons,
+		"is_mock":    gc.Signals.Mock,
+	}
+	if gc.Signals.AccuracyM != nil {
+		m["accuracy_m"] = round1(*gc.Signals.AccuracyM)
+	}
+	if gc.Signals.AltitudeM != nil {
+		m["altitude_m"] = round1(*gc.Signals.AltitudeM)
+	}
+	if gc.Signals.Provider != "" {
+		m["provider"] = gc.Signals.Provider
+	}
+	if !gc.Signals.FixTime.IsZero() {
+		m["fix_time"] = gc.Signals.FixTime.UTC().Format(time.RFC3339)
+	}
+	if gc.SpeedKmh > 0 {
+		m["speed_kmh"] = round1(gc.SpeedKmh)
+	}
+	return m
+}
+
+// rejectGeo: tulis 422 untuk lokasi palsu / terlalu berisiko. true = sudah direspon.
+func rejectGeo(w http.ResponseWriter, gc geoCheck) bool {
+	if !gc.Reject() {
+		return false
+	}
+	details := gc.json()
+	details["reject_score"] = georisk.RejectScore()
+	writeJSON(w, 422, map[string]any{
+		"error": map[string]any{"code": "location_rejected", "details": details},
+	})
+	return true
+}
+
+// flagGeo: lokasi mencurigakan tapi absensi diterima → antrian review.
+func (h *AttendanceHandler) flagGeo(ctx context.Context, userID string, date time.Time, event string, gc geoCheck) {
+	if !gc.Flag() {
+		return
+	}
+	details := gc.json()
+	details["flag_score"] = georisk.FlagScore()
+	if _, err := h.Flags.Create(ctx, userID, date, event, "location_risk", details); err != nil {
+		log.Println("create location flag:", err)
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"image"
+	"net/http"
+	"time"
+
+	"absensi/internal/face"
+	"absensi/internal/liveness"
+	"absensi/internal/repo"
+	"absensi/internal/util/imgutil"
+)
+
+// issueChallenge: challenge baru untuk absensi berikutnya (dipanggil dari Status).
+func (h *AttendanceHandler) issueChallenge(ctx context.Context, userID string) (map[string]any, error) {
+	action, err := liveness.RandomAction()
+	if err != nil {
+		return nil, err
+	}
+	c, err := h.Liveness.Issue(ctx, userID, action, liveness.DefaultFrames, liveness.TTL())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"id":         c.ID,
+		"action":     c.Action,
+		"frames":     c.Frames,
+		"expires_at": c.ExpiresAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+func livenessError(w http.ResponseWriter, code string, details map[string]any) {
+	e := map[string]any{"code": code}
+	if details != nil {
+		e["details"] = details
+	}
+	writeJSON(w, 422, map[string]any{"error": e})
+}
+
+// checkLiveness: pakai challenge lalu periksa frame jawabannya, termasuk
+// bahwa wajah di frame sama dengan selfie yang disimpan.
+// ok=false → response sudah ditulis.
+func (h *AttendanceHandler) checkLiveness(ctx context.Context, w http.ResponseWriter, userID, challengeID string, selfie []byte, frames []image.Image) (string, bool) {
+	if challengeID == "" && len(frames) == 0 {
+		if liveness.Required() {
+			livenessError(w, "liveness_required", nil)
+			return "", false
+		}
+		return liveness.StatusDisabled, true
+	}
+	if challengeID == "" {
+		http.Error(w, "challenge_id required", http.StatusBadRequest)
+		return "", false
+	}
+
+	c, err := h.Liveness.Consume(ctx, challengeID, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrChallengeInvalid) || isNotFound(err) {
+			livenessError(w, "challenge_invalid", nil)
+			return "", false
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return "", false
+	}
+	if len(frames) < c.Frames {
+		livenessError(w, "liveness_failed", map[string]any{"reason": "not_enough_frames", "frames": c.Frames})
+		return "", false
+	}
+
+	res, err := h.Live.Check(ctx, c.Action, frames)
+	if err != nil {
+		http.Error(w, "liveness check error", http.StatusInternalServerError)
+		return "", false
+	}
+	if !res.Passed {
+		livenessError(w, "liveness_failed", map[string]any{"reason": res.Reason, "action": c.Action})
+		return "", false
+	}
+
+	probe, err := imgutil.Decode(selfie)
+	if err != nil {
+		http.Error(w, "invalid selfie", http.StatusBadRequest)
+		return "", false
+	}
+	if !h.checkFrameFaces(ctx, w, probe, frames) {
+		return "", false
+	}
+	return liveness.StatusPassed, true
+}
+
+// checkFrameFaces: setiap frame liveness harus berisi wajah yang sama dengan
+// selfie; tanpa ini foto cetak bisa dikirim bersama gerakan kepala orang lain.
+// Selalu ditolak (tidak ikut FACE_MISMATCH_ACTION) karena ini bagian liveness.
+func (h *AttendanceHandler) checkFrameFaces(ctx context.Context, w http.ResponseWriter, selfie image.Image, frames []image.Image) bool {
+	for i, f := range frames {
+		score, err := h.Face.Compare(ctx, selfie, f)
+		if err != nil {
+			http.Error(w, "face verification error", http.StatusInternalServerError)
+			return false
+		}
+		if score < face.Threshold() {
+			livenessError(w, "liveness_failed", map[string]any{
+				"reason":    "frame_face_mismatch",
+				"frame":     i,
+				"score":     round3(score),
+				"threshold": face.Threshold(),
+			})
+			return false
+		}
+	}
+	return true
+}
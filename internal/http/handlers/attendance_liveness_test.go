@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"math"
+	"net/http/httptest"
+	"testing"
+
+	"absensi/internal/face"
+)
+
+func grayImage(f func(x, y int) uint8) image.Image {
+	img := image.NewGray(image.Rect(0, 0, 240, 240))
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 240; x++ {
+			img.SetGray(x, y, color.Gray{Y: f(x, y)})
+		}
+	}
+	return img
+}
+
+func TestCheckFrameFaces(t *testing.T) {
+	// "wajah" sintetis: pola cincin vs pola kotak-kotak
+	employee := func(x, y int) uint8 {
+		return uint8(128 + 100*math.Sin(math.Hypot(float64(x-120), float64(y-110))/6))
+	}
+	other := func(x, y int) uint8 {
+		if (x/12+y/20)%2 == 0 {
+			return 40
+		}
+		return 210
+	}
+	dim := func(x, y int) uint8 { return employee(x, y)/2 + 10 }
+
+	selfie := grayImage(employee)
+	tests := []struct {
+		name   string
+		frames []image.Image
+		ok     bool
+		frame  int
+	}{
+		{"same face", []image.Image{grayImage(employee), grayImage(dim), grayImage(employee)}, true, 0},
+		{"frames show a different face", []image.Image{grayImage(other), grayImage(other), grayImage(other)}, false, 0},
+		{"one swapped frame", []image.Image{grayImage(employee), grayImage(employee), grayImage(other)}, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &AttendanceHandler{Face: face.LBPVerifier{}}
+			w := httptest.NewRecorder()
+			if got := h.checkFrameFaces(context.Background(), w, selfie, tt.frames); got != tt.ok {
+				t.Fatalf("checkFrameFaces = %v, want %v", got, tt.ok)
+			}
+			if tt.ok {
+				if w.Body.Len() != 0 {
+					t.Fatalf("unexpected response: %s", w.Body)
+				}
+				return
+			}
+			if w.Code != 422 {
+				t.Fatalf("status = %d, want 422", w.Code)
+			}
+			var body struct {
+				Error struct {
+					Code    string         `json:"code"`
+					Details map[string]any `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error.Code != "liveness_failed" || body.Error.Details["reason"] != "frame_face_mismatch" {
+				t.Fatalf("error = %+v", body.Error)
+			}
+			if body.Error.Details["frame"] != float64(tt.frame) {
+				t.Fatalf("frame = %v, want %d", body.Error.Details["frame"], tt.frame)
+			}
+		})
+	}
+}
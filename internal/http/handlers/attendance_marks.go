@@ -5,7 +5,11 @@ import (
 	"database/sql"
 	"encoding/json"
 	"net/http"
+	"sort"
 	"time"
+
+	"absensi/internal/repo"
+	"absensi/internal/storage"
 )
 
 // ===== GET /attendance/marks?month=YYYY-MM&tz=Asia/Jakarta
@@ -13,6 +17,15 @@ import (
 type marksResp struct {
 	Month       string   `json:"month"`
 	DaysPresent []string `json:"days_present"`
+	DaysWFH     []string `json:"days_wfh"`   // subset days_present dengan mode wfh
+	DaysDinas   []string `json:"days_dinas"` // subset days_present dengan mode dinas
+	// terlambat / pulang cepat terhadap jadwal, setelah izin yang disetujui
+	DaysLate       []string `json:"days_late"`
+	DaysEarlyLeave []string `json:"days_early_leave"`
+	// total bulan ini dari timeline; hari yang belum check-out tidak dihitung
+	GrossWorkedSeconds int64 `json:"gross_worked_seconds"`
+	BreakSeconds       int64 `json:"break_seconds"`
+	NetWorkedSeconds   int64 `json:"net_worked_seconds"`
 }
 
 func (h *AttendanceHandler) GetMarks(w http.ResponseWriter, r *http.Request) {
@@ -57,33 +70,80 @@ func (h *AttendanceHandler) GetMarks(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "query failed: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	ws, err := h.workSchedule(ctx, uid)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	izin, err := h.Leaves.ApprovedIzin(ctx, uid, start, end)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
 
 	out := marksResp{
 		Month:       start.Format("2006-01"),
 		DaysPresent: make([]string, 0, len(dates)),
+		DaysWFH:     []string{},
+		DaysDinas:   []string{},
+
+		DaysLate:       []string{},
+		DaysEarlyLeave: []string{},
 	}
 	for _, d := range dates {
-		out.DaysPresent = append(out.DaysPresent, d.Format("2006-01-02"))
+		out.DaysPresent = append(out.DaysPresent, d.Date.Format("2006-01-02"))
+		if d.CheckOutAt.Valid {
+			out.GrossWorkedSeconds += d.WorkedSeconds
+			out.BreakSeconds += d.BreakSeconds
+			out.NetWorkedSeconds += d.NetWorkedSeconds
+		}
+		switch d.Mode {
+		case repo.ModeWFH:
+			out.DaysWFH = append(out.DaysWFH, d.Date.Format("2006-01-02"))
+		case repo.ModeDinas:
+			out.DaysDinas = append(out.DaysDinas, d.Date.Format("2006-01-02"))
+		}
+		if p, ok := calcPunctuality(ws, d.Date, d.CheckInAt, d.CheckOutAt, izin); ok {
+			if p.LateSeconds > 0 {
+				out.DaysLate = append(out.DaysLate, d.Date.Format("2006-01-02"))
+			}
+			if p.EarlyLeaveSeconds > 0 {
+				out.DaysEarlyLeave = append(out.DaysEarlyLeave, d.Date.Format("2006-01-02"))
+			}
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(out)
 }
 
-// ===== GET /attendance/day?date=YYYY-MM-DD&tz=Asia/Jakarta
+// ===== GET /attendance/day?date=YYYY-MM-DD&tz=Asia/Jakarta&size=thumb|medium|full
 
 type dayEvent struct {
-	Type        string   `json:"type"`
-	At          string   `json:"at"`
-	Lat         *float64 `json:"lat,omitempty"`
-	Lng         *float64 `json:"lng,omitempty"`
-	DistanceM   *float64 `json:"distance_m,omitempty"`
-	PhotoBase64 *string  `json:"photo_base64,omitempty"`
+	ID          string          `json:"id,omitempty"` // hanya visit / break_*
+	Type        string          `json:"type"`
+	At          string          `json:"at"`
+	Note        *string         `json:"note,omitempty"`
+	Lat         *float64        `json:"lat,omitempty"`
+	Lng         *float64        `json:"lng,omitempty"`
+	DistanceM   *float64        `json:"distance_m,omitempty"`
+	PhotoURL    *string         `json:"photo_url,omitempty"`    // signed, berumur pendek
+	PhotoBase64 *string         `json:"photo_base64,omitempty"` // hanya data lama yang belum dipindah
+	FaceScore   *float64        `json:"face_score,omitempty"`
+	FaceStatus  *string         `json:"face_status,omitempty"`
+	Liveness    *string         `json:"liveness,omitempty"`
+	Exif        json.RawMessage `json:"exif,omitempty"`
+	RiskScore   *int64          `json:"risk_score,omitempty"` // risiko spoofing GPS 0..100
+	Geo         json.RawMessage `json:"geo,omitempty"`
+	Presence    *string         `json:"presence,omitempty"`     // gps | wifi | ble
+	PresenceRef *string         `json:"presence_ref,omitempty"` // BSSID / beacon yang cocok
+	ReceivedAt  any             `json:"received_at,omitempty"`  // hanya event offline (At = waktu kejadian)
 }
 type dayResp struct {
-	Date          string     `json:"date"`
-	Events        []dayEvent `json:"events"`
-	WorkedSeconds int64      `json:"worked_seconds"`
+	Date          string         `json:"date"`
+	Events        []dayEvent     `json:"events"` // timeline lengkap, urut waktu
+	Summary       map[string]any `json:"summary"`
+	WorkedSeconds int64          `json:"worked_seconds"`
 }
 
 func (h *AttendanceHandler) GetDay(w http.ResponseWriter, r *http.Request) {
@@ -107,6 +167,10 @@ func (h *AttendanceHandler) GetDay(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid date", http.StatusBadRequest)
 		return
 	}
+	size, ok := photoSize(w, r)
+	if !ok {
+		return
+	}
 
 	uid, ok := userIDFromRequest(r)
 	if !ok || uid == "" {
@@ -124,7 +188,6 @@ func (h *AttendanceHandler) GetDay(w http.ResponseWriter, r *http.Request) {
 	}
 
 	events := make([]dayEvent, 0, 2)
-	var worked int64
 
 	// Helper to take pointer if valid
 	ptr := func(n sql.NullFloat64) *float64 {
@@ -135,6 +198,19 @@ func (h *AttendanceHandler) GetDay(w http.ResponseWriter, r *http.Request) {
 		return nil
 	}
 
+	var urlErr error
+	signed := func(key sql.NullString) *string {
+		if !key.Valid || key.String == "" {
+			return nil
+		}
+		u, err := storage.ImageURL(ctx, h.Store, key.String, size, storage.URLTTL())
+		if err != nil {
+			urlErr = err
+			return nil
+		}
+		return &u
+	}
+
 	ptrS := func(s sql.NullString) *string {
 		if s.Valid {
 			v := s.String
@@ -143,35 +219,130 @@ func (h *AttendanceHandler) GetDay(w http.ResponseWriter, r *http.Request) {
 		return nil
 	}
 
-	if raw.CheckInAt.Valid {
-		events = append(events, dayEvent{
-			Type:        "check_in",
-			At:          raw.CheckInAt.Time.UTC().Format(time.RFC3339),
-			Lat:         ptr(raw.InLat),
-			Lng:         ptr(raw.InLng),
-			DistanceM:   ptr(raw.InDist),
-			PhotoBase64: ptrS(raw.InPhotoB64),
-		})
+	ptrI := func(n sql.NullInt64) *int64 {
+		if n.Valid {
+			v := n.Int64
+			return &v
+		}
+		return nil
+	}
+
+	// timeline dari attendance_events (sumber kebenaran); detail check-in /
+	// check-out (foto, wajah, EXIF, ...) dari attendance_days
+	logged, err := h.Attendance.ListEvents(ctx, uid, day)
+	if err != nil {
+		http.Error(w, "query failed: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
-	if raw.CheckOutAt.Valid {
-		events = append(events, dayEvent{
-			Type:        "check_out",
-			At:          raw.CheckOutAt.Time.UTC().Format(time.RFC3339),
-			Lat:         ptr(raw.OutLat),
-			Lng:         ptr(raw.OutLng),
-			DistanceM:   ptr(raw.OutDist),
-			PhotoBase64: ptrS(raw.OutPhotoB64),
-		})
+	ad := repo.AttendanceDay{CheckInAt: raw.CheckInAt, CheckOutAt: raw.CheckOutAt}
+	ad.SetWorked(logged, time.Now())
+	sum := repo.Summarize(logged, time.Now())
+
+	inDetail := dayEvent{
+		Type:        "check_in",
+		At:          raw.CheckInAt.Time.UTC().Format(time.RFC3339),
+		Lat:         ptr(raw.InLat),
+		Lng:         ptr(raw.InLng),
+		DistanceM:   ptr(raw.InDist),
+		PhotoURL:    signed(raw.InPhotoKey),
+		PhotoBase64: ptrS(raw.InPhotoB64),
+		FaceScore:   ptr(raw.InFaceScr),
+		FaceStatus:  ptrS(raw.InFaceStat),
+		Liveness:    ptrS(raw.InLiveness),
+		Exif:        rawJSON(raw.InExif),
+		RiskScore:   ptrI(raw.InRisk),
+		Geo:         rawJSON(raw.InGeo),
+		Presence:    ptrS(raw.InPresence),
+		PresenceRef: ptrS(raw.InPresRef),
+		ReceivedAt:  toRFC3339(optTime(raw.InReceived)),
 	}
-	if raw.CheckInAt.Valid && raw.CheckOutAt.Valid {
-		worked = int64(raw.CheckOutAt.Time.Sub(raw.CheckInAt.Time).Seconds())
+	outDetail := dayEvent{
+		Type:        "check_out",
+		At:          raw.CheckOutAt.Time.UTC().Format(time.RFC3339),
+		Lat:         ptr(raw.OutLat),
+		Lng:         ptr(raw.OutLng),
+		DistanceM:   ptr(raw.OutDist),
+		PhotoURL:    signed(raw.OutPhotoKey),
+		PhotoBase64: ptrS(raw.OutPhotoB64),
+		FaceScore:   ptr(raw.OutFaceScr),
+		FaceStatus:  ptrS(raw.OutFaceStat),
+		Liveness:    ptrS(raw.OutLiveness),
+		Exif:        rawJSON(raw.OutExif),
+		RiskScore:   ptrI(raw.OutRisk),
+		Geo:         rawJSON(raw.OutGeo),
+		Presence:    ptrS(raw.OutPresence),
+		PresenceRef: ptrS(raw.OutPresRef),
+		ReceivedAt:  toRFC3339(optTime(raw.OutReceived)),
 	}
+	usedIn, usedOut := false, false
+	for _, ev := range logged {
+		at := ev.At.UTC().Format(time.RFC3339)
+		switch {
+		case ev.Type == repo.EventCheckIn && !usedIn && raw.CheckInAt.Valid:
+			inDetail.At, usedIn = at, true
+			events = append(events, inDetail)
+			continue
+		case ev.Type == repo.EventCheckOut && !usedOut && raw.CheckOutAt.Valid && ev.At.Equal(ad.CheckOutAt.Time):
+			outDetail.At, usedOut = at, true
+			events = append(events, outDetail)
+			continue
+		}
+		de := dayEvent{
+			Type:      ev.Type,
+			At:        at,
+			Note:      ptrS(ev.Note),
+			Lat:       ptr(ev.Lat),
+			Lng:       ptr(ev.Lng),
+			DistanceM: ptr(ev.DistanceM),
+			PhotoURL:  signed(ev.PhotoKey),
+			Presence:  ptrS(ev.Presence),
+		}
+		if ev.Type != repo.EventCheckIn && ev.Type != repo.EventCheckOut {
+			de.ID = ev.ID
+		}
+		if ev.Details != nil {
+			de.Geo, _ = json.Marshal(ev.Details)
+		}
+		events = append(events, de)
+	}
+	// hari lama tanpa event check-in/out
+	if !usedIn && raw.CheckInAt.Valid && !sum.CheckInAt.Valid {
+		events = append(events, inDetail)
+	}
+	if !usedOut && raw.CheckOutAt.Valid && !sum.CheckOutAt.Valid {
+		events = append(events, outDetail)
+	}
+	// RFC3339 UTC → urutan string = urutan waktu
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At < events[j].At })
 
+	if urlErr != nil {
+		http.Error(w, "storage error", http.StatusInternalServerError)
+		return
+	}
 	resp := dayResp{
-		Date:          day.Format("2006-01-02"),
-		Events:        events,
-		WorkedSeconds: worked,
+		Date:   day.Format("2006-01-02"),
+		Events: events,
+		Summary: map[string]any{
+			"check_in_at":  toRFC3339(optTime(ad.CheckInAt)),
+			"check_out_at": toRFC3339(optTime(ad.CheckOutAt)),
+			"mode":         modeJSON(raw.Mode),
+			"punctuality":  h.punctualityJSON(ctx, uid, day, ad.CheckInAt, ad.CheckOutAt),
+			"visits":       sum.Visits,
+			"breaks":       sum.Breaks,
+
+			"gross_worked_seconds": ad.WorkedSeconds,
+			"break_seconds":        ad.BreakSeconds,
+			"net_worked_seconds":   ad.NetWorkedSeconds,
+		},
+		WorkedSeconds: ad.WorkedSeconds,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
+
+func rawJSON(s sql.NullString) json.RawMessage {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	return json.RawMessage(s.String)
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"absensi/internal/devicesig"
+	"absensi/internal/repo"
+	"absensi/internal/storage"
+	"absensi/internal/util"
+)
+
+// toleransi jam perangkat yang sedikit lebih cepat dari server
+const offlineMaxFuture = 2 * time.Minute
+
+// offlineFields: bagian event offline dari request (lihat internal/devicesig).
+type offlineFields struct {
+	DeviceID   string
+	Event      string
+	CapturedAt time.Time
+	Signature  []byte
+}
+
+func (o *offlineFields) set(name, v string) error {
+	switch name {
+	case "device_id":
+		o.DeviceID = strings.TrimSpace(v)
+	case "event":
+		if v != "check_in" && v != "check_out" {
+			return badUpload("invalid event")
+		}
+		o.Event = v
+	case "captured_at":
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return badUpload("invalid captured_at")
+		}
+		o.CapturedAt = t.UTC().Truncate(time.Second)
+	case "signature":
+		sig, err := devicesig.ParseSignature(v)
+		if err != nil {
+			return badUpload("invalid signature")
+		}
+		o.Signature = sig
+	}
+	return nil
+}
+
+func offlineError(w http.ResponseWriter, code string, details map[string]any) {
+	e := map[string]any{"code": code}
+	if details != nil {
+		e["details"] = details
+	}
+	writeJSON(w, 422, map[string]any{"error": e})
+}
+
+// ===== POST /attendance/offline
+// Sama seperti check-in/check-out (JSON posReq atau multipart) + device_id,
+// event, captured_at, signature. Waktu absen = captured_at (tidak sebelum
+// perangkat didaftarkan); liveness dilewati karena challenge butuh koneksi
+// saat kejadian. Setiap absen offline di-flag "offline_punch" untuk review.
+
+func (h *AttendanceHandler) Offline(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+	req, ok := readPunch(w, r)
+	if !ok {
+		return
+	}
+	o := req.Offline
+	if o.DeviceID == "" || o.Event == "" || o.CapturedAt.IsZero() || len(o.Signature) == 0 {
+		http.Error(w, "device_id, event, captured_at, signature required", http.StatusBadRequest)
+		return
+	}
+
+	received := time.Now().UTC()
+	captured := o.CapturedAt
+	switch {
+	case captured.After(received.Add(offlineMaxFuture)):
+		offlineError(w, "invalid_captured_at", map[string]any{"reason": "in_future"})
+		return
+	case received.Sub(captured) > util.OfflineMaxAge():
+		offlineError(w, "invalid_captured_at", map[string]any{
+			"reason":        "too_old",
+			"max_age_hours": int(util.OfflineMaxAge() / time.Hour),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	dev, err := h.Devices.Get(ctx, o.DeviceID)
+	if err != nil && !isNotFound(err) {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if err != nil || dev.UserID != uid || dev.RevokedAt.Valid {
+		offlineError(w, "unknown_device", nil)
+		return
+	}
+	// kunci yang baru didaftarkan tidak boleh menandatangani kejadian lampau
+	if captured.Before(dev.CreatedAt.UTC().Truncate(time.Second)) {
+		offlineError(w, "invalid_captured_at", map[string]any{
+			"reason":        "before_device_registered",
+			"registered_at": dev.CreatedAt.UTC().Format(time.RFC3339),
+		})
+		return
+	}
+	if !devicesig.Verify(dev.PublicKey, devicesig.Event{
+		DeviceID: dev.ID, UserID: uid, Event: o.Event, CapturedAt: captured,
+		Lat: req.Lat, Lng: req.Lng, PhotoSHA256: req.SelfieSHA,
+	}, o.Signature) {
+		offlineError(w, "invalid_signature", nil)
+		return
+	}
+
+	jpg := req.Selfie
+	dist := util.HaversineMeters(req.Lat, req.Lng, util.OfficeLat, util.OfficeLng)
+	date := util.OfficeDate(captured)
+	pr, ok := h.checkPresence(ctx, w, uid, req, dist, date)
+	if !ok {
+		return
+	}
+
+	gc, err := h.checkGeo(ctx, uid, req, captured)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if rejectGeo(w, gc) {
+		return
+	}
+
+	ec := checkExif(req.Meta, captured)
+	if rejectExif(w, ec) {
+		return
+	}
+
+	fc, err := h.checkFace(ctx, uid, jpg)
+	if err != nil {
+		http.Error(w, "face verification error", http.StatusInternalServerError)
+		return
+	}
+	if rejectFace(w, fc) {
+		return
+	}
+
+	photoKey, err := h.putPhoto(ctx, uid, date, o.Event, jpg)
+	if err != nil {
+		http.Error(w, "storage error", http.StatusInternalServerError)
+		return
+	}
+
+	p := repo.Punch{
+		Lat: req.Lat, Lng: req.Lng, DistanceM: dist, NoLocation: !req.HasLoc, PhotoKey: photoKey,
+		FaceScore: fc.Score, FaceStatus: fc.Status,
+		Exif: ec.json(), RiskScore: gc.Score, Geo: gc.json(),
+		Presence: pr.Method, PresenceRef: pr.Ref, Mode: pr.mode(),
+		ReceivedAt: received, DeviceID: dev.ID,
+	}
+	var ad repo.AttendanceDay
+	if o.Event == "check_in" {
+		ad, err = h.Attendance.DoCheckIn(ctx, uid, date, captured, p)
+	} else {
+		ad, err = h.Attendance.DoCheckOut(ctx, uid, date, captured, p)
+	}
+	if err != nil {
+		_ = storage.DeleteImage(ctx, h.Store, photoKey)
+		code := "already_checked_in"
+		if o.Event == "check_out" {
+			code = "not_checked_in_yet_or_already_checked_out"
+		}
+		writeJSON(w, 409, map[string]any{"error": map[string]any{"code": code}})
+		return
+	}
+	h.flagFace(ctx, uid, date, o.Event, fc)
+	h.flagExif(ctx, uid, date, o.Event, ec)
+	h.flagGeo(ctx, uid, date, o.Event, gc)
+	h.flagDuplicate(ctx, uid, date, o.Event, jpg)
+	h.flagOffline(ctx, uid, date, o.Event, dev, captured, received)
+	h.flagOpenBreak(ctx, uid, date, ad)
+	_ = h.Devices.Touch(ctx, dev.ID)
+
+	result := "checked_in"
+	if o.Event == "check_out" {
+		result = "checked_out"
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"result":      result,
+		"captured_at": captured.Format(time.RFC3339),
+		"received_at": received.Format(time.RFC3339),
+		"distance_m":  distJSON(req, dist),
+		"presence":    pr.json(),
+		"face":        fc.json(),
+		"exif":        ec.json(),
+		"location":    gc.json(),
+		"today":       todayJSON(date, ad),
+	})
+}
+
+// flagOffline: waktu absen offline berasal dari perangkat user → setiap
+// absen offline masuk antrian review.
+func (h *AttendanceHandler) flagOffline(ctx context.Context, userID string, date time.Time, event string, dev repo.UserDevice, captured, received time.Time) {
+	if _, err := h.Flags.Create(ctx, userID, date, event, "offline_punch", map[string]any{
+		"device_id":     dev.ID,
+		"device_name":   dev.Name,
+		"captured_at":   captured.Format(time.RFC3339),
+		"received_at":   received.Format(time.RFC3339),
+		"delay_seconds": int64(received.Sub(captured).Seconds()),
+	}); err != nil {
+		log.Println("create offline flag:", err)
+	}
+}
+
+// ===== GET /me/devices
+
+func deviceJSON(d repo.UserDevice) map[string]any {
+	return map[string]any{
+		"id":           d.ID,
+		"name":         d.Name,
+		"created_at":   d.CreatedAt.UTC().Format(time.RFC3339),
+		"last_used_at": toRFC3339(optTime(d.LastUsedAt)),
+		"revoked_at":   toRFC3339(optTime(d.RevokedAt)),
+	}
+}
+
+func (h *AttendanceHandler) ListMyDevices(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	list, err := h.Devices.ListByUser(ctx, uid)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	items := make([]map[string]any, 0, len(list))
+	for _, d := range list {
+		items = append(items, deviceJSON(d))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// ===== POST /me/devices  {"name":"HP Samsung","public_key":"<base64 ed25519>"}
+
+type registerDeviceReq struct {
+	Name      string `json:"name"`
+	PublicKey string `json:"public_key"`
+}
+
+func (h *AttendanceHandler) RegisterMyDevice(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req registerDeviceReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "name required", http.StatusBadRequest)
+		return
+	}
+	pub, err := devicesig.ParsePublicKey(req.PublicKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	d, err := h.Devices.Register(ctx, uid, req.Name, pub)
+	if err != nil {
+		if isUniqueViolation(err) {
+			http.Error(w, "public key already registered", http.StatusConflict)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, deviceJSON(d))
+}
+
+// ===== DELETE /me/devices/{id}
+
+func (h *AttendanceHandler) RevokeMyDevice(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	done, err := h.Devices.Revoke(ctx, uid, r.PathValue("id"))
+	if err != nil && !isNotFound(err) {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !done {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
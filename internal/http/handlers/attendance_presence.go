@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"absensi/internal/kioskqr"
+	"absensi/internal/repo"
+	"absensi/internal/util"
+)
+
+// Metode bukti kehadiran.
+const (
+	presenceGPS   = "gps"
+	presenceWiFi  = "wifi"
+	presenceBLE   = "ble"
+	presenceQR    = "qr"
+	presenceWFH   = "wfh"   // hari WFH yang disetujui
+	presenceDinas = "dinas" // di geofence tujuan perjalanan dinas
+)
+
+const maxScanned = 50 // batas jumlah BSSID / beacon per request
+
+type presence struct {
+	Method string // presenceGPS / presenceWiFi / presenceBLE / presenceQR / presenceWFH / presenceDinas
+	Ref    string // BSSID / beacon yang cocok, kantor QR, atau ID pengajuan WFH / dinas
+}
+
+// mode: mode kerja untuk attendance_days.
+func (p presence) mode() string {
+	switch p.Method {
+	case presenceWFH:
+		return repo.ModeWFH
+	case presenceDinas:
+		return repo.ModeDinas
+	}
+	return repo.ModeOffice
+}
+
+func (p presence) json() map[string]any {
+	m := map[string]any{"method": p.Method}
+	if p.Ref != "" {
+		m["ref"] = p.Ref
+	}
+	return m
+}
+
+// normBSSID: "AA-BB-CC-DD-EE-FF" / "aabbccddeeff" → "aa:bb:cc:dd:ee:ff".
+func normBSSID(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.NewReplacer(":", "", "-", "", ".", "").Replace(s)
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 6 {
+		return "", fmt.Errorf("invalid bssid")
+	}
+	parts := make([]string, len(b))
+	for i, x := range b {
+		parts[i] = hex.EncodeToString([]byte{x})
+	}
+	return strings.Join(parts, ":"), nil
+}
+
+// normBeacon: ID beacon BLE (iBeacon uuid:major:minor, Eddystone namespace:instance, ...),
+// cukup di-trim & lowercase supaya cocok dengan yang didaftarkan admin.
+func normBeacon(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || len(s) > 100 {
+		return "", fmt.Errorf("invalid beacon id")
+	}
+	return s, nil
+}
+
+func normProof(kind, s string) (string, error) {
+	if kind == presenceWiFi {
+		return normBSSID(s)
+	}
+	return normBeacon(s)
+}
+
+// checkPresence: QR kiosk kalau dikirim; di dalam radius → GPS; di luar
+// radius / tanpa lokasi → coba cocokkan hasil scan Wi-Fi / BLE dengan daftar
+// kantor user, lalu dinas / WFH yang disetujui untuk tanggal date. Kalau
+// tidak ada yang cocok tulis 422. ok=false → response sudah ditulis.
+func (h *AttendanceHandler) checkPresence(ctx context.Context, w http.ResponseWriter, userID string, in punchInput, dist float64, date time.Time) (presence, bool) {
+	if in.QRCode != "" {
+		return h.checkQR(ctx, w, userID, in.QRCode)
+	}
+	if in.HasLoc && util.InsideRadius(dist) {
+		return presence{Method: presenceGPS}, true
+	}
+
+	if len(in.WiFi) > 0 || len(in.BLE) > 0 {
+		u, err := h.Users.GetByID(ctx, userID)
+		if err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return presence{}, false
+		}
+		kind, ref, ok, err := h.Presence.Match(ctx, u.Office, in.WiFi, in.BLE)
+		if err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return presence{}, false
+		}
+		if ok {
+			return presence{Method: kind, Ref: ref}, true
+		}
+	}
+
+	trip, ok, err := h.Leaves.ApprovedDinas(ctx, userID, date)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return presence{}, false
+	}
+	if ok {
+		return checkDinas(w, in, trip)
+	}
+	wfh, ok, err := h.Leaves.ApprovedWFH(ctx, userID, date)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return presence{}, false
+	}
+	if ok {
+		return checkWFH(w, in, wfh)
+	}
+
+	if !in.HasLoc {
+		writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "location_required"}})
+		return presence{}, false
+	}
+	writeJSON(w, 422, map[string]any{
+		"error": map[string]any{
+			"code": "outside_radius",
+			"details": map[string]any{
+				"distance_m":    round1(dist),
+				"radius_m":      util.OfficeRadiusM,
+				"wifi_scanned":  len(in.WiFi),
+				"ble_scanned":   len(in.BLE),
+				"proof_matched": false,
+			},
+		},
+	})
+	return presence{}, false
+}
+
+// checkQR: payload QR kiosk harus segar, kantornya terdaftar, dan sama dengan
+// kantor user (user tanpa kantor boleh scan QR kantor mana pun).
+func (h *AttendanceHandler) checkQR(ctx context.Context, w http.ResponseWriter, userID, payload string) (presence, bool) {
+	invalid := func(reason string) (presence, bool) {
+		writeJSON(w, 422, map[string]any{
+			"error": map[string]any{"code": "invalid_qr", "details": map[string]any{"reason": reason}},
+		})
+		return presence{}, false
+	}
+
+	scan, err := kioskqr.Parse(payload)
+	if err != nil {
+		return invalid("malformed")
+	}
+	k, err := h.QR.Get(ctx, scan.Office)
+	if err != nil {
+		if isNotFound(err) {
+			return invalid("unknown_office")
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return presence{}, false
+	}
+	u, err := h.Users.GetByID(ctx, userID)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return presence{}, false
+	}
+	if u.Office != "" && u.Office != scan.Office {
+		return invalid("office_mismatch")
+	}
+	switch err := kioskqr.Verify(k.Secret, scan, time.Now()); {
+	case errors.Is(err, kioskqr.ErrExpired):
+		return invalid("expired")
+	case err != nil:
+		return invalid("invalid_code")
+	}
+	return presence{Method: presenceQR, Ref: scan.Office}, true
+}
+
+// ===== GET /admin/presence-proofs?office=&kind=wifi|ble
+
+func presenceProofJSON(p repo.PresenceProof) map[string]any {
+	return map[string]any{
+		"id":         p.ID,
+		"office":     p.Office,
+		"kind":       p.Kind,
+		"identifier": p.Identifier,
+		"label":      p.Label.String,
+		"created_by": p.CreatedBy.String,
+		"created_at": p.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func (h *AttendanceHandler) ListPresenceProofs(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+	q := r.URL.Query()
+	kind := q.Get("kind")
+	if kind != "" && kind != presenceWiFi && kind != presenceBLE {
+		http.Error(w, "invalid kind", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	list, err := h.Presence.List(ctx, strings.TrimSpace(q.Get("office")), kind)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	items := make([]map[string]any, 0, len(list))
+	for _, p := range list {
+		items = append(items, presenceProofJSON(p))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// ===== POST /admin/presence-proofs  {"office":"","kind":"wifi","identifier":"aa:bb:..","label":"Lt 5"}
+
+type presenceProofReq struct {
+	Office     string `json:"office,omitempty"` // kosong = semua kantor
+	Kind       string `json:"kind"`
+	Identifier string `json:"identifier"`
+	Label      string `json:"label,omitempty"`
+}
+
+func (h *AttendanceHandler) CreatePresenceProof(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r, h.Users)
+	if !ok {
+		return
+	}
+
+	var req presenceProofReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if req.Kind != presenceWiFi && req.Kind != presenceBLE {
+		http.Error(w, "invalid kind", http.StatusBadRequest)
+		return
+	}
+	ident, err := normProof(req.Kind, req.Identifier)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	p, err := h.Presence.Create(ctx, repo.PresenceProof{
+		Office:     strings.TrimSpace(req.Office),
+		Kind:       req.Kind,
+		Identifier: ident,
+		Label:      sql.NullString{String: strings.TrimSpace(req.Label), Valid: true},
+		CreatedBy:  sql.NullString{String: admin.ID, Valid: true},
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			http.Error(w, "already registered", http.StatusConflict)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, presenceProofJSON(p))
+}
+
+// ===== DELETE /admin/presence-proofs/{id}
+
+func (h *AttendanceHandler) DeletePresenceProof(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	deleted, err := h.Presence.Delete(ctx, r.PathValue("id"))
+	if err != nil && !isNotFound(err) {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
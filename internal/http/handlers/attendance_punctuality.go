@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"absensi/internal/models"
+	"absensi/internal/repo"
+)
+
+// punctuality: terlambat / pulang cepat terhadap jadwal kerja. Izin yang
+// disetujui menggeser jam yang diharapkan:
+//   - late_arrival → jam masuk = akhir izin
+//   - early_leave  → jam pulang = awal izin
+//   - step_out     → tidak mengubah apa-apa (hanya dicantumkan)
+type punctuality struct {
+	ExpectedIn, ExpectedOut time.Time
+	LateSeconds             int64 // 0 = tepat waktu / diizinkan
+	EarlyLeaveSeconds       int64 // 0 = tidak pulang cepat / diizinkan / belum check-out
+	LateExcused             bool  // terlambat terhadap jadwal tapi tertutup izin
+	EarlyLeaveExcused       bool
+	Izin                    []repo.IzinRequest
+}
+
+// calcPunctuality: ok=false kalau bukan hari kerja atau belum check-in.
+func calcPunctuality(ws models.WorkSchedule, date time.Time, in, out sql.NullTime, izin []repo.IzinRequest) (punctuality, bool) {
+	date = officeDay(date)
+	if !in.Valid || !ws.IsWorkDay(date) {
+		return punctuality{}, false
+	}
+	start, err1 := ws.StartOn(date)
+	end, err2 := ws.EndOn(date)
+	if err1 != nil || err2 != nil {
+		return punctuality{}, false
+	}
+
+	p := punctuality{ExpectedIn: start, ExpectedOut: end}
+	for _, iz := range izin {
+		if !officeDay(iz.StartDate).Equal(date) {
+			continue
+		}
+		p.Izin = append(p.Izin, iz)
+		switch iz.Type {
+		case repo.IzinLateArrival:
+			if t, err := models.ClockOn(date, iz.EndTime); err == nil && t.After(p.ExpectedIn) {
+				p.ExpectedIn = t
+			}
+		case repo.IzinEarlyLeave:
+			if t, err := models.ClockOn(date, iz.StartTime); err == nil && t.Before(p.ExpectedOut) {
+				p.ExpectedOut = t
+			}
+		}
+	}
+
+	if in.Time.After(p.ExpectedIn) {
+		p.LateSeconds = int64(in.Time.Sub(p.ExpectedIn).Seconds())
+	}
+	p.LateExcused = p.LateSeconds == 0 && in.Time.After(start)
+	if out.Valid {
+		if out.Time.Before(p.ExpectedOut) {
+			p.EarlyLeaveSeconds = int64(p.ExpectedOut.Sub(out.Time).Seconds())
+		}
+		p.EarlyLeaveExcused = p.EarlyLeaveSeconds == 0 && out.Time.Before(end)
+	}
+	return p, true
+}
+
+func (p punctuality) json() map[string]any {
+	izin := make([]map[string]any, 0, len(p.Izin))
+	for _, iz := range p.Izin {
+		izin = append(izin, map[string]any{
+			"id":         iz.ID,
+			"type":       iz.Type,
+			"start_time": iz.StartTime,
+			"end_time":   iz.EndTime,
+		})
+	}
+	return map[string]any{
+		"expected_in":         p.ExpectedIn.UTC().Format(time.RFC3339),
+		"expected_out":        p.ExpectedOut.UTC().Format(time.RFC3339),
+		"late":                p.LateSeconds > 0,
+		"late_seconds":        p.LateSeconds,
+		"late_excused":        p.LateExcused,
+		"early_leave":         p.EarlyLeaveSeconds > 0,
+		"early_leave_seconds": p.EarlyLeaveSeconds,
+		"early_leave_excused": p.EarlyLeaveExcused,
+		"izin":                izin,
+	}
+}
+
+// workSchedule: jadwal kerja user, atau defaultSchedule (lihat overtime.go)
+// kalau belum diatur.
+func (h *AttendanceHandler) workSchedule(ctx context.Context, uid string) (models.WorkSchedule, error) {
+	ws, ok, err := h.userSchedule(ctx, uid)
+	if err != nil || !ok {
+		return defaultSchedule, err
+	}
+	return ws, nil
+}
+
+// punctualityJSON: punctuality user untuk satu hari; nil kalau tidak
+// berlaku atau gagal dihitung (tidak menggagalkan response absen).
+func (h *AttendanceHandler) punctualityJSON(ctx context.Context, uid string, date time.Time, in, out sql.NullTime) map[string]any {
+	if !in.Valid {
+		return nil
+	}
+	ws, err := h.workSchedule(ctx, uid)
+	if err != nil {
+		log.Println("punctuality schedule:", err)
+		return nil
+	}
+	izin, err := h.Leaves.ApprovedIzin(ctx, uid, date, date.AddDate(0, 0, 1))
+	if err != nil {
+		log.Println("punctuality izin:", err)
+		return nil
+	}
+	p, ok := calcPunctuality(ws, date, in, out, izin)
+	if !ok {
+		return nil
+	}
+	return p.json()
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"absensi/internal/repo"
+)
+
+func izin(id, typ string, day int, start, end string) repo.IzinRequest {
+	ir := repo.IzinRequest{Type: typ, StartTime: start, EndTime: end}
+	ir.ID = id
+	ir.StartDate = time.Date(2025, 8, day, 0, 0, 0, 0, time.UTC) // kolom DATE
+	return ir
+}
+
+func TestCalcPunctuality(t *testing.T) {
+	fri := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	sat := time.Date(2025, 8, 2, 0, 0, 0, 0, time.UTC)
+	late := izin("iz-late", repo.IzinLateArrival, 1, "08:00", "10:00")
+	early := izin("iz-early", repo.IzinEarlyLeave, 1, "15:00", "17:00")
+	stepOut := izin("iz-out", repo.IzinStepOut, 1, "11:00", "12:00")
+	otherDay := izin("iz-other", repo.IzinLateArrival, 4, "08:00", "10:00")
+
+	tests := []struct {
+		name    string
+		date    time.Time
+		in, out sql.NullTime
+		izin    []repo.IzinRequest
+		ok      bool
+		late    int64
+		early   int64
+		lateEx  bool
+		earlyEx bool
+		nIzin   int
+	}{
+		{name: "not checked in", date: fri, ok: false},
+		{name: "rest day", date: sat, in: officeTime(2, "09:00"), ok: false},
+		{name: "on time, still at work", date: fri, in: officeTime(1, "07:55"), ok: true},
+		{name: "late 15m", date: fri, in: officeTime(1, "08:15"), ok: true, late: 900},
+		{name: "left 30m early", date: fri, in: officeTime(1, "08:00"), out: officeTime(1, "16:30"), ok: true, early: 1800},
+		{name: "late covered by izin", date: fri, in: officeTime(1, "09:45"), izin: []repo.IzinRequest{late}, ok: true, lateEx: true, nIzin: 1},
+		{name: "late beyond izin", date: fri, in: officeTime(1, "10:20"), izin: []repo.IzinRequest{late}, ok: true, late: 1200, nIzin: 1},
+		{name: "early leave covered", date: fri, in: officeTime(1, "08:00"), out: officeTime(1, "15:00"),
+			izin: []repo.IzinRequest{early}, ok: true, earlyEx: true, nIzin: 1},
+		{name: "step out changes nothing", date: fri, in: officeTime(1, "08:10"), out: officeTime(1, "17:00"),
+			izin: []repo.IzinRequest{stepOut}, ok: true, late: 600, nIzin: 1},
+		{name: "izin on another day ignored", date: fri, in: officeTime(1, "09:00"), izin: []repo.IzinRequest{otherDay}, ok: true, late: 3600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := calcPunctuality(defaultSchedule, tt.date, tt.in, tt.out, tt.izin)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if p.LateSeconds != tt.late || p.EarlyLeaveSeconds != tt.early {
+				t.Errorf("late/early = %d/%d, want %d/%d", p.LateSeconds, p.EarlyLeaveSeconds, tt.late, tt.early)
+			}
+			if p.LateExcused != tt.lateEx || p.EarlyLeaveExcused != tt.earlyEx {
+				t.Errorf("excused = %v/%v, want %v/%v", p.LateExcused, p.EarlyLeaveExcused, tt.lateEx, tt.earlyEx)
+			}
+			if len(p.Izin) != tt.nIzin {
+				t.Errorf("izin = %d, want %d", len(p.Izin), tt.nIzin)
+			}
+		})
+	}
+}
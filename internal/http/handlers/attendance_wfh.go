@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"absensi/internal/repo"
+	"absensi/internal/util"
+)
+
+// checkWFH: hari WFH yang disetujui. Kebijakan "home" → harus dalam
+// WFH_HOME_RADIUS_M dari lokasi rumah yang tercatat di pengajuan;
+// "anywhere" → cukup mengirim lokasi.
+func checkWFH(w http.ResponseWriter, in punchInput, wr repo.WFHRequest) (presence, bool) {
+	if !in.HasLoc {
+		writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "location_required"}})
+		return presence{}, false
+	}
+	pr := presence{Method: presenceWFH, Ref: wr.ID}
+	if util.WFHPolicy() == util.WFHPolicyAnywhere {
+		return pr, true
+	}
+	if !wr.Lat.Valid || !wr.Lng.Valid {
+		writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "home_location_required"}})
+		return presence{}, false
+	}
+	d := util.HaversineMeters(in.Lat, in.Lng, wr.Lat.Float64, wr.Lng.Float64)
+	if d > util.WFHRadiusM() {
+		writeJSON(w, 422, map[string]any{
+			"error": map[string]any{
+				"code": "outside_home_radius",
+				"details": map[string]any{
+					"distance_m":     round1(d),
+					"radius_m":       util.WFHRadiusM(),
+					"wfh_request_id": wr.ID,
+				},
+			},
+		})
+		return presence{}, false
+	}
+	return pr, true
+}
+
+// ===== GET /me/home-location
+
+func homeJSON(hl repo.HomeLocation) map[string]any {
+	if !hl.Lat.Valid || !hl.Lng.Valid {
+		return map[string]any{"lat": nil, "lng": nil, "set_at": nil}
+	}
+	return map[string]any{
+		"lat":    hl.Lat.Float64,
+		"lng":    hl.Lng.Float64,
+		"set_at": toRFC3339(optTime(hl.SetAt)),
+	}
+}
+
+func (h *AttendanceHandler) GetHomeLocation(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	hl, err := h.Users.GetHomeLocation(ctx, uid)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	resp := homeJSON(hl)
+	resp["policy"] = util.WFHPolicy()
+	resp["radius_m"] = util.WFHRadiusM()
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ===== PUT /me/home-location  {"lat":-6.2,"lng":106.8}
+// Pengajuan WFH yang sudah ada tetap memakai lokasi saat diajukan.
+
+type homeLocationReq struct {
+	Lat *float64 `json:"lat"`
+	Lng *float64 `json:"lng"`
+}
+
+func (h *AttendanceHandler) SetHomeLocation(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req homeLocationReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if req.Lat == nil || req.Lng == nil ||
+		*req.Lat < -90 || *req.Lat > 90 || *req.Lng < -180 || *req.Lng > 180 ||
+		(*req.Lat == 0 && *req.Lng == 0) {
+		http.Error(w, "invalid lat/lng", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	hl, err := h.Users.SetHomeLocation(ctx, uid, *req.Lat, *req.Lng)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, homeJSON(hl))
+}
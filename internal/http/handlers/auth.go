@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -18,12 +19,14 @@ import (
 type AuthHandler struct {
 	Users       *repo.UserRepo
 	RefreshRepo *repo.RefreshRepo // ← rename field repositori refresh
+	Invites     *repo.InvitationRepo
 }
 
+// Pendaftaran hanya lewat undangan admin; jabatan & data HR diambil dari undangan.
 type registerReq struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Jabatan  string `json:"jabatan"`
+	InviteToken string `json:"invite_token"`
+	Username    string `json:"username"`
+	Password    string `json:"password"`
 }
 
 type loginReq struct {
@@ -50,7 +53,30 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	req.Username = strings.TrimSpace(req.Username)
-	if len(req.Username) < 3 || len(req.Password) < 6 || strings.TrimSpace(req.Jabatan) == "" {
+	req.InviteToken = strings.TrimSpace(req.InviteToken)
+	if req.InviteToken == "" {
+		http.Error(w, "invite_token required", http.StatusForbidden)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	tokenHash := util.HashToken(req.InviteToken)
+	inv, err := h.Invites.GetByTokenHash(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, repo.ErrInviteNotFound) {
+			http.Error(w, "invalid invitation", http.StatusForbidden)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	// username boleh dikosongkan kalau sudah ditentukan di undangan
+	if req.Username == "" {
+		req.Username = inv.Profile.Username
+	}
+	if len(req.Username) < 3 || len(req.Password) < 6 {
 		http.Error(w, "invalid payload", http.StatusBadRequest)
 		return
 	}
@@ -61,17 +87,22 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
-	defer cancel()
-
-	u, err := h.Users.Create(ctx, req.Username, string(hash), req.Jabatan)
+	u, err := h.Invites.Accept(ctx, tokenHash, req.Username, string(hash), time.Now().UTC())
 	if err != nil {
-		low := strings.ToLower(err.Error())
-		if strings.Contains(low, "duplicate key") || strings.Contains(low, "unique") {
-			http.Error(w, "username already exists", http.StatusConflict)
-			return
+		switch {
+		case errors.Is(err, repo.ErrInviteNotFound):
+			http.Error(w, "invalid invitation", http.StatusForbidden)
+		case errors.Is(err, repo.ErrInviteUsed):
+			http.Error(w, "invitation already used", http.StatusGone)
+		case errors.Is(err, repo.ErrInviteExpired):
+			http.Error(w, "invitation expired", http.StatusGone)
+		case errors.Is(err, repo.ErrInviteUsername):
+			http.Error(w, "username must match invitation", http.StatusBadRequest)
+		case isUniqueViolation(err):
+			http.Error(w, conflictMessage(err), http.StatusConflict)
+		default:
+			http.Error(w, "db error", http.StatusInternalServerError)
 		}
-		http.Error(w, "db error", http.StatusInternalServerError)
 		return
 	}
 
@@ -113,6 +144,10 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid credentials", http.StatusUnauthorized)
 		return
 	}
+	if !u.IsActive {
+		http.Error(w, "account deactivated", http.StatusForbidden)
+		return
+	}
 
 	access, accessExp, err := util.SignAccessToken(u.ID, u.Username)
 	if err != nil {
@@ -137,7 +172,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		"expires_at":    accessExp.Format(time.RFC3339),
 		"refresh_token": refresh,
 		"user": map[string]any{
-			"id": u.ID, "username": u.Username, "jabatan": u.Jabatan,
+			"id": u.ID, "username": u.Username, "jabatan": u.Jabatan, "role": u.Role,
 		},
 	})
 }
@@ -171,6 +206,11 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "user not found", http.StatusUnauthorized)
 		return
 	}
+	if !u.IsActive {
+		_ = h.RefreshRepo.Revoke(ctx, req.RefreshToken)
+		http.Error(w, "account deactivated", http.StatusForbidden)
+		return
+	}
 
 	access, accessExp, err := util.SignAccessToken(u.ID, u.Username)
 	if err != nil {
@@ -196,7 +236,7 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		"expires_at":    accessExp.Format(time.RFC3339),
 		"refresh_token": newRefresh,
 		"user": map[string]any{
-			"id": u.ID, "username": u.Username, "jabatan": u.Jabatan,
+			"id": u.ID, "username": u.Username, "jabatan": u.Jabatan, "role": u.Role,
 		},
 	})
 }
@@ -255,11 +295,5 @@ func (h *AuthHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]any{
-		"id":         u.ID,
-		"username":   u.Username,
-		"jabatan":    u.Jabatan,
-		"created_at": u.CreatedAt,
-	})
+	writeJSON(w, http.StatusOK, userJSON(u))
 }
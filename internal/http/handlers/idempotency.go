@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"absensi/internal/repo"
+	"absensi/internal/util"
+)
+
+// IdempotencyHandler: header Idempotency-Key untuk semua request yang
+// mengubah data. Retry dengan key yang sama mendapat response pertama apa
+// adanya (plus header Idempotent-Replayed: true) selama IDEMPOTENCY_TTL_HOURS.
+type IdempotencyHandler struct {
+	Keys *repo.IdempotencyRepo
+}
+
+const (
+	maxIdempotencyKey  = 255
+	maxReplayBodyBytes = 1 << 20  // response lebih besar tidak disimpan
+	maxSpoolMemory     = 64 << 10 // body request lebih besar disalin ke file sementara
+)
+
+// idempotencyScope: key berlaku per pemilik kredensial. Kosong = request
+// tanpa kredensial valid (login, register, ...) → header diabaikan.
+func idempotencyScope(r *http.Request) string {
+	authz := r.Header.Get("Authorization")
+	switch {
+	case strings.HasPrefix(authz, "Bearer "):
+		claims, err := util.ParseAccessClaims(strings.TrimSpace(strings.TrimPrefix(authz, "Bearer ")))
+		if err != nil {
+			return ""
+		}
+		if claims.Impersonated() {
+			return "user:" + claims.UserID + ":act:" + claims.ActorID
+		}
+		return "user:" + claims.UserID
+	case strings.HasPrefix(authz, "Kiosk "):
+		// kredensial kiosk belum diverifikasi di sini → pakai hash seluruh
+		// kredensial supaya tidak bisa menyerobot key kiosk lain
+		sum := sha256.Sum256([]byte(authz))
+		return "kiosk:" + hex.EncodeToString(sum[:])
+	}
+	return ""
+}
+
+// replayable: response yang boleh diputar ulang. Auth gagal, rate limit,
+// dan error server dilepas supaya retry diproses lagi.
+func replayable(status int) bool {
+	switch {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden, status == http.StatusTooManyRequests:
+		return false
+	case status >= 500:
+		return false
+	}
+	return true
+}
+
+// requestHash: fingerprint body untuk mendeteksi key yang dipakai ulang.
+// Multipart di-hash per part (nama + sha256 isi) supaya boundary acak dari
+// klien tidak membuat retry yang sah dianggap request lain. Body dibaca
+// sekali secara streaming; hash mentah dihitung bersamaan sebagai fallback.
+func requestHash(contentType string, body io.Reader) (string, error) {
+	raw := sha256.New()
+	tee := io.TeeReader(body, raw)
+	mt, params, _ := mime.ParseMediaType(contentType)
+	if mt == "multipart/form-data" && params["boundary"] != "" {
+		parts, ok := multipartDigest(tee, params["boundary"])
+		// sisa body (epilog / bagian setelah multipart rusak) tetap masuk hash mentah
+		if _, err := io.Copy(io.Discard, tee); err != nil {
+			return "", err
+		}
+		if ok {
+			h := sha256.New()
+			h.Write([]byte("multipart\n"))
+			h.Write(parts)
+			return hex.EncodeToString(h.Sum(nil)), nil
+		}
+	} else if _, err := io.Copy(io.Discard, tee); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw.Sum(nil)), nil
+}
+
+// multipartDigest: "nama:sha256(isi)\n" per part sesuai urutan; ok=false
+// kalau body bukan multipart yang valid atau tanpa part sama sekali
+// (fallback ke hash body mentah).
+func multipartDigest(body io.Reader, boundary string) ([]byte, bool) {
+	var out bytes.Buffer
+	mr := multipart.NewReader(body, boundary)
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return out.Bytes(), out.Len() > 0
+		}
+		if err != nil {
+			return nil, false
+		}
+		sum := sha256.New()
+		_, err = io.Copy(sum, part)
+		part.Close()
+		if err != nil {
+			return nil, false
+		}
+		fmt.Fprintf(&out, "%s:%x\n", part.FormName(), sum.Sum(nil))
+	}
+}
+
+// spoolBody: salinan body request untuk handler setelah di-hash. Body kecil
+// (JSON) tetap di memori; upload yang melebihi maxSpoolMemory ditulis ke file
+// sementara supaya selfie / surat dokter tidak ditahan utuh di memori.
+type spoolBody struct {
+	mem bytes.Buffer
+	f   *os.File
+}
+
+func (s *spoolBody) Write(p []byte) (int, error) {
+	if s.f == nil && s.mem.Len()+len(p) <= maxSpoolMemory {
+		return s.mem.Write(p)
+	}
+	if s.f == nil {
+		f, err := os.CreateTemp("", "absensi-idem-*")
+		if err != nil {
+			return 0, err
+		}
+		s.f = f
+		if _, err := f.Write(s.mem.Bytes()); err != nil {
+			return 0, err
+		}
+		s.mem.Reset()
+	}
+	return s.f.Write(p)
+}
+
+// reader: isi yang sudah disalin, dari awal.
+func (s *spoolBody) reader() (io.Reader, error) {
+	if s.f == nil {
+		return bytes.NewReader(s.mem.Bytes()), nil
+	}
+	if _, err := s.f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return s.f, nil
+}
+
+// remove: hapus file sementara (kalau ada).
+func (s *spoolBody) remove() {
+	if s.f == nil {
+		return
+	}
+	s.f.Close()
+	if err := os.Remove(s.f.Name()); err != nil {
+		log.Println("idempotency spool:", err)
+	}
+}
+
+func (h *IdempotencyHandler) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			http.Error(w, "Idempotency-Key too long", http.StatusBadRequest)
+			return
+		}
+		scope := idempotencyScope(r)
+		if scope == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// body di-hash sambil disalin ke spool lalu dipasang lagi; yang
+		// melebihi batas upload tetap diteruskan (handler yang menolak)
+		spool := &spoolBody{}
+		defer spool.remove()
+		reqHash, err := requestHash(r.Header.Get("Content-Type"),
+			io.TeeReader(io.LimitReader(r.Body, util.UploadMaxBytes()+1), spool))
+		if err != nil {
+			http.Error(w, "read body error", http.StatusBadRequest)
+			return
+		}
+		head, err := spool.reader()
+		if err != nil {
+			http.Error(w, "read body error", http.StatusInternalServerError)
+			return
+		}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(head, r.Body), r.Body}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		prev, created, err := h.Keys.Begin(ctx, scope, key, r.Method, r.URL.Path, reqHash,
+			util.IdempotencyTTL(), util.IdempotencyLease())
+		cancel()
+		if err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+
+		if !created {
+			switch {
+			case prev.Method != r.Method || prev.Path != r.URL.Path || prev.RequestHash != reqHash:
+				writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "idempotency_key_reused"}})
+			case !prev.Status.Valid:
+				w.Header().Set("Retry-After", "1")
+				writeJSON(w, 409, map[string]any{"error": map[string]any{"code": "idempotency_in_progress"}})
+			default:
+				if prev.ContentType.Valid {
+					w.Header().Set("Content-Type", prev.ContentType.String)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(int(prev.Status.Int64))
+				_, _ = w.Write(prev.Body)
+			}
+			return
+		}
+
+		rec := &bodyRecorder{statusRecorder: statusRecorder{ResponseWriter: w}}
+		defer func() {
+			// handler panic → key dilepas lalu panic diteruskan; jangan
+			// simpan 200 kosong yang akan diputar ulang ke setiap retry
+			p := recover()
+
+			// pakai context baru: request ctx bisa sudah dibatalkan
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			var err error
+			// status 0 = handler tidak menulis apa pun
+			if status := rec.status; p == nil && status != 0 && replayable(status) && !rec.overflow {
+				err = h.Keys.Complete(ctx, scope, key, prev.LeaseToken, status, rec.Header().Get("Content-Type"), rec.buf.Bytes())
+			} else {
+				err = h.Keys.Release(ctx, scope, key, prev.LeaseToken)
+			}
+			if err != nil {
+				log.Println("idempotency:", err)
+			}
+			if p != nil {
+				panic(p)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// bodyRecorder: statusRecorder + salinan body response (maks maxReplayBodyBytes).
+type bodyRecorder struct {
+	statusRecorder
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (b *bodyRecorder) Write(p []byte) (int, error) {
+	if !b.overflow {
+		if b.buf.Len()+len(p) > maxReplayBodyBytes {
+			b.overflow = true
+			b.buf.Reset()
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return b.statusRecorder.Write(p)
+}
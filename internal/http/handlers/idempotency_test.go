@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"os"
+	"testing"
+)
+
+func multipartBody(t *testing.T, boundary string, fields map[string]string, file []byte) (string, []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	if err := mw.SetBoundary(boundary); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"lat", "lng"} {
+		if v, ok := fields[k]; ok {
+			_ = mw.WriteField(k, v)
+		}
+	}
+	fw, err := mw.CreateFormFile("photo", "selfie.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fw.Write(file)
+	_ = mw.Close()
+	return mw.FormDataContentType(), buf.Bytes()
+}
+
+func hashOf(t *testing.T, ct string, body []byte) string {
+	t.Helper()
+	h, err := requestHash(ct, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestRequestHash(t *testing.T) {
+	fields := map[string]string{"lat": "-6.2", "lng": "106.8"}
+	ctA, bodyA := multipartBody(t, "boundaryAAAA", fields, []byte("jpeg-1"))
+	ctB, bodyB := multipartBody(t, "boundaryBBBB", fields, []byte("jpeg-1"))
+	ctC, bodyC := multipartBody(t, "boundaryCCCC", fields, []byte("jpeg-2"))
+	ctD, bodyD := multipartBody(t, "boundaryDDDD", map[string]string{"lat": "-6.3", "lng": "106.8"}, []byte("jpeg-1"))
+
+	base := hashOf(t, ctA, bodyA)
+	tests := []struct {
+		name string
+		ct   string
+		body []byte
+		same bool
+	}{
+		{"retry with new boundary", ctB, bodyB, true},
+		{"different file", ctC, bodyC, false},
+		{"different field", ctD, bodyD, false},
+		{"raw body with multipart content type", "application/json", bodyA, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hashOf(t, tt.ct, tt.body) == base; got != tt.same {
+				t.Fatalf("same hash = %v, want %v", got, tt.same)
+			}
+		})
+	}
+
+	if hashOf(t, "application/json", []byte(`{"a":1}`)) != hashOf(t, "application/json", []byte(`{"a":1}`)) {
+		t.Fatal("json hash not stable")
+	}
+	if hashOf(t, "application/json", []byte(`{"a":1}`)) == hashOf(t, "application/json", []byte(`{"a":2}`)) {
+		t.Fatal("json hash ignores body")
+	}
+	// multipart rusak → fallback ke hash body mentah
+	if hashOf(t, "multipart/form-data; boundary=x", []byte("garbage")) != hashOf(t, "text/plain", []byte("garbage")) {
+		t.Fatal("broken multipart not hashed as raw body")
+	}
+	// data setelah penutup multipart tetap dibaca habis (untuk spool)
+	ctE, bodyE := multipartBody(t, "boundaryEEEE", fields, []byte("jpeg-1"))
+	rd := bytes.NewReader(append(bodyE, "epilogue"...))
+	if _, err := requestHash(ctE, rd); err != nil || rd.Len() != 0 {
+		t.Fatalf("body not drained: err=%v left=%d", err, rd.Len())
+	}
+}
+
+func TestSpoolBody(t *testing.T) {
+	for _, size := range []int{100, maxSpoolMemory + 1, 3 * maxSpoolMemory} {
+		body := bytes.Repeat([]byte("x"), size)
+		s := &spoolBody{}
+		// tulis dalam potongan kecil seperti TeeReader
+		for i := 0; i < size; i += 4096 {
+			if _, err := s.Write(body[i:min(i+4096, size)]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if spilled := s.f != nil; spilled != (size > maxSpoolMemory) {
+			t.Fatalf("size %d: spilled = %v", size, spilled)
+		}
+		rd, err := s.reader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(rd)
+		if err != nil || !bytes.Equal(got, body) {
+			t.Fatalf("size %d: read back %d bytes, err %v", size, len(got), err)
+		}
+		name := ""
+		if s.f != nil {
+			name = s.f.Name()
+		}
+		s.remove()
+		if name != "" {
+			if _, err := os.Stat(name); !os.IsNotExist(err) {
+				t.Fatalf("temp file not removed: %v", err)
+			}
+		}
+	}
+}
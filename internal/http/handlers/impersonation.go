@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"absensi/internal/repo"
+	"absensi/internal/util"
+)
+
+// ImpersonationHandler: token "act as user" untuk helpdesk + audit log-nya.
+type ImpersonationHandler struct {
+	Users *repo.UserRepo
+	Audit *repo.AuditRepo
+}
+
+// endpoint POST yang tidak mengubah data → tetap boleh dengan token read-only
+// (Status tidak menerbitkan challenge liveness untuk token read-only)
+var readOnlyPOSTAllowed = map[string]bool{
+	"/attendance/status": true,
+}
+
+// ===== POST /admin/impersonate
+
+type impersonateReq struct {
+	UserID     string `json:"user_id"`
+	Reason     string `json:"reason"`                // wajib, masuk audit
+	Write      bool   `json:"write,omitempty"`       // default read-only
+	TTLMinutes int    `json:"ttl_minutes,omitempty"` // maks IMPERSONATION_TTL_MIN
+}
+
+func (h *ImpersonationHandler) Start(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r, h.Users)
+	if !ok {
+		return
+	}
+
+	var req impersonateReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		http.Error(w, "reason required", http.StatusBadRequest)
+		return
+	}
+	ttl := util.ImpersonationTTL()
+	if req.TTLMinutes < 0 {
+		http.Error(w, "invalid ttl_minutes", http.StatusBadRequest)
+		return
+	}
+	if d := time.Duration(req.TTLMinutes) * time.Minute; d > 0 && d < ttl {
+		ttl = d
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	target, err := h.Users.GetByID(ctx, req.UserID)
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	// admin tidak boleh menyamar jadi admin lain (eskalasi)
+	if target.IsAdmin() || target.ID == admin.ID {
+		http.Error(w, "cannot impersonate admin", http.StatusForbidden)
+		return
+	}
+	if !target.IsActive {
+		http.Error(w, "user deactivated", http.StatusConflict)
+		return
+	}
+
+	token, exp, err := util.SignImpersonationToken(admin.ID, target.ID, target.Username, ttl, !req.Write)
+	if err != nil {
+		http.Error(w, "token error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.Audit.LogImpersonation(ctx, repo.ImpersonationEntry{
+		AdminID:   admin.ID,
+		UserID:    target.ID,
+		Action:    "start",
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Status:    http.StatusCreated,
+		ReadOnly:  !req.Write,
+		Reason:    req.Reason,
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+	}); err != nil {
+		http.Error(w, "audit error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_at":   exp.UTC().Format(time.RFC3339),
+		"read_only":    !req.Write,
+		"user": map[string]any{
+			"id": target.ID, "username": target.Username, "jabatan": target.Jabatan,
+		},
+		"actor": map[string]any{"id": admin.ID, "username": admin.Username},
+	})
+}
+
+// ===== GET /admin/impersonation/audit?admin_id=&user_id=&page=&page_size=
+
+func (h *ImpersonationHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	page, pageSize := 1, 50
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid page", http.StatusBadRequest)
+			return
+		}
+		page = n
+	}
+	if v := q.Get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 200 {
+			http.Error(w, "invalid page_size", http.StatusBadRequest)
+			return
+		}
+		pageSize = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	entries, err := h.Audit.ListImpersonation(ctx, q.Get("admin_id"), q.Get("user_id"), pageSize, (page-1)*pageSize)
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "invalid admin_id or user_id", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]map[string]any, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, map[string]any{
+			"id":         e.ID,
+			"admin_id":   e.AdminID,
+			"user_id":    e.UserID,
+			"action":     e.Action,
+			"method":     e.Method,
+			"path":       e.Path,
+			"status":     e.Status,
+			"read_only":  e.ReadOnly,
+			"reason":     e.Reason,
+			"ip":         e.IP,
+			"user_agent": e.UserAgent,
+			"at":         e.At.UTC().Format(time.RFC3339),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"page": page, "page_size": pageSize, "items": items})
+}
+
+// Guard: middleware untuk semua route. Token biasa diteruskan apa adanya;
+// token impersonation dicek (admin masih aktif, read-only) lalu setiap
+// request-nya dicatat ke audit log beserta status response.
+func (h *ImpersonationHandler) Guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authz := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authz, "Bearer ") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		claims, err := util.ParseAccessClaims(strings.TrimSpace(strings.TrimPrefix(authz, "Bearer ")))
+		if err != nil || !claims.Impersonated() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			// pakai context baru: request ctx bisa sudah dibatalkan
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			if err := h.Audit.LogImpersonation(ctx, repo.ImpersonationEntry{
+				AdminID:   claims.ActorID,
+				UserID:    claims.UserID,
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Status:    rec.code(),
+				ReadOnly:  claims.ReadOnly,
+				IP:        clientIP(r),
+				UserAgent: r.UserAgent(),
+			}); err != nil {
+				log.Println("impersonation audit:", err)
+			}
+		}()
+
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		admin, err := h.Users.GetByID(ctx, claims.ActorID)
+		cancel()
+		if err != nil || !admin.IsActive || !admin.IsAdmin() {
+			http.Error(rec, "impersonation no longer allowed", http.StatusUnauthorized)
+			return
+		}
+
+		readMethod := r.Method == http.MethodGet || r.Method == http.MethodHead
+		if claims.ReadOnly && !readMethod && !(r.Method == http.MethodPost && readOnlyPOSTAllowed[r.URL.Path]) {
+			http.Error(rec, "impersonation token is read-only", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// statusRecorder: ResponseWriter yang mengingat status code.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusRecorder) code() int {
+	if s.status == 0 {
+		return http.StatusOK
+	}
+	return s.status
+}
+
+func clientIP(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		return strings.TrimSpace(strings.Split(xff, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"absensi/internal/kioskqr"
+	"absensi/internal/repo"
+)
+
+type KioskHandler struct {
+	Users   *repo.UserRepo
+	QR      *repo.QRKioskRepo
+	Devices *repo.KioskRepo
+	Att     *AttendanceHandler // pipeline absensi (wajah, foto, DoCheckIn/Out)
+}
+
+func qrKioskJSON(k repo.QRKiosk) map[string]any {
+	return map[string]any{
+		"office":       k.Office,
+		"step_seconds": int(kioskqr.Step / time.Second),
+		"created_by":   k.CreatedBy.String,
+		"created_at":   k.CreatedAt.UTC().Format(time.RFC3339),
+		"rotated_at":   k.RotatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// ===== GET /admin/kiosks/qr
+
+func (h *KioskHandler) ListQR(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	list, err := h.QR.List(ctx)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	items := make([]map[string]any, 0, len(list))
+	for _, k := range list {
+		items = append(items, qrKioskJSON(k))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// ===== PUT /admin/kiosks/qr/{office}  (buat / rotasi secret; secret hanya ditampilkan sekali)
+
+func (h *KioskHandler) RotateQR(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r, h.Users)
+	if !ok {
+		return
+	}
+	office := strings.TrimSpace(r.PathValue("office"))
+	if office == "" {
+		http.Error(w, "office required", http.StatusBadRequest)
+		return
+	}
+	secret, err := kioskqr.NewSecret()
+	if err != nil {
+		http.Error(w, "rng error", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	k, err := h.QR.Rotate(ctx, office, secret, admin.ID)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	resp := qrKioskJSON(k)
+	resp["secret"] = hex.EncodeToString(secret) // untuk tablet yang menghitung kode sendiri (offline)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ===== DELETE /admin/kiosks/qr/{office}
+
+func (h *KioskHandler) DeleteQR(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	deleted, err := h.QR.Delete(ctx, r.PathValue("office"))
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ===== GET /kiosk/qr/{office}  (layar kiosk polling tiap ≤ 30 detik)
+// Auth: device kiosk kantor itu, atau admin.
+
+func (h *KioskHandler) CurrentQR(w http.ResponseWriter, r *http.Request) {
+	if isKioskAuth(r) {
+		dev, ok := h.mustKiosk(w, r)
+		if !ok {
+			return
+		}
+		if dev.Office != "" && dev.Office != r.PathValue("office") {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+	} else if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	k, err := h.QR.Get(ctx, r.PathValue("office"))
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]any{
+		"office":       k.Office,
+		"payload":      kioskqr.Payload(k.Secret, k.Office, now),
+		"expires_at":   kioskqr.StepEnd(kioskqr.StepAt(now)).Format(time.RFC3339),
+		"step_seconds": int(kioskqr.Step / time.Second),
+	})
+}
@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"absensi/internal/repo"
+)
+
+// ===== auth kiosk: "Authorization: Kiosk <device_id>:<secret>"
+
+func hashKioskSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func isKioskAuth(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Authorization"), "Kiosk ")
+}
+
+// mustKiosk: validasi kredensial device kiosk. ok=false → response sudah ditulis.
+func (h *KioskHandler) mustKiosk(w http.ResponseWriter, r *http.Request) (repo.KioskDevice, bool) {
+	cred, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Kiosk ")
+	if !ok {
+		http.Error(w, "missing kiosk credential", http.StatusUnauthorized)
+		return repo.KioskDevice{}, false
+	}
+	id, secret, ok := strings.Cut(strings.TrimSpace(cred), ":")
+	if !ok || id == "" || secret == "" {
+		http.Error(w, "invalid kiosk credential", http.StatusUnauthorized)
+		return repo.KioskDevice{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	d, err := h.Devices.GetDevice(ctx, id)
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "invalid kiosk credential", http.StatusUnauthorized)
+			return repo.KioskDevice{}, false
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return repo.KioskDevice{}, false
+	}
+	if subtle.ConstantTimeCompare([]byte(hashKioskSecret(secret)), []byte(d.SecretHash)) != 1 || d.RevokedAt.Valid {
+		http.Error(w, "invalid kiosk credential", http.StatusUnauthorized)
+		return repo.KioskDevice{}, false
+	}
+	_ = h.Devices.TouchDevice(ctx, d.ID)
+	return d, true
+}
+
+// normBadge: UID kartu → hex uppercase tanpa pemisah ("04:a2:2b:..." → "04A22B...").
+func normBadge(s string) (string, bool) {
+	s = strings.ToUpper(strings.NewReplacer(":", "", "-", "", " ", "").Replace(strings.TrimSpace(s)))
+	if len(s) < 4 || len(s) > 40 {
+		return "", false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'A' && c <= 'F') {
+			return "", false
+		}
+	}
+	return s, true
+}
+
+func kioskDeviceJSON(d repo.KioskDevice) map[string]any {
+	var lat, lng any
+	if d.Lat.Valid && d.Lng.Valid {
+		lat, lng = d.Lat.Float64, d.Lng.Float64
+	}
+	return map[string]any{
+		"id":           d.ID,
+		"name":         d.Name,
+		"office":       d.Office,
+		"lat":          lat,
+		"lng":          lng,
+		"created_by":   d.CreatedBy.String,
+		"created_at":   d.CreatedAt.UTC().Format(time.RFC3339),
+		"last_seen_at": toRFC3339(optTime(d.LastSeenAt)),
+		"revoked_at":   toRFC3339(optTime(d.RevokedAt)),
+	}
+}
+
+// ===== GET /admin/kiosks/devices
+
+func (h *KioskHandler) ListDevices(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	list, err := h.Devices.ListDevices(ctx)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	items := make([]map[string]any, 0, len(list))
+	for _, d := range list {
+		items = append(items, kioskDeviceJSON(d))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// ===== POST /admin/kiosks/devices  {"name":"Lobby Pabrik A","office":"Pabrik A","lat":..,"lng":..}
+
+type kioskDeviceReq struct {
+	Name   string   `json:"name"`
+	Office string   `json:"office"`        // wajib; badge hanya untuk pegawai kantor ini
+	Lat    *float64 `json:"lat,omitempty"` // lokasi tetap kiosk (opsional)
+	Lng    *float64 `json:"lng,omitempty"`
+}
+
+func (h *KioskHandler) CreateDevice(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r, h.Users)
+	if !ok {
+		return
+	}
+
+	var req kioskDeviceReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Office = strings.TrimSpace(req.Office)
+	if req.Name == "" || (req.Lat == nil) != (req.Lng == nil) {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	if req.Office == "" {
+		http.Error(w, "office required", http.StatusBadRequest)
+		return
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		http.Error(w, "rng error", http.StatusInternalServerError)
+		return
+	}
+	secret := hex.EncodeToString(raw)
+
+	d := repo.KioskDevice{
+		Name:       req.Name,
+		Office:     req.Office,
+		SecretHash: hashKioskSecret(secret),
+		CreatedBy:  sql.NullString{String: admin.ID, Valid: true},
+	}
+	if req.Lat != nil {
+		d.Lat = sql.NullFloat64{Float64: *req.Lat, Valid: true}
+		d.Lng = sql.NullFloat64{Float64: *req.Lng, Valid: true}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	d, err := h.Devices.CreateDevice(ctx, d)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	resp := kioskDeviceJSON(d)
+	// secret hanya ditampilkan sekali
+	resp["secret"] = secret
+	resp["authorization"] = "Kiosk " + d.ID + ":" + secret
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// ===== POST /admin/kiosks/devices/{id}/revoke
+
+func (h *KioskHandler) RevokeDevice(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	id := r.PathValue("id")
+	done, err := h.Devices.RevokeDevice(ctx, id)
+	if err != nil && !isNotFound(err) {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !done {
+		http.Error(w, "not found or already revoked", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"result": "revoked", "id": id})
+}
+
+// ===== GET /admin/badges?user_id=
+
+func badgeJSON(b repo.Badge) map[string]any {
+	return map[string]any{
+		"uid":        b.UID,
+		"user_id":    b.UserID,
+		"username":   b.Username,
+		"label":      b.Label.String,
+		"created_by": b.CreatedBy.String,
+		"created_at": b.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func (h *KioskHandler) ListBadges(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	list, err := h.Devices.ListBadges(ctx, r.URL.Query().Get("user_id"))
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	items := make([]map[string]any, 0, len(list))
+	for _, b := range list {
+		items = append(items, badgeJSON(b))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// ===== PUT /admin/badges/{uid}  {"user_id":"...","label":"Kartu utama"}
+
+type badgeReq struct {
+	UserID string `json:"user_id"`
+	Label  string `json:"label,omitempty"`
+}
+
+func (h *KioskHandler) PutBadge(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r, h.Users)
+	if !ok {
+		return
+	}
+	uid, ok := normBadge(r.PathValue("uid"))
+	if !ok {
+		http.Error(w, "invalid badge uid", http.StatusBadRequest)
+		return
+	}
+	var req badgeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	u, err := h.Users.GetByID(ctx, req.UserID)
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "unknown user_id", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	b, err := h.Devices.PutBadge(ctx, repo.Badge{
+		UID:       uid,
+		UserID:    u.ID,
+		Username:  u.Username,
+		Label:     sql.NullString{String: strings.TrimSpace(req.Label), Valid: true},
+		CreatedBy: sql.NullString{String: admin.ID, Valid: true},
+	})
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, badgeJSON(b))
+}
+
+// ===== DELETE /admin/badges/{uid}
+
+func (h *KioskHandler) DeleteBadge(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+	uid, ok := normBadge(r.PathValue("uid"))
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	deleted, err := h.Devices.DeleteBadge(ctx, uid)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
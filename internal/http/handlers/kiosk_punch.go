@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"absensi/internal/repo"
+	"absensi/internal/storage"
+	"absensi/internal/util"
+	"absensi/internal/util/imgutil"
+)
+
+const presenceKiosk = "kiosk"
+
+// ===== POST /kiosk/punch  (Authorization: Kiosk <id>:<secret>)
+// JSON {"badge_uid":"04A22B..","event":"check_in|check_out","photo_base64":".."}
+// atau multipart: field badge_uid, event + file photo (kamera kiosk, opsional).
+// event kosong = otomatis (check-in kalau belum, selain itu check-out).
+
+type kioskPunchReq struct {
+	BadgeUID    string `json:"badge_uid"`
+	Event       string `json:"event,omitempty"`
+	PhotoBase64 string `json:"photo_base64,omitempty"`
+}
+
+type kioskPunchInput struct {
+	BadgeUID string
+	Event    string
+	Photo    []byte // JPEG hasil normalisasi, nil = tanpa foto
+}
+
+func readKioskPunch(w http.ResponseWriter, r *http.Request) (kioskPunchInput, bool) {
+	limitBody(w, r)
+	var in kioskPunchInput
+
+	if isMultipart(r) {
+		err := readMultipart(r, func(name, v string) error {
+			switch name {
+			case "badge_uid":
+				in.BadgeUID = v
+			case "event":
+				in.Event = v
+			}
+			return nil
+		}, func(name string, body io.Reader) error {
+			if name != "photo" {
+				return nil
+			}
+			jpg, err := imgutil.NormalizeReader(body)
+			if err != nil {
+				return imageUploadErr("photo", err)
+			}
+			in.Photo = jpg
+			return nil
+		})
+		if err != nil {
+			writeUploadErr(w, err)
+			return in, false
+		}
+	} else {
+		var req kioskPunchReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			var tooBig *http.MaxBytesError
+			if errors.As(err, &tooBig) {
+				writeUploadErr(w, err)
+				return in, false
+			}
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return in, false
+		}
+		in.BadgeUID, in.Event = req.BadgeUID, req.Event
+		if strings.TrimSpace(req.PhotoBase64) != "" {
+			jpg, err := imgutil.NormalizeJPEG(req.PhotoBase64)
+			if err != nil {
+				http.Error(w, "invalid photo: "+err.Error(), http.StatusBadRequest)
+				return in, false
+			}
+			in.Photo = jpg
+		}
+	}
+
+	uid, ok := normBadge(in.BadgeUID)
+	if !ok {
+		http.Error(w, "invalid badge_uid", http.StatusBadRequest)
+		return in, false
+	}
+	in.BadgeUID = uid
+	if in.Event != "" && in.Event != "check_in" && in.Event != "check_out" {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return in, false
+	}
+	return in, true
+}
+
+// Punch: absen atas nama pemilik kartu. Lokasi kiosk tetap, jadi GPS per user,
+// liveness, dan EXIF tidak dipakai; foto kamera kiosk (kalau ada) tetap
+// diverifikasi wajah dan dicek duplikat.
+func (h *KioskHandler) Punch(w http.ResponseWriter, r *http.Request) {
+	dev, ok := h.mustKiosk(w, r)
+	if !ok {
+		return
+	}
+	req, ok := readKioskPunch(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	date := util.OfficeDate(now)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	userID, err := h.Devices.BadgeUser(ctx, req.BadgeUID)
+	if err != nil {
+		if isNotFound(err) {
+			writeJSON(w, 404, map[string]any{"error": map[string]any{"code": "unknown_badge"}})
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	u, err := h.Users.GetByID(ctx, userID)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !u.IsActive {
+		writeJSON(w, 403, map[string]any{"error": map[string]any{"code": "user_deactivated"}})
+		return
+	}
+	// kiosk selalu punya kantor (CHECK di migrasi 014); user tanpa kantor
+	// tidak boleh absen lewat kiosk mana pun
+	if dev.Office != u.Office {
+		writeJSON(w, 422, map[string]any{
+			"error": map[string]any{
+				"code":    "office_mismatch",
+				"details": map[string]any{"kiosk_office": dev.Office, "user_office": u.Office},
+			},
+		})
+		return
+	}
+
+	event := req.Event
+	if event == "" {
+		day, err := h.Att.Attendance.GetByUserAndDate(ctx, u.ID, date)
+		if err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+		switch {
+		case !day.CheckInAt.Valid:
+			event = "check_in"
+		case !day.CheckOutAt.Valid:
+			event = "check_out"
+		default:
+			writeJSON(w, 409, map[string]any{"error": map[string]any{"code": "already_checked_out"}})
+			return
+		}
+	}
+
+	p := repo.Punch{NoLocation: true, Presence: presenceKiosk, PresenceRef: dev.ID}
+	if dev.Lat.Valid && dev.Lng.Valid {
+		p.NoLocation = false
+		p.Lat, p.Lng = dev.Lat.Float64, dev.Lng.Float64
+		p.DistanceM = util.HaversineMeters(p.Lat, p.Lng, util.OfficeLat, util.OfficeLng)
+	}
+
+	var fc faceCheck
+	if len(req.Photo) > 0 {
+		fc, err = h.Att.checkFace(ctx, u.ID, req.Photo)
+		if err != nil {
+			http.Error(w, "face verification error", http.StatusInternalServerError)
+			return
+		}
+		if rejectFace(w, fc) {
+			return
+		}
+		p.FaceScore, p.FaceStatus = fc.Score, fc.Status
+		if p.PhotoKey, err = h.Att.putPhoto(ctx, u.ID, date, event, req.Photo); err != nil {
+			http.Error(w, "storage error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	var ad repo.AttendanceDay
+	if event == "check_in" {
+		ad, err = h.Att.Attendance.DoCheckIn(ctx, u.ID, date, now, p)
+	} else {
+		ad, err = h.Att.Attendance.DoCheckOut(ctx, u.ID, date, now, p)
+	}
+	if err != nil {
+		if p.PhotoKey != "" {
+			_ = storage.DeleteImage(ctx, h.Att.Store, p.PhotoKey)
+		}
+		code := "already_checked_in"
+		if event == "check_out" {
+			code = "not_checked_in_yet_or_already_checked_out"
+		}
+		writeJSON(w, 409, map[string]any{"error": map[string]any{"code": code}})
+		return
+	}
+	var face any
+	if len(req.Photo) > 0 {
+		h.Att.flagFace(ctx, u.ID, date, event, fc)
+		h.Att.flagDuplicate(ctx, u.ID, date, event, req.Photo)
+		face = fc.json()
+	}
+	h.Att.flagOpenBreak(ctx, u.ID, date, ad)
+
+	result := "checked_in"
+	if event == "check_out" {
+		result = "checked_out"
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"result": result,
+		"user":   map[string]any{"id": u.ID, "username": u.Username, "full_name": u.FullName},
+		"face":   face,
+		"today":  todayJSON(date, ad),
+	})
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"absensi/internal/repo"
+	"absensi/internal/util"
+)
+
+// ===== POST /leave/dinas/request
+// {"destination":"Kantor Cabang Surabaya","lat":-7.25,"lng":112.75,"radius_m":500,
+//  "start_date":"yyyy-mm-dd","end_date":"yyyy-mm-dd","purpose":"audit cabang"}
+// radius_m opsional (default DINAS_RADIUS_M).
+
+type dinasReq struct {
+	Destination string   `json:"destination"`
+	Lat         *float64 `json:"lat"`
+	Lng         *float64 `json:"lng"`
+	RadiusM     float64  `json:"radius_m,omitempty"`
+	StartDate   string   `json:"start_date"`
+	EndDate     string   `json:"end_date"`
+	Purpose     string   `json:"purpose"`
+}
+
+func dinasJSON(dr repo.DinasRequest) map[string]any {
+	m := map[string]any{
+		"id":          dr.ID,
+		"user_id":     dr.UserID,
+		"status":      dr.Status,
+		"purpose":     dr.Reason.String,
+		"destination": dr.Destination,
+		"lat":         dr.Lat,
+		"lng":         dr.Lng,
+		"radius_m":    dr.RadiusM,
+		"start_date":  dr.StartDate.Format("2006-01-02"),
+		"end_date":    dr.EndDate.Format("2006-01-02"),
+		"days":        dr.Days,
+		"created_at":  dr.CreatedAt.UTC().Format(time.RFC3339),
+		"decided_at":  toRFC3339(optTime(dr.DecidedAt)),
+		"decided_by":  nil,
+	}
+	if dr.DecidedBy.Valid {
+		m["decided_by"] = dr.DecidedBy.String
+	}
+	return m
+}
+
+func (h *LeaveHandler) RequestDinas(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req dinasReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	req.Destination = strings.TrimSpace(req.Destination)
+	req.Purpose = strings.TrimSpace(req.Purpose)
+	if req.Destination == "" || req.Purpose == "" {
+		http.Error(w, "destination and purpose required", http.StatusBadRequest)
+		return
+	}
+	if req.Lat == nil || req.Lng == nil ||
+		*req.Lat < -90 || *req.Lat > 90 || *req.Lng < -180 || *req.Lng > 180 ||
+		(*req.Lat == 0 && *req.Lng == 0) {
+		http.Error(w, "invalid lat/lng", http.StatusBadRequest)
+		return
+	}
+	radius := req.RadiusM
+	if radius == 0 {
+		radius = util.DinasRadiusM()
+	}
+	if radius < 0 || radius > util.DinasMaxRadiusM {
+		http.Error(w, "invalid radius_m", http.StatusBadRequest)
+		return
+	}
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	start, err1 := time.ParseInLocation("2006-01-02", req.StartDate, loc)
+	end, err2 := time.ParseInLocation("2006-01-02", req.EndDate, loc)
+	if err1 != nil || err2 != nil || end.Before(start) {
+		http.Error(w, "invalid date range", http.StatusBadRequest)
+		return
+	}
+	days := int(end.Sub(start).Hours()/24) + 1
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	overlap, err := h.Leaves.HasDinasOverlap(ctx, userID, start, end)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if overlap {
+		writeJSON(w, 409, map[string]any{"error": map[string]any{"code": "date_overlap"}})
+		return
+	}
+
+	id, err := h.Leaves.CreateDinasPending(ctx, userID, start, end, days, req.Purpose,
+		req.Destination, *req.Lat, *req.Lng, radius)
+	if err != nil {
+		http.Error(w, "insert failed", http.StatusInternalServerError)
+		return
+	}
+	dr, err := h.Leaves.GetDinas(ctx, id)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dinasJSON(dr))
+}
+
+// ===== POST /leave/dinas/{id}/approve & /leave/dinas/{id}/reject
+// Sama seperti WFH: atasan langsung atau admin.
+
+func (h *LeaveHandler) ApproveDinas(w http.ResponseWriter, r *http.Request) {
+	h.decideDinas(w, r, "approved")
+}
+
+func (h *LeaveHandler) RejectDinas(w http.ResponseWriter, r *http.Request) {
+	h.decideDinas(w, r, "rejected")
+}
+
+func (h *LeaveHandler) decideDinas(w http.ResponseWriter, r *http.Request, status string) {
+	approverID, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	dr, err := h.Leaves.GetDinas(ctx, r.PathValue("id"))
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !canDecide(ctx, w, h.Users, approverID, dr.UserID) {
+		return
+	}
+
+	done, err := h.Leaves.DecideDinas(ctx, dr.ID, status, approverID)
+	if err != nil {
+		http.Error(w, "update failed", http.StatusInternalServerError)
+		return
+	}
+	if !done {
+		http.Error(w, "conflict: already decided", http.StatusConflict)
+		return
+	}
+	if dr, err = h.Leaves.GetDinas(ctx, dr.ID); err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, dinasJSON(dr))
+}
+
+// ===== GET /leave/dinas/list?status=all|pending|approved|rejected&year=YYYY
+
+func (h *LeaveHandler) ListDinas(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	status := q.Get("status")
+	if status == "" {
+		status = "all"
+	}
+	switch status {
+	case "all", "pending", "approved", "rejected":
+	default:
+		http.Error(w, "invalid status", http.StatusBadRequest)
+		return
+	}
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	year := time.Now().In(loc).Year()
+	if y := q.Get("year"); y != "" {
+		v, err := strconv.Atoi(y)
+		if err != nil {
+			http.Error(w, "invalid year", http.StatusBadRequest)
+			return
+		}
+		year = v
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := h.Leaves.ListDinasByYearStatus(ctx, userID, year, status)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	items := make([]map[string]any, 0, len(rows))
+	for _, dr := range rows {
+		items = append(items, dinasJSON(dr))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"year":          year,
+		"status_filter": status,
+		"items":         items,
+	})
+}
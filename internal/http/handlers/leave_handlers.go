@@ -1,27 +1,37 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
 	"absensi/internal/repo"
+	"absensi/internal/storage"
+	"absensi/internal/util/imgutil"
 )
 
 type LeaveHandler struct {
-	Leaves *repo.LeaveRepo
+	Leaves   *repo.LeaveRepo
+	Users    *repo.UserRepo
+	Policies *repo.LeavePolicyRepo
+	Store    storage.Store
 }
 
 // ===== GET /leave/quota  (tahun berjalan) =====
 
 type quotaResp struct {
-	Year          int `json:"year"`
-	QuotaDays     int `json:"quota_days"` // default 12
-	UsedDays      int `json:"used_days"`  // hanya approved
-	RemainingDays int `json:"remaining_days"`
+	Year          int     `json:"year"`
+	QuotaDays     int     `json:"quota_days"` // dari leave_policies / override user
+	UsedDays      int     `json:"used_days"`  // hanya approved
+	RemainingDays int     `json:"remaining_days"`
+	EligibleFrom  *string `json:"eligible_from,omitempty"` // selesai masa percobaan
+	PolicySource  string  `json:"policy_source"`           // override | policy | none
 }
 
 func (h *LeaveHandler) GetQuota(w http.ResponseWriter, r *http.Request) {
@@ -38,8 +48,11 @@ func (h *LeaveHandler) GetQuota(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	const quota = 12
-
+	pol, err := h.effectivePolicy(ctx, userID, "cuti", year)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
 	used, err := h.Leaves.SumApprovedDays(ctx, userID, year)
 	if err != nil {
 		http.Error(w, "db error", http.StatusInternalServerError)
@@ -49,13 +62,7 @@ func (h *LeaveHandler) GetQuota(w http.ResponseWriter, r *http.Request) {
 		used = 0
 	}
 
-	resp := quotaResp{
-		Year:          year,
-		QuotaDays:     quota,
-		UsedDays:      used,
-		RemainingDays: quota - used,
-	}
-	writeJSON(w, http.StatusOK, resp)
+	writeJSON(w, http.StatusOK, pol.quota(used))
 }
 
 // ===== POST /leave/cuti/request  =====
@@ -107,17 +114,30 @@ func (h *LeaveHandler) RequestCuti(w http.ResponseWriter, r *http.Request) {
 
 	// Kuota tahun berjalan
 	year := start.Year() // diasumsikan cuti dalam satu tahun yang sama
-	const quota = 12
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
+	pol, err := h.effectivePolicy(ctx, userID, "cuti", year)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if pol.Source == "none" {
+		// tidak ada kebijakan yang cocok: tolak eksplisit, bukan quota_exceeded
+		writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "no_leave_policy"}})
+		return
+	}
+	if !pol.eligibleOn(start) {
+		writeNotEligible(w, pol)
+		return
+	}
 	used, err := h.Leaves.SumApprovedDays(ctx, userID, year)
 	if err != nil {
 		http.Error(w, "db error", http.StatusInternalServerError)
 		return
 	}
-	remain := quota - used
+	remain := pol.quotaDays() - used
 	if days > remain {
 		// Tidak potong kuota sekarang, tapi kita batasi
 		// agar pengajuan tidak melebihi sisa kuota.
@@ -153,12 +173,7 @@ func (h *LeaveHandler) RequestCuti(w http.ResponseWriter, r *http.Request) {
 		Days:      days,
 		StartDate: start.Format("2006-01-02"),
 		EndDate:   end.Format("2006-01-02"),
-		Quota: quotaResp{
-			Year:          year,
-			QuotaDays:     quota,
-			UsedDays:      used,
-			RemainingDays: remain, // belum dipotong—dipotong saat APPROVE
-		},
+		Quota:     pol.quota(used), // belum dipotong—dipotong saat APPROVE
 	}
 	writeJSON(w, http.StatusCreated, resp)
 }
@@ -223,14 +238,22 @@ func (h *LeaveHandler) ApproveCuti(w http.ResponseWriter, r *http.Request) {
 	}
 
 	year := lr.StartDate.Year()
-	const quota = 12
 
+	pol, err := h.effectivePolicy(ctx, lr.UserID, "cuti", year)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !pol.eligibleOn(officeDay(lr.StartDate)) {
+		writeNotEligible(w, pol)
+		return
+	}
 	used, err := h.Leaves.SumApprovedDays(ctx, lr.UserID, year)
 	if err != nil {
 		http.Error(w, "db error", http.StatusInternalServerError)
 		return
 	}
-	remain := quota - used
+	remain := pol.quotaDays() - used
 	if lr.Days > remain {
 		writeJSON(w, 422, map[string]any{
 			"error": map[string]any{
@@ -254,20 +277,14 @@ func (h *LeaveHandler) ApproveCuti(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newUsed := used + lr.Days
 	resp := cutiDecisionResp{
-		RequestID: lr.ID,
-		Status:    "approved",
-		Reason:    lr.Reason.String,
-		Days:      lr.Days,
-		StartDate: lr.StartDate.Format("2006-01-02"),
-		EndDate:   lr.EndDate.Format("2006-01-02"),
-		QuotaSnapshot: quotaResp{
-			Year:          year,
-			QuotaDays:     quota,
-			UsedDays:      newUsed,
-			RemainingDays: quota - newUsed,
-		},
+		RequestID:     lr.ID,
+		Status:        "approved",
+		Reason:        lr.Reason.String,
+		Days:          lr.Days,
+		StartDate:     lr.StartDate.Format("2006-01-02"),
+		EndDate:       lr.EndDate.Format("2006-01-02"),
+		QuotaSnapshot: pol.quota(used + lr.Days),
 	}
 	writeJSON(w, http.StatusOK, resp)
 }
@@ -325,7 +342,11 @@ func (h *LeaveHandler) RejectCuti(w http.ResponseWriter, r *http.Request) {
 	}
 
 	year := lr.StartDate.Year()
-	const quota = 12
+	pol, err := h.effectivePolicy(ctx, lr.UserID, "cuti", year)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
 	used, err := h.Leaves.SumApprovedDays(ctx, lr.UserID, year)
 	if err != nil {
 		http.Error(w, "db error", http.StatusInternalServerError)
@@ -333,18 +354,13 @@ func (h *LeaveHandler) RejectCuti(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := cutiDecisionResp{
-		RequestID: lr.ID,
-		Status:    "rejected",
-		Reason:    lr.Reason.String,
-		Days:      lr.Days,
-		StartDate: lr.StartDate.Format("2006-01-02"),
-		EndDate:   lr.EndDate.Format("2006-01-02"),
-		QuotaSnapshot: quotaResp{
-			Year:          year,
-			QuotaDays:     quota,
-			UsedDays:      used,         // tidak berubah
-			RemainingDays: quota - used, // tidak berubah
-		},
+		RequestID:     lr.ID,
+		Status:        "rejected",
+		Reason:        lr.Reason.String,
+		Days:          lr.Days,
+		StartDate:     lr.StartDate.Format("2006-01-02"),
+		EndDate:       lr.EndDate.Format("2006-01-02"),
+		QuotaSnapshot: pol.quota(used), // tidak berubah
 	}
 	writeJSON(w, http.StatusOK, resp)
 }
@@ -452,6 +468,90 @@ type sickResp struct {
 	EndDate   string `json:"end_date"`
 }
 
+// readSickReq: JSON sickReq (bukti base64) atau multipart/form-data dengan
+// field start_date, end_date, reason + file doctor_note.
+func readSickReq(w http.ResponseWriter, r *http.Request) (sickReq, []byte, string, bool) {
+	limitBody(w, r)
+	var req sickReq
+	var proof []byte
+	var proofType string
+
+	if isMultipart(r) {
+		err := readMultipart(r, func(name, v string) error {
+			switch name {
+			case "start_date":
+				req.StartDate = v
+			case "end_date":
+				req.EndDate = v
+			case "reason":
+				req.Reason = v
+			}
+			return nil
+		}, func(name string, body io.Reader) error {
+			if name != "doctor_note" {
+				return nil
+			}
+			var err error
+			proof, proofType, err = readDoctorNote(body)
+			return err
+		})
+		if err != nil {
+			writeUploadErr(w, err)
+			return req, nil, "", false
+		}
+		if proof == nil {
+			http.Error(w, "doctor_note required", http.StatusBadRequest)
+			return req, nil, "", false
+		}
+		return req, proof, proofType, true
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			writeUploadErr(w, err)
+			return req, nil, "", false
+		}
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return req, nil, "", false
+	}
+	if req.DoctorNoteBase64 == "" {
+		http.Error(w, "doctor_note_base64 required", http.StatusBadRequest)
+		return req, nil, "", false
+	}
+	raw, err := imgutil.DecodeRaw(req.DoctorNoteBase64)
+	if err != nil {
+		http.Error(w, "invalid doctor_note_base64", http.StatusBadRequest)
+		return req, nil, "", false
+	}
+	proof, proofType, err = readDoctorNote(bytes.NewReader(raw))
+	if err != nil {
+		writeUploadErr(w, err)
+		return req, nil, "", false
+	}
+	return req, proof, proofType, true
+}
+
+// readDoctorNote: foto (JPEG/PNG) dinormalisasi seperti selfie; PDF disimpan apa adanya.
+func readDoctorNote(body io.Reader) ([]byte, string, error) {
+	ct, body, err := imgutil.Sniff(body)
+	if err != nil {
+		return nil, "", err
+	}
+	switch ct {
+	case "image/jpeg", "image/png":
+		jpg, err := imgutil.NormalizeReader(body)
+		if err != nil {
+			return nil, "", imageUploadErr("doctor note", err)
+		}
+		return jpg, "image/jpeg", nil
+	case "application/pdf":
+		b, err := io.ReadAll(body)
+		return b, ct, err
+	}
+	return nil, "", badUpload("doctor note must be jpeg, png or pdf")
+}
+
 func (h *LeaveHandler) RequestSakit(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -462,13 +562,8 @@ func (h *LeaveHandler) RequestSakit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req sickReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
-		return
-	}
-	if req.DoctorNoteBase64 == "" {
-		http.Error(w, "doctor_note_base64 required", http.StatusBadRequest)
+	req, proof, proofType, ok := readSickReq(w, r)
+	if !ok {
 		return
 	}
 
@@ -490,8 +585,24 @@ func (h *LeaveHandler) RequestSakit(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	id, err := h.Leaves.CreateSakitPending(ctx, userID, start, end, days, req.Reason, req.DoctorNoteBase64)
+	proofKey, err := storage.NewKey("leave/"+userID, storage.ExtFor(proofType))
+	if err != nil {
+		http.Error(w, "storage error", http.StatusInternalServerError)
+		return
+	}
+	if proofType == "image/jpeg" {
+		err = storage.PutImage(ctx, h.Store, proofKey, proof)
+	} else {
+		err = h.Store.Put(ctx, proofKey, proof, proofType)
+	}
 	if err != nil {
+		http.Error(w, "storage error", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := h.Leaves.CreateSakitPending(ctx, userID, start, end, days, req.Reason, proofKey)
+	if err != nil {
+		_ = storage.DeleteImage(ctx, h.Store, proofKey)
 		http.Error(w, "insert failed", http.StatusInternalServerError)
 		return
 	}
@@ -576,7 +687,7 @@ func (h *LeaveHandler) RejectSakit(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// ===== GET /leave/sakit/list?status=all|pending|approved|rejected&year=YYYY =====
+// ===== GET /leave/sakit/list?status=all|pending|approved|rejected&year=YYYY&size=thumb|medium|full =====
 
 type sickListItem struct {
 	ID        string  `json:"id"`
@@ -588,6 +699,7 @@ type sickListItem struct {
 	CreatedAt string  `json:"created_at"`           // RFC3339 UTC
 	DecidedAt *string `json:"decided_at,omitempty"` // RFC3339 UTC
 	HasProof  bool    `json:"has_proof"`
+	ProofURL  *string `json:"proof_url,omitempty"` // signed, berumur pendek
 }
 
 type sickListResp struct {
@@ -613,6 +725,10 @@ func (h *LeaveHandler) ListSakit(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid status", http.StatusBadRequest)
 		return
 	}
+	size, ok := photoSize(w, r)
+	if !ok {
+		return
+	}
 
 	loc, _ := time.LoadLocation("Asia/Jakarta")
 	now := time.Now().In(loc)
@@ -646,6 +762,15 @@ func (h *LeaveHandler) ListSakit(w http.ResponseWriter, r *http.Request) {
 
 	items := make([]sickListItem, 0, len(rows))
 	for _, sr := range rows {
+		var proofURL *string
+		if sr.ProofKey.Valid {
+			u, err := storage.ImageURL(ctx, h.Store, sr.ProofKey.String, size, storage.URLTTL())
+			if err != nil {
+				http.Error(w, "storage error", http.StatusInternalServerError)
+				return
+			}
+			proofURL = &u
+		}
 		items = append(items, sickListItem{
 			ID:        sr.ID,
 			Status:    sr.Status,
@@ -655,7 +780,8 @@ func (h *LeaveHandler) ListSakit(w http.ResponseWriter, r *http.Request) {
 			Days:      sr.Days,
 			CreatedAt: sr.CreatedAt.UTC().Format(time.RFC3339),
 			DecidedAt: toPtr(sr.DecidedAt),
-			HasProof:  sr.ProofBase64.Valid && sr.ProofBase64.String != "",
+			HasProof:  sr.ProofKey.Valid || sr.LegacyProof,
+			ProofURL:  proofURL,
 		})
 	}
 
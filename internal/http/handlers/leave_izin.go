@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"absensi/internal/repo"
+)
+
+// ===== POST /leave/izin/request
+// {"type":"late_arrival|early_leave|step_out","date":"yyyy-mm-dd",
+//  "start_time":"HH:MM","end_time":"HH:MM","reason":"..."}
+// Izin sebagian hari; tidak memotong kuota cuti.
+
+type izinReq struct {
+	Type      string `json:"type"`
+	Date      string `json:"date"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Reason    string `json:"reason"`
+}
+
+func izinJSON(ir repo.IzinRequest) map[string]any {
+	m := map[string]any{
+		"id":         ir.ID,
+		"user_id":    ir.UserID,
+		"status":     ir.Status,
+		"type":       ir.Type,
+		"reason":     ir.Reason.String,
+		"date":       ir.StartDate.Format("2006-01-02"),
+		"start_time": ir.StartTime,
+		"end_time":   ir.EndTime,
+		"created_at": ir.CreatedAt.UTC().Format(time.RFC3339),
+		"decided_at": toRFC3339(optTime(ir.DecidedAt)),
+		"decided_by": nil,
+	}
+	if ir.DecidedBy.Valid {
+		m["decided_by"] = ir.DecidedBy.String
+	}
+	return m
+}
+
+func (h *LeaveHandler) RequestIzin(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req izinReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	switch req.Type {
+	case repo.IzinLateArrival, repo.IzinEarlyLeave, repo.IzinStepOut:
+	default:
+		http.Error(w, "invalid type", http.StatusBadRequest)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		http.Error(w, "reason required", http.StatusBadRequest)
+		return
+	}
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	date, err := time.ParseInLocation("2006-01-02", req.Date, loc)
+	if err != nil {
+		http.Error(w, "invalid date", http.StatusBadRequest)
+		return
+	}
+	start, err1 := time.Parse("15:04", req.StartTime)
+	end, err2 := time.Parse("15:04", req.EndTime)
+	if err1 != nil || err2 != nil || !end.After(start) {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	overlap, err := h.Leaves.HasIzinOverlap(ctx, userID, date, req.StartTime, req.EndTime)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if overlap {
+		writeJSON(w, 409, map[string]any{"error": map[string]any{"code": "time_overlap"}})
+		return
+	}
+
+	id, err := h.Leaves.CreateIzinPending(ctx, userID, date, req.Type, req.StartTime, req.EndTime, req.Reason)
+	if err != nil {
+		http.Error(w, "insert failed", http.StatusInternalServerError)
+		return
+	}
+	ir, err := h.Leaves.GetIzin(ctx, id)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, izinJSON(ir))
+}
+
+// ===== POST /leave/izin/{id}/approve & /leave/izin/{id}/reject
+// Sama seperti WFH: atasan langsung atau admin. Izin late_arrival /
+// early_leave yang disetujui menggeser jam masuk / pulang yang diharapkan.
+
+func (h *LeaveHandler) ApproveIzin(w http.ResponseWriter, r *http.Request) {
+	h.decideIzin(w, r, "approved")
+}
+
+func (h *LeaveHandler) RejectIzin(w http.ResponseWriter, r *http.Request) {
+	h.decideIzin(w, r, "rejected")
+}
+
+func (h *LeaveHandler) decideIzin(w http.ResponseWriter, r *http.Request, status string) {
+	approverID, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	ir, err := h.Leaves.GetIzin(ctx, r.PathValue("id"))
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !canDecide(ctx, w, h.Users, approverID, ir.UserID) {
+		return
+	}
+
+	done, err := h.Leaves.DecideIzin(ctx, ir.ID, status, approverID)
+	if err != nil {
+		http.Error(w, "update failed", http.StatusInternalServerError)
+		return
+	}
+	if !done {
+		http.Error(w, "conflict: already decided", http.StatusConflict)
+		return
+	}
+	if ir, err = h.Leaves.GetIzin(ctx, ir.ID); err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, izinJSON(ir))
+}
+
+// ===== GET /leave/izin/list?status=all|pending|approved|rejected&year=YYYY
+
+func (h *LeaveHandler) ListIzin(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	status := q.Get("status")
+	if status == "" {
+		status = "all"
+	}
+	switch status {
+	case "all", "pending", "approved", "rejected":
+	default:
+		http.Error(w, "invalid status", http.StatusBadRequest)
+		return
+	}
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	year := time.Now().In(loc).Year()
+	if y := q.Get("year"); y != "" {
+		v, err := strconv.Atoi(y)
+		if err != nil {
+			http.Error(w, "invalid year", http.StatusBadRequest)
+			return
+		}
+		year = v
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := h.Leaves.ListIzinByYearStatus(ctx, userID, year, status)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	items := make([]map[string]any, 0, len(rows))
+	for _, ir := range rows {
+		items = append(items, izinJSON(ir))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"year":          year,
+		"status_filter": status,
+		"items":         items,
+	})
+}
@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"absensi/internal/models"
+	"absensi/internal/repo"
+)
+
+// leavePolicy: jatah cuti yang berlaku untuk satu user & tahun.
+//   - override user (year spesifik, lalu setiap tahun) → dipakai apa adanya
+//   - kalau tidak ada: kebijakan paling spesifik untuk jabatan / employment_type,
+//     berhak mulai hire_date + probation_months
+//   - tidak ada kebijakan yang cocok → 0 hari
+type leavePolicy struct {
+	Year         int
+	AnnualDays   int
+	EligibleFrom sql.NullTime // NULL = langsung berhak
+	Source       string       // override | policy | none
+	RefID        string       // id override / kebijakan
+}
+
+// quotaDays: 0 kalau baru berhak setelah tahun tsb.
+func (p leavePolicy) quotaDays() int {
+	if p.EligibleFrom.Valid && p.EligibleFrom.Time.Year() > p.Year {
+		return 0
+	}
+	return p.AnnualDays
+}
+
+func (p leavePolicy) eligibleOn(date time.Time) bool {
+	return !p.EligibleFrom.Valid || !date.Before(p.EligibleFrom.Time)
+}
+
+func (p leavePolicy) quota(used int) quotaResp {
+	q := quotaResp{
+		Year:          p.Year,
+		QuotaDays:     p.quotaDays(),
+		UsedDays:      used,
+		RemainingDays: p.quotaDays() - used,
+		PolicySource:  p.Source,
+	}
+	if p.EligibleFrom.Valid {
+		s := p.EligibleFrom.Time.Format("2006-01-02")
+		q.EligibleFrom = &s
+	}
+	return q
+}
+
+func (h *LeaveHandler) effectivePolicy(ctx context.Context, userID, kind string, year int) (leavePolicy, error) {
+	p := leavePolicy{Year: year, Source: "none"}
+
+	o, ok, err := h.Policies.GetOverride(ctx, userID, kind, year)
+	if err != nil {
+		return p, err
+	}
+	if ok {
+		p.AnnualDays, p.Source, p.RefID = o.AnnualDays, "override", o.ID
+		return p, nil
+	}
+
+	u, err := h.Users.GetByID(ctx, userID)
+	if err != nil {
+		return p, err
+	}
+	lp, ok, err := h.Policies.MatchPolicy(ctx, kind, u.Jabatan, u.EmploymentType)
+	if err != nil || !ok {
+		return p, err
+	}
+	p.AnnualDays, p.Source, p.RefID = lp.AnnualDays, "policy", lp.ID
+	if u.HireDate.Valid && lp.ProbationMonths > 0 {
+		p.EligibleFrom = sql.NullTime{Time: officeDay(u.HireDate.Time).AddDate(0, lp.ProbationMonths, 0), Valid: true}
+	}
+	return p, nil
+}
+
+// writeNotEligible: 422 saat cuti jatuh sebelum masa percobaan selesai.
+func writeNotEligible(w http.ResponseWriter, p leavePolicy) {
+	writeJSON(w, 422, map[string]any{
+		"error": map[string]any{
+			"code": "not_eligible",
+			"details": map[string]any{
+				"eligible_from": p.EligibleFrom.Time.Format("2006-01-02"),
+			},
+		},
+	})
+}
+
+// ===== GET /admin/leave-policies?kind=cuti
+
+func leavePolicyJSON(p repo.LeavePolicy) map[string]any {
+	m := map[string]any{
+		"id":               p.ID,
+		"kind":             p.Kind,
+		"jabatan":          nil,
+		"employment_type":  nil,
+		"annual_days":      p.AnnualDays,
+		"probation_months": p.ProbationMonths,
+		"updated_at":       p.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if p.Jabatan.Valid {
+		m["jabatan"] = p.Jabatan.String
+	}
+	if p.EmploymentType.Valid {
+		m["employment_type"] = p.EmploymentType.String
+	}
+	return m
+}
+
+func leaveOverrideJSON(o repo.LeaveOverride) map[string]any {
+	m := map[string]any{
+		"id":          o.ID,
+		"user_id":     o.UserID,
+		"kind":        o.Kind,
+		"year":        nil,
+		"annual_days": o.AnnualDays,
+		"note":        o.Note.String,
+		"updated_at":  o.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if o.Year.Valid {
+		m["year"] = o.Year.Int64
+	}
+	return m
+}
+
+func (h *LeaveHandler) ListPolicies(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	list, err := h.Policies.ListPolicies(ctx, r.URL.Query().Get("kind"))
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	items := make([]map[string]any, 0, len(list))
+	for _, p := range list {
+		items = append(items, leavePolicyJSON(p))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// ===== PUT /admin/leave-policies
+// {"kind":"cuti","jabatan":"","employment_type":"kontrak","annual_days":12,"probation_months":12}
+// jabatan / employment_type kosong = semua. Cakupan yang sama → ditimpa.
+
+type leavePolicyReq struct {
+	Kind            string `json:"kind"`
+	Jabatan         string `json:"jabatan,omitempty"`
+	EmploymentType  string `json:"employment_type,omitempty"`
+	AnnualDays      *int   `json:"annual_days"`
+	ProbationMonths int    `json:"probation_months,omitempty"`
+}
+
+func (h *LeaveHandler) PutPolicy(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r, h.Users)
+	if !ok {
+		return
+	}
+
+	var req leavePolicyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = "cuti"
+	}
+	if !repo.ValidLeavePolicyKind(req.Kind) {
+		http.Error(w, "invalid kind", http.StatusBadRequest)
+		return
+	}
+	req.Jabatan = strings.TrimSpace(req.Jabatan)
+	if req.EmploymentType != "" && !models.ValidEmploymentType(req.EmploymentType) {
+		http.Error(w, "invalid employment_type", http.StatusBadRequest)
+		return
+	}
+	if req.AnnualDays == nil || *req.AnnualDays < 0 || *req.AnnualDays > 366 {
+		http.Error(w, "invalid annual_days", http.StatusBadRequest)
+		return
+	}
+	if req.ProbationMonths < 0 || req.ProbationMonths > 120 {
+		http.Error(w, "invalid probation_months", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	p, err := h.Policies.UpsertPolicy(ctx, repo.LeavePolicy{
+		Kind:            req.Kind,
+		Jabatan:         sql.NullString{String: req.Jabatan, Valid: req.Jabatan != ""},
+		EmploymentType:  sql.NullString{String: req.EmploymentType, Valid: req.EmploymentType != ""},
+		AnnualDays:      *req.AnnualDays,
+		ProbationMonths: req.ProbationMonths,
+		UpdatedBy:       sql.NullString{String: admin.ID, Valid: true},
+	})
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, leavePolicyJSON(p))
+}
+
+// ===== DELETE /admin/leave-policies/{id}
+// Kebijakan umum cuti tidak bisa dihapus (409 default_policy); ubah
+// annual_days-nya lewat PUT.
+
+func (h *LeaveHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	deleted, err := h.Policies.DeletePolicy(ctx, r.PathValue("id"))
+	if errors.Is(err, repo.ErrDefaultPolicy) {
+		writeJSON(w, 409, map[string]any{"error": map[string]any{"code": "default_policy"}})
+		return
+	}
+	if err != nil && !isNotFound(err) {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ===== GET /admin/users/{id}/leave-policy?kind=cuti&year=YYYY
+// Kebijakan efektif + sisa kuota + daftar override user.
+
+func (h *LeaveHandler) GetUserPolicy(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	kind := q.Get("kind")
+	if kind == "" {
+		kind = "cuti"
+	}
+	if !repo.ValidLeavePolicyKind(kind) {
+		http.Error(w, "invalid kind", http.StatusBadRequest)
+		return
+	}
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	year := time.Now().In(loc).Year()
+	if y := q.Get("year"); y != "" {
+		v, err := strconv.Atoi(y)
+		if err != nil {
+			http.Error(w, "invalid year", http.StatusBadRequest)
+			return
+		}
+		year = v
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	uid := r.PathValue("id")
+	pol, err := h.effectivePolicy(ctx, uid, kind, year)
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	used := 0
+	if kind == "cuti" {
+		if used, err = h.Leaves.SumApprovedDays(ctx, uid, year); err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+	}
+	list, err := h.Policies.ListOverrides(ctx, uid)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	overrides := make([]map[string]any, 0, len(list))
+	for _, o := range list {
+		overrides = append(overrides, leaveOverrideJSON(o))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"user_id":   uid,
+		"kind":      kind,
+		"quota":     pol.quota(used),
+		"source_id": pol.RefID,
+		"overrides": overrides,
+	})
+}
+
+// ===== PUT /admin/users/{id}/leave-override
+// {"kind":"cuti","year":2026,"annual_days":15,"note":"kompensasi"}; year null = setiap tahun.
+
+type leaveOverrideReq struct {
+	Kind       string `json:"kind"`
+	Year       *int   `json:"year"`
+	AnnualDays *int   `json:"annual_days"`
+	Note       string `json:"note,omitempty"`
+}
+
+func (h *LeaveHandler) PutUserOverride(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r, h.Users)
+	if !ok {
+		return
+	}
+
+	var req leaveOverrideReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = "cuti"
+	}
+	if !repo.ValidLeavePolicyKind(req.Kind) {
+		http.Error(w, "invalid kind", http.StatusBadRequest)
+		return
+	}
+	if req.AnnualDays == nil || *req.AnnualDays < 0 || *req.AnnualDays > 366 {
+		http.Error(w, "invalid annual_days", http.StatusBadRequest)
+		return
+	}
+	var year sql.NullInt64
+	if req.Year != nil {
+		if *req.Year < 2000 || *req.Year > 2100 {
+			http.Error(w, "invalid year", http.StatusBadRequest)
+			return
+		}
+		year = sql.NullInt64{Int64: int64(*req.Year), Valid: true}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	uid := r.PathValue("id")
+	if _, err := h.Users.GetByID(ctx, uid); err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	o, err := h.Policies.UpsertOverride(ctx, repo.LeaveOverride{
+		UserID:     uid,
+		Kind:       req.Kind,
+		Year:       year,
+		AnnualDays: *req.AnnualDays,
+		Note:       sql.NullString{String: strings.TrimSpace(req.Note), Valid: true},
+		UpdatedBy:  sql.NullString{String: admin.ID, Valid: true},
+	})
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, leaveOverrideJSON(o))
+}
+
+// ===== DELETE /admin/users/{id}/leave-override?kind=cuti&year=YYYY (tanpa year = setiap tahun)
+
+func (h *LeaveHandler) DeleteUserOverride(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Users); !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	kind := q.Get("kind")
+	if kind == "" {
+		kind = "cuti"
+	}
+	if !repo.ValidLeavePolicyKind(kind) {
+		http.Error(w, "invalid kind", http.StatusBadRequest)
+		return
+	}
+	var year sql.NullInt64
+	if y := q.Get("year"); y != "" {
+		v, err := strconv.Atoi(y)
+		if err != nil {
+			http.Error(w, "invalid year", http.StatusBadRequest)
+			return
+		}
+		year = sql.NullInt64{Int64: int64(v), Valid: true}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	deleted, err := h.Policies.DeleteOverride(ctx, r.PathValue("id"), kind, year)
+	if err != nil && !isNotFound(err) {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
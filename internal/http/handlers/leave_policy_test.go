@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"absensi/internal/util"
+)
+
+func TestLeavePolicyQuota(t *testing.T) {
+	eligible := func(y int, m time.Month, d int) sql.NullTime {
+		return sql.NullTime{Time: time.Date(y, m, d, 0, 0, 0, 0, util.OfficeTZ), Valid: true}
+	}
+
+	tests := []struct {
+		name      string
+		p         leavePolicy
+		used      int
+		quota     int
+		remaining int
+		from      *string
+		on        time.Time
+		eligible  bool
+	}{
+		{name: "no probation", p: leavePolicy{Year: 2025, AnnualDays: 12, Source: "policy"},
+			used: 3, quota: 12, remaining: 9, on: time.Date(2025, 1, 1, 0, 0, 0, 0, util.OfficeTZ), eligible: true},
+		{name: "eligible mid-year keeps full quota", p: leavePolicy{Year: 2025, AnnualDays: 12, EligibleFrom: eligible(2025, 7, 1)},
+			quota: 12, remaining: 12, from: ptr("2025-07-01"), on: time.Date(2025, 6, 30, 0, 0, 0, 0, util.OfficeTZ), eligible: false},
+		{name: "eligible on the day", p: leavePolicy{Year: 2025, AnnualDays: 12, EligibleFrom: eligible(2025, 7, 1)},
+			quota: 12, remaining: 12, from: ptr("2025-07-01"), on: time.Date(2025, 7, 1, 0, 0, 0, 0, util.OfficeTZ), eligible: true},
+		{name: "eligible next year", p: leavePolicy{Year: 2025, AnnualDays: 12, EligibleFrom: eligible(2026, 2, 1)},
+			quota: 0, remaining: 0, from: ptr("2026-02-01"), on: time.Date(2025, 12, 31, 0, 0, 0, 0, util.OfficeTZ), eligible: false},
+		{name: "override", p: leavePolicy{Year: 2025, AnnualDays: 15, Source: "override"},
+			used: 16, quota: 15, remaining: -1, on: time.Date(2025, 3, 1, 0, 0, 0, 0, util.OfficeTZ), eligible: true},
+		{name: "no policy", p: leavePolicy{Year: 2025, Source: "none"},
+			quota: 0, remaining: 0, on: time.Date(2025, 3, 1, 0, 0, 0, 0, util.OfficeTZ), eligible: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.p.quota(tt.used)
+			if q.QuotaDays != tt.quota || q.RemainingDays != tt.remaining || q.PolicySource != tt.p.Source {
+				t.Fatalf("quota = %+v, want quota %d remaining %d", q, tt.quota, tt.remaining)
+			}
+			if (q.EligibleFrom == nil) != (tt.from == nil) || (q.EligibleFrom != nil && *q.EligibleFrom != *tt.from) {
+				t.Fatalf("eligible_from = %v, want %v", q.EligibleFrom, tt.from)
+			}
+			if got := tt.p.eligibleOn(tt.on); got != tt.eligible {
+				t.Fatalf("eligibleOn(%s) = %v, want %v", tt.on.Format("2006-01-02"), got, tt.eligible)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string { return &s }
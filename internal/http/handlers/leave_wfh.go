@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"absensi/internal/repo"
+	"absensi/internal/util"
+)
+
+// ===== POST /leave/wfh/request  {"start_date":"yyyy-mm-dd","end_date":"yyyy-mm-dd","reason":"..."}
+// Kebijakan "home" → lokasi rumah (/me/home-location) wajib sudah didaftarkan
+// dan disalin ke pengajuan.
+
+type wfhReq struct {
+	Reason    string `json:"reason"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+func wfhJSON(wr repo.WFHRequest) map[string]any {
+	m := map[string]any{
+		"id":         wr.ID,
+		"user_id":    wr.UserID,
+		"status":     wr.Status,
+		"reason":     wr.Reason.String,
+		"start_date": wr.StartDate.Format("2006-01-02"),
+		"end_date":   wr.EndDate.Format("2006-01-02"),
+		"days":       wr.Days,
+		"home":       nil,
+		"created_at": wr.CreatedAt.UTC().Format(time.RFC3339),
+		"decided_at": toRFC3339(optTime(wr.DecidedAt)),
+		"decided_by": nil,
+	}
+	if wr.Lat.Valid && wr.Lng.Valid {
+		m["home"] = map[string]any{"lat": wr.Lat.Float64, "lng": wr.Lng.Float64}
+	}
+	if wr.DecidedBy.Valid {
+		m["decided_by"] = wr.DecidedBy.String
+	}
+	return m
+}
+
+func (h *LeaveHandler) RequestWFH(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req wfhReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	start, err1 := time.ParseInLocation("2006-01-02", req.StartDate, loc)
+	end, err2 := time.ParseInLocation("2006-01-02", req.EndDate, loc)
+	if err1 != nil || err2 != nil || end.Before(start) {
+		http.Error(w, "invalid date range", http.StatusBadRequest)
+		return
+	}
+	days := int(end.Sub(start).Hours()/24) + 1
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var lat, lng sql.NullFloat64
+	if util.WFHPolicy() == util.WFHPolicyHome {
+		hl, err := h.Users.GetHomeLocation(ctx, userID)
+		if err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+		if !hl.Lat.Valid || !hl.Lng.Valid {
+			writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "home_location_required"}})
+			return
+		}
+		lat, lng = hl.Lat, hl.Lng
+	}
+
+	overlap, err := h.Leaves.HasWFHOverlap(ctx, userID, start, end)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if overlap {
+		writeJSON(w, 409, map[string]any{"error": map[string]any{"code": "date_overlap"}})
+		return
+	}
+
+	id, err := h.Leaves.CreateWFHPending(ctx, userID, start, end, days, req.Reason, lat, lng)
+	if err != nil {
+		http.Error(w, "insert failed", http.StatusInternalServerError)
+		return
+	}
+	wr, err := h.Leaves.GetWFH(ctx, id)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, wfhJSON(wr))
+}
+
+// ===== POST /leave/wfh/{id}/approve & /leave/wfh/{id}/reject
+// Diputuskan atasan langsung (users.manager_id) atau admin, bukan oleh
+// pemohon sendiri.
+
+func (h *LeaveHandler) ApproveWFH(w http.ResponseWriter, r *http.Request) {
+	h.decideWFH(w, r, "approved")
+}
+
+func (h *LeaveHandler) RejectWFH(w http.ResponseWriter, r *http.Request) {
+	h.decideWFH(w, r, "rejected")
+}
+
+func (h *LeaveHandler) decideWFH(w http.ResponseWriter, r *http.Request, status string) {
+	approverID, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	wr, err := h.Leaves.GetWFH(ctx, r.PathValue("id"))
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !canDecide(ctx, w, h.Users, approverID, wr.UserID) {
+		return
+	}
+
+	done, err := h.Leaves.DecideWFH(ctx, wr.ID, status, approverID)
+	if err != nil {
+		http.Error(w, "update failed", http.StatusInternalServerError)
+		return
+	}
+	if !done {
+		http.Error(w, "conflict: already decided", http.StatusConflict)
+		return
+	}
+	if wr, err = h.Leaves.GetWFH(ctx, wr.ID); err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, wfhJSON(wr))
+}
+
+// canDecide: yang memutuskan pengajuan (WFH, dinas, lembur) harus atasan
+// langsung pemiliknya atau admin aktif, dan bukan pemiliknya sendiri.
+// false → response sudah ditulis.
+func canDecide(ctx context.Context, w http.ResponseWriter, users *repo.UserRepo, approverID, ownerID string) bool {
+	if approverID == ownerID {
+		http.Error(w, "forbidden: cannot decide own request", http.StatusForbidden)
+		return false
+	}
+	owner, err := users.GetByID(ctx, ownerID)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return false
+	}
+	if owner.ManagerID == approverID {
+		return true
+	}
+	approver, err := users.GetByID(ctx, approverID)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return false
+	}
+	if approver.IsActive && approver.IsAdmin() {
+		return true
+	}
+	http.Error(w, "forbidden", http.StatusForbidden)
+	return false
+}
+
+// ===== GET /leave/wfh/list?status=all|pending|approved|rejected&year=YYYY
+
+func (h *LeaveHandler) ListWFH(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	status := q.Get("status")
+	if status == "" {
+		status = "all"
+	}
+	switch status {
+	case "all", "pending", "approved", "rejected":
+	default:
+		http.Error(w, "invalid status", http.StatusBadRequest)
+		return
+	}
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	year := time.Now().In(loc).Year()
+	if y := q.Get("year"); y != "" {
+		v, err := strconv.Atoi(y)
+		if err != nil {
+			http.Error(w, "invalid year", http.StatusBadRequest)
+			return
+		}
+		year = v
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := h.Leaves.ListWFHByYearStatus(ctx, userID, year, status)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	items := make([]map[string]any, 0, len(rows))
+	for _, wr := range rows {
+		items = append(items, wfhJSON(wr))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"year":          year,
+		"status_filter": status,
+		"policy":        util.WFHPolicy(),
+		"items":         items,
+	})
+}
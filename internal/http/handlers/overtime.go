@@ -0,0 +1,483 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"absensi/internal/models"
+	"absensi/internal/overtime"
+	"absensi/internal/repo"
+	"absensi/internal/util"
+)
+
+// OvertimeHandler: pengajuan / klaim lembur, persetujuan atasan, dan
+// rekap untuk payroll. Tarif mengikuti internal/overtime.
+type OvertimeHandler struct {
+	Overtime *repo.OvertimeRepo
+	Att      *AttendanceHandler
+}
+
+// defaultSchedule: dipakai untuk user tanpa jadwal kerja (sama dengan REG).
+var defaultSchedule = models.WorkSchedule{StartTime: "08:00", EndTime: "17:00", WorkDays: "1,2,3,4,5"}
+
+func (h *OvertimeHandler) schedule(ctx context.Context, uid string) (models.WorkSchedule, error) {
+	ws, ok, err := h.Att.userSchedule(ctx, uid)
+	if err != nil || !ok {
+		return defaultSchedule, err
+	}
+	return ws, nil
+}
+
+func dayType(ws models.WorkSchedule, date time.Time) string {
+	if ws.IsWorkDay(date) {
+		return overtime.Workday
+	}
+	return overtime.RestDay
+}
+
+// actualOvertime: menit lembur aktual. Hari kerja dihitung dari jam pulang
+// jadwal sampai check-out (istirahat dianggap di jam kerja normal); hari
+// libur seluruh jam kerja dikurangi istirahat. ok=false kalau belum check-out.
+// ws = jadwal saat pengajuan diputuskan (lihat OvertimeRepo.Decide).
+func actualOvertime(ws models.WorkSchedule, date time.Time, in, out sql.NullTime, breaks time.Duration) (int, bool) {
+	if !in.Valid || !out.Valid {
+		return 0, false
+	}
+	from := in.Time
+	if dayType(ws, date) == overtime.Workday {
+		end, err := ws.EndOn(date)
+		if err != nil {
+			return 0, false
+		}
+		from = end
+		if in.Time.After(end) {
+			from = in.Time
+		}
+	} else {
+		from = from.Add(breaks)
+	}
+	if !out.Time.After(from) {
+		return 0, true
+	}
+	return int(out.Time.Sub(from) / time.Minute), true
+}
+
+// officeDay: kolom DATE (UTC 00:00) → anchor 00:00 zona kantor.
+func officeDay(d time.Time) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, util.OfficeTZ)
+}
+
+// otCalc: lembur yang dibayar untuk satu pengajuan.
+type otCalc struct {
+	DayType string
+	Actual  any // menit lembur aktual; nil = belum check-out
+	Payable int // min(aktual, disetujui, batas tarif); 0 kalau belum approved
+	Tiers   []overtime.Tier
+}
+
+func calcOvertime(o repo.OvertimeRequest) otCalc {
+	ws := defaultSchedule
+	if o.ScheduleEnd.Valid && o.WorkDays.Valid {
+		ws.EndTime, ws.WorkDays = o.ScheduleEnd.String, o.WorkDays.String
+	}
+	date := officeDay(o.Date)
+	c := otCalc{DayType: dayType(ws, date)}
+
+	limit := o.Minutes
+	if o.ApprovedMinutes.Valid {
+		limit = int(o.ApprovedMinutes.Int64)
+	}
+	breaks := time.Duration(o.BreakSeconds) * time.Second
+	if m, ok := actualOvertime(ws, date, o.CheckInAt, o.CheckOutAt, breaks); ok {
+		c.Actual = m
+		if o.Status == "approved" {
+			c.Payable = min(m, limit, overtime.MaxMinutes(c.DayType, ws.SixDayWeek()))
+		}
+	}
+	c.Tiers = overtime.Tiers(c.Payable, c.DayType, ws.SixDayWeek())
+	return c
+}
+
+func overtimeJSON(o repo.OvertimeRequest) map[string]any {
+	c := calcOvertime(o)
+	tiers := make([]map[string]any, 0, len(c.Tiers))
+	for _, t := range c.Tiers {
+		tiers = append(tiers, map[string]any{"minutes": t.Minutes, "multiplier": t.Multiplier})
+	}
+
+	m := map[string]any{
+		"id":               o.ID,
+		"user_id":          o.UserID,
+		"username":         o.Username,
+		"date":             o.Date.Format("2006-01-02"),
+		"kind":             o.Kind,
+		"minutes":          o.Minutes,
+		"reason":           o.Reason.String,
+		"status":           o.Status,
+		"approved_minutes": nil,
+		"decided_by":       nil,
+		"decided_at":       toRFC3339(optTime(o.DecidedAt)),
+		"decision_note":    o.DecisionNote.String,
+		"created_at":       o.CreatedAt.UTC().Format(time.RFC3339),
+		"day_type":         c.DayType,
+		"check_out_at":     toRFC3339(optTime(o.CheckOutAt)),
+		"actual_minutes":   c.Actual, // null = belum check-out
+		"payable_minutes":  c.Payable,
+		"tiers":            tiers,
+		"weighted_hours":   overtime.WeightedHours(c.Tiers),
+	}
+	if o.ApprovedMinutes.Valid {
+		m["approved_minutes"] = o.ApprovedMinutes.Int64
+	}
+	if o.DecidedBy.Valid {
+		m["decided_by"] = o.DecidedBy.String
+	}
+	return m
+}
+
+// ===== POST /overtime/request  {"date":"yyyy-mm-dd","minutes":120,"reason":"..."}
+// Pengajuan sebelum lembur (hari ini atau nanti).
+// ===== POST /overtime/claim    {"date":"yyyy-mm-dd","minutes":90,"reason":"..."}
+// Klaim setelah check-out; minutes opsional (default = lembur aktual).
+
+type overtimeReq struct {
+	Date    string `json:"date"`
+	Minutes int    `json:"minutes"`
+	Reason  string `json:"reason"`
+}
+
+func (h *OvertimeHandler) Request(w http.ResponseWriter, r *http.Request) {
+	h.create(w, r, repo.OvertimePre)
+}
+
+func (h *OvertimeHandler) Claim(w http.ResponseWriter, r *http.Request) {
+	h.create(w, r, repo.OvertimeClaim)
+}
+
+func (h *OvertimeHandler) create(w http.ResponseWriter, r *http.Request, kind string) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req overtimeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	date, err := time.ParseInLocation("2006-01-02", req.Date, util.OfficeTZ)
+	if err != nil {
+		http.Error(w, "invalid date", http.StatusBadRequest)
+		return
+	}
+	if req.Minutes < 0 {
+		http.Error(w, "invalid minutes", http.StatusBadRequest)
+		return
+	}
+	today := util.OfficeDate(time.Now())
+	if kind == repo.OvertimePre && date.Before(today) {
+		writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "date_in_past"}})
+		return
+	}
+	if kind == repo.OvertimeClaim && date.After(today) {
+		writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "date_in_future"}})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	ws, err := h.schedule(ctx, uid)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	dt := dayType(ws, date)
+	maxMin := overtime.MaxMinutes(dt, ws.SixDayWeek())
+
+	minutes := req.Minutes
+	if kind == repo.OvertimeClaim {
+		ad, err := h.Att.Attendance.GetByUserAndDate(ctx, uid, date)
+		if err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+		events, err := h.Att.Attendance.ListEvents(ctx, uid, date)
+		if err != nil {
+			http.Error(w, "db error", http.StatusInternalServerError)
+			return
+		}
+		ad.SetWorked(events, time.Now().UTC())
+		actual, ok := actualOvertime(ws, date, ad.CheckInAt, ad.CheckOutAt, time.Duration(ad.BreakSeconds)*time.Second)
+		if !ok {
+			writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "not_checked_out"}})
+			return
+		}
+		if actual == 0 {
+			writeJSON(w, 422, map[string]any{"error": map[string]any{"code": "no_overtime"}})
+			return
+		}
+		if minutes == 0 {
+			minutes = min(actual, maxMin)
+		}
+		if minutes > actual {
+			writeJSON(w, 422, map[string]any{
+				"error": map[string]any{
+					"code":    "exceeds_actual",
+					"details": map[string]any{"actual_minutes": actual},
+				},
+			})
+			return
+		}
+	}
+	if minutes == 0 {
+		http.Error(w, "minutes required", http.StatusBadRequest)
+		return
+	}
+	if minutes > maxMin {
+		writeJSON(w, 422, map[string]any{
+			"error": map[string]any{
+				"code":    "exceeds_limit",
+				"details": map[string]any{"max_minutes": maxMin, "day_type": dt},
+			},
+		})
+		return
+	}
+
+	id, err := h.Overtime.Create(ctx, uid, date, kind, minutes, strings.TrimSpace(req.Reason))
+	if err != nil {
+		if isUniqueViolation(err) {
+			writeJSON(w, 409, map[string]any{"error": map[string]any{"code": "overtime_exists"}})
+			return
+		}
+		http.Error(w, "insert failed", http.StatusInternalServerError)
+		return
+	}
+	o, err := h.Overtime.Get(ctx, id)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, overtimeJSON(o))
+}
+
+// parseMonth: "YYYY-MM" (kosong = bulan ini) → [awal, awal+1 bulan) zona kantor.
+func parseMonth(s string) (time.Time, time.Time, bool) {
+	var start time.Time
+	if s == "" {
+		now := time.Now().In(util.OfficeTZ)
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, util.OfficeTZ)
+	} else {
+		t, err := time.ParseInLocation("2006-01", s, util.OfficeTZ)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		start = t
+	}
+	return start, start.AddDate(0, 1, 0), true
+}
+
+// ===== GET /overtime/list?month=YYYY-MM
+
+func (h *OvertimeHandler) List(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := parseMonth(r.URL.Query().Get("month"))
+	if !ok {
+		http.Error(w, "invalid month", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := h.Overtime.ListByUser(ctx, uid, from, to)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	items := make([]map[string]any, 0, len(rows))
+	for _, o := range rows {
+		items = append(items, overtimeJSON(o))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"month": from.Format("2006-01"), "items": items})
+}
+
+// ===== GET /overtime/approvals?status=pending|approved|rejected|all
+// Pengajuan bawahan langsung; admin melihat semua.
+
+func (h *OvertimeHandler) Approvals(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+	switch status {
+	case "all", "pending", "approved", "rejected":
+	default:
+		http.Error(w, "invalid status", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	me, err := h.Att.Users.GetByID(ctx, uid)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	rows, err := h.Overtime.ListForApprover(ctx, uid, me.IsActive && me.IsAdmin(), status)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	items := make([]map[string]any, 0, len(rows))
+	for _, o := range rows {
+		if o.UserID == uid {
+			continue
+		}
+		items = append(items, overtimeJSON(o))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status_filter": status, "items": items})
+}
+
+// ===== POST /overtime/{id}/approve  {"minutes":90,"note":"..."}  (minutes opsional, batas atas)
+// ===== POST /overtime/{id}/reject   {"note":"..."}
+
+type overtimeDecisionReq struct {
+	Minutes int    `json:"minutes,omitempty"`
+	Note    string `json:"note,omitempty"`
+}
+
+func (h *OvertimeHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, "approved")
+}
+
+func (h *OvertimeHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, "rejected")
+}
+
+func (h *OvertimeHandler) decide(w http.ResponseWriter, r *http.Request, status string) {
+	approverID, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req overtimeDecisionReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Minutes < 0 || (status == "rejected" && req.Minutes != 0) {
+		http.Error(w, "invalid minutes", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	o, err := h.Overtime.Get(ctx, r.PathValue("id"))
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if !canDecide(ctx, w, h.Att.Users, approverID, o.UserID) {
+		return
+	}
+	if req.Minutes > o.Minutes {
+		writeJSON(w, 422, map[string]any{
+			"error": map[string]any{
+				"code":    "exceeds_requested",
+				"details": map[string]any{"requested_minutes": o.Minutes},
+			},
+		})
+		return
+	}
+
+	done, err := h.Overtime.Decide(ctx, o.ID, status, req.Minutes, approverID, strings.TrimSpace(req.Note))
+	if err != nil {
+		http.Error(w, "update failed", http.StatusInternalServerError)
+		return
+	}
+	if !done {
+		http.Error(w, "conflict: already decided", http.StatusConflict)
+		return
+	}
+	if o, err = h.Overtime.Get(ctx, o.ID); err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, overtimeJSON(o))
+}
+
+// ===== GET /admin/overtime/payroll?month=YYYY-MM
+// Rekap lembur disetujui per user. Upah lembur =
+// weighted_hours × upah sebulan / hourly_divisor.
+
+func (h *OvertimeHandler) Payroll(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdmin(w, r, h.Att.Users); !ok {
+		return
+	}
+	from, to, ok := parseMonth(r.URL.Query().Get("month"))
+	if !ok {
+		http.Error(w, "invalid month", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	rows, err := h.Overtime.ListApproved(ctx, from, to)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+
+	type payrollUser struct {
+		UserID         string           `json:"user_id"`
+		Username       string           `json:"username"`
+		PayableMinutes int              `json:"payable_minutes"`
+		WeightedHours  float64          `json:"weighted_hours"`
+		Items          []map[string]any `json:"items"`
+	}
+	users := make([]*payrollUser, 0)
+	var cur *payrollUser
+	var tiers []overtime.Tier
+	for _, o := range rows { // urut username
+		if cur == nil || cur.UserID != o.UserID {
+			if cur != nil {
+				cur.WeightedHours = overtime.WeightedHours(tiers)
+			}
+			cur, tiers = &payrollUser{UserID: o.UserID, Username: o.Username}, nil
+			users = append(users, cur)
+		}
+		c := calcOvertime(o)
+		cur.PayableMinutes += c.Payable
+		cur.Items = append(cur.Items, overtimeJSON(o))
+		tiers = append(tiers, c.Tiers...)
+	}
+	if cur != nil {
+		cur.WeightedHours = overtime.WeightedHours(tiers)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"month":          from.Format("2006-01"),
+		"hourly_divisor": overtime.HourlyDivisor,
+		"users":          users,
+	})
+}
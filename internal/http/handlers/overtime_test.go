@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"absensi/internal/overtime"
+	"absensi/internal/repo"
+	"absensi/internal/util"
+)
+
+func officeTime(day int, hhmm string) sql.NullTime {
+	t, _ := time.ParseInLocation("2006-01-02 15:04", fmt.Sprintf("2025-08-%02d %s", day, hhmm), util.OfficeTZ)
+	return sql.NullTime{Time: t, Valid: true}
+}
+
+func TestActualOvertime(t *testing.T) {
+	fri := time.Date(2025, 8, 1, 0, 0, 0, 0, util.OfficeTZ) // hari kerja REG
+	sat := time.Date(2025, 8, 2, 0, 0, 0, 0, util.OfficeTZ) // hari libur REG
+
+	tests := []struct {
+		name    string
+		date    time.Time
+		in, out sql.NullTime
+		breaks  time.Duration
+		want    int
+		ok      bool
+	}{
+		{"not checked out", fri, officeTime(1, "08:00"), sql.NullTime{}, 0, 0, false},
+		{"not checked in", fri, sql.NullTime{}, officeTime(1, "19:00"), 0, 0, false},
+		{"left on time", fri, officeTime(1, "08:00"), officeTime(1, "17:00"), 0, 0, true},
+		{"left early", fri, officeTime(1, "08:00"), officeTime(1, "16:00"), 0, 0, true},
+		{"workday 2h after end", fri, officeTime(1, "08:00"), officeTime(1, "19:00"), 0, 120, true},
+		{"workday breaks ignored", fri, officeTime(1, "08:00"), officeTime(1, "19:00"), time.Hour, 120, true},
+		{"workday checked in after end", fri, officeTime(1, "18:00"), officeTime(1, "19:30"), 0, 90, true},
+		{"rest day whole stay", sat, officeTime(2, "09:00"), officeTime(2, "14:00"), 0, 300, true},
+		{"rest day minus breaks", sat, officeTime(2, "09:00"), officeTime(2, "14:00"), 45 * time.Minute, 255, true},
+		{"rest day break longer than stay", sat, officeTime(2, "09:00"), officeTime(2, "09:30"), time.Hour, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := actualOvertime(defaultSchedule, tt.date, tt.in, tt.out, tt.breaks)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("actualOvertime = %d, %v; want %d, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCalcOvertime(t *testing.T) {
+	date := time.Date(2025, 8, 2, 0, 0, 0, 0, time.UTC) // kolom DATE, Sabtu
+	base := repo.OvertimeRequest{
+		Date: date, Minutes: 660, Status: "approved",
+		CheckInAt: officeTime(2, "08:00"), CheckOutAt: officeTime(2, "19:00"),
+		BreakSeconds: 3600,
+	}
+
+	tests := []struct {
+		name    string
+		o       func(repo.OvertimeRequest) repo.OvertimeRequest
+		dayType string
+		payable int
+	}{
+		{"rest day, break deducted", nil, overtime.RestDay, 600},
+		{"approved minutes cap", func(o repo.OvertimeRequest) repo.OvertimeRequest {
+			o.ApprovedMinutes = sql.NullInt64{Int64: 240, Valid: true}
+			return o
+		}, overtime.RestDay, 240},
+		{"pending pays nothing", func(o repo.OvertimeRequest) repo.OvertimeRequest { o.Status = "pending"; return o }, overtime.RestDay, 0},
+		{"snapshot six-day schedule makes saturday a workday", func(o repo.OvertimeRequest) repo.OvertimeRequest {
+			o.ScheduleEnd = sql.NullString{String: "15:00", Valid: true}
+			o.WorkDays = sql.NullString{String: "1,2,3,4,5,6", Valid: true}
+			return o
+		}, overtime.Workday, overtime.MaxWorkdayMinutes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := base
+			if tt.o != nil {
+				o = tt.o(o)
+			}
+			c := calcOvertime(o)
+			if c.DayType != tt.dayType || c.Payable != tt.payable {
+				t.Fatalf("calcOvertime = %s/%d, want %s/%d", c.DayType, c.Payable, tt.dayType, tt.payable)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"absensi/internal/models"
+	"absensi/internal/repo"
+	"absensi/internal/util"
+	"absensi/internal/util/imgutil"
+)
+
+// userJSON: profil lengkap user (dipakai /get-user, /me, dan endpoint admin).
+func userJSON(u models.User) map[string]any {
+	dateOrNil := func(nt sql.NullTime) any {
+		if nt.Valid {
+			return nt.Time.Format("2006-01-02")
+		}
+		return nil
+	}
+	return map[string]any{
+		"id":                u.ID,
+		"username":          u.Username,
+		"jabatan":           u.Jabatan,
+		"role":              u.Role,
+		"office":            u.Office,
+		"full_name":         u.FullName,
+		"nip":               u.NIP,
+		"email":             u.Email,
+		"phone":             u.Phone,
+		"department":        u.Department,
+		"hire_date":         dateOrNil(u.HireDate),
+		"employment_type":   u.EmploymentType,
+		"contract_end_date": dateOrNil(u.ContractEndDate),
+		"has_photo":         u.HasPhoto,
+		"manager_id":        u.ManagerID,
+		"schedule_code":     u.ScheduleCode,
+		"is_active":         u.IsActive,
+		"deactivated_at":    toRFC3339(optTime(u.DeactivatedAt)),
+		"created_at":        u.CreatedAt.UTC().Format(time.RFC3339),
+		"updated_at":        u.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// userJSONWithPhoto: userJSON + photo_base64 (untuk tampilan detail 1 user).
+func userJSONWithPhoto(ctx context.Context, users *repo.UserRepo, u models.User) (map[string]any, error) {
+	resp := userJSON(u)
+	resp["photo_base64"] = nil
+	if u.HasPhoto {
+		photo, err := users.GetPhoto(ctx, u.ID)
+		if err != nil {
+			return nil, err
+		}
+		resp["photo_base64"] = photo
+	}
+	return resp, nil
+}
+
+// profileFields: field profil yang boleh diubah pegawai sendiri.
+type profileFields struct {
+	FullName    *string `json:"full_name,omitempty"`
+	Email       *string `json:"email,omitempty"`
+	Phone       *string `json:"phone,omitempty"`
+	PhotoBase64 *string `json:"photo_base64,omitempty"` // "" = hapus foto
+}
+
+// hrFields: data master HR, hanya admin.
+type hrFields struct {
+	NIP             *string `json:"nip,omitempty"`
+	Department      *string `json:"department,omitempty"`
+	HireDate        *string `json:"hire_date,omitempty"`       // yyyy-mm-dd
+	EmploymentType  *string `json:"employment_type,omitempty"` // tetap|kontrak|magang
+	ContractEndDate *string `json:"contract_end_date,omitempty"`
+	ManagerID       *string `json:"manager_id,omitempty"`    // "" = tanpa atasan
+	ScheduleCode    *string `json:"schedule_code,omitempty"` // work_schedules.code
+}
+
+func trimPtr(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*s)
+	return &v
+}
+
+// apply: validasi + isi patch. Error-nya aman ditampilkan ke client.
+func (f profileFields) apply(p *repo.UserPatch) error {
+	p.FullName = trimPtr(f.FullName)
+	if e := trimPtr(f.Email); e != nil {
+		if *e != "" {
+			if !util.ValidEmail(*e) {
+				return errors.New("invalid email")
+			}
+		}
+		p.Email = e
+	}
+	if ph := trimPtr(f.Phone); ph != nil {
+		if *ph != "" && !util.ValidPhone(*ph) {
+			return errors.New("invalid phone")
+		}
+		p.Phone = ph
+	}
+	return nil
+}
+
+func (f hrFields) apply(p *repo.UserPatch) error {
+	if n := trimPtr(f.NIP); n != nil {
+		if *n != "" && !util.ValidNIP(*n) {
+			return errors.New("invalid nip")
+		}
+		p.NIP = n
+	}
+	p.Department = trimPtr(f.Department)
+	for _, d := range []*string{trimPtr(f.HireDate), trimPtr(f.ContractEndDate)} {
+		if d != nil && *d != "" {
+			if _, err := time.Parse("2006-01-02", *d); err != nil {
+				return errors.New("invalid date (yyyy-mm-dd)")
+			}
+		}
+	}
+	p.HireDate = trimPtr(f.HireDate)
+	p.ContractEndDate = trimPtr(f.ContractEndDate)
+	if t := trimPtr(f.EmploymentType); t != nil {
+		if *t != "" && !models.ValidEmploymentType(*t) {
+			return errors.New("invalid employment_type")
+		}
+		p.EmploymentType = t
+	}
+	p.ManagerID = trimPtr(f.ManagerID)
+	p.ScheduleCode = trimPtr(f.ScheduleCode)
+	return nil
+}
+
+// patchToUser: salin field profil/HR dari patch ke base (untuk insert baru).
+func patchToUser(p repo.UserPatch, base models.User) models.User {
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	base.FullName = deref(p.FullName)
+	base.NIP = deref(p.NIP)
+	base.Email = deref(p.Email)
+	base.Phone = deref(p.Phone)
+	base.Department = deref(p.Department)
+	base.HireDate = parseOptDate(deref(p.HireDate))
+	base.EmploymentType = deref(p.EmploymentType)
+	base.ContractEndDate = parseOptDate(deref(p.ContractEndDate))
+	base.ManagerID = deref(p.ManagerID)
+	base.ScheduleCode = deref(p.ScheduleCode)
+	return base
+}
+
+// checkContract: validasi silang jenis kepegawaian vs tanggal kontrak,
+// setelah patch diterapkan ke data user saat ini.
+func checkContract(cur models.User, p repo.UserPatch) error {
+	empType := cur.EmploymentType
+	if p.EmploymentType != nil {
+		empType = *p.EmploymentType
+	}
+	dateStr := func(nt sql.NullTime, override *string) string {
+		if override != nil {
+			return *override
+		}
+		if nt.Valid {
+			return nt.Time.Format("2006-01-02")
+		}
+		return ""
+	}
+	hire := dateStr(cur.HireDate, p.HireDate)
+	end := dateStr(cur.ContractEndDate, p.ContractEndDate)
+
+	if empType == models.EmploymentTetap && end != "" {
+		return errors.New("contract_end_date not allowed for tetap")
+	}
+	if hire != "" && end != "" && end < hire {
+		return errors.New("contract_end_date before hire_date")
+	}
+	return nil
+}
+
+func isFKViolation(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "foreign key")
+}
+
+// conflictMessage: pesan 409 sesuai unique index yang dilanggar.
+func conflictMessage(err error) string {
+	low := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(low, "nip"):
+		return "nip already exists"
+	case strings.Contains(low, "email"):
+		return "email already exists"
+	default:
+		return "username already exists"
+	}
+}
+
+// parseOptDate: "yyyy-mm-dd" → NullTime; string kosong/invalid → NULL.
+func parseOptDate(s string) sql.NullTime {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t, Valid: true}
+}
+
+// normalizePhoto: normalisasi foto profil; input kosong → "" (hapus foto).
+func normalizePhoto(in string) (string, error) {
+	if strings.TrimSpace(in) == "" {
+		return "", nil
+	}
+	return imgutil.NormalizeBase64(in)
+}
+
+// ===== GET /me
+
+func (h *AuthHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	u, err := h.Users.GetByID(ctx, uid)
+	if err != nil {
+		http.Error(w, "user not found", http.StatusUnauthorized)
+		return
+	}
+
+	resp, err := userJSONWithPhoto(ctx, h.Users, u)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ===== PATCH /me  (hanya full_name, email, phone, photo_base64)
+
+func (h *AuthHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	uid, _, ok := mustAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req profileFields
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields() // field HR/jabatan tidak boleh diubah sendiri
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, "invalid json or field not editable", http.StatusBadRequest)
+		return
+	}
+
+	var patch repo.UserPatch
+	if err := req.apply(&patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.PhotoBase64 != nil {
+		norm, err := normalizePhoto(*req.PhotoBase64)
+		if err != nil {
+			http.Error(w, "invalid photo: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		patch.PhotoBase64 = &norm
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// foto ikut dalam UPDATE yang sama → gagal validasi / konflik tidak
+	// meninggalkan foto yang sudah terganti
+	u, err := h.Users.Update(ctx, uid, patch)
+	if err != nil {
+		if isNotFound(err) {
+			http.Error(w, "user not found", http.StatusUnauthorized)
+			return
+		}
+		if isUniqueViolation(err) {
+			http.Error(w, conflictMessage(err), http.StatusConflict)
+			return
+		}
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, userJSON(u))
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"absensi/internal/util"
+)
+
+const maxFieldBytes = 4 << 10 // field teks multipart (lat, tanggal, alasan, ...)
+
+// uploadError: kesalahan input upload dengan status HTTP-nya.
+type uploadError struct {
+	code int
+	msg  string
+}
+
+func (e *uploadError) Error() string { return e.msg }
+
+func badUpload(format string, args ...any) error {
+	return &uploadError{code: http.StatusBadRequest, msg: fmt.Sprintf(format, args...)}
+}
+
+func isMultipart(r *http.Request) bool {
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mt == "multipart/form-data"
+}
+
+// limitBody: MaxBytesReader sesuai UPLOAD_MAX_MB; JSON base64 dapat
+// kelonggaran karena ukurannya ~4/3 dari file aslinya.
+func limitBody(w http.ResponseWriter, r *http.Request) {
+	limit := util.UploadMaxBytes()
+	if !isMultipart(r) {
+		limit = limit*4/3 + 64<<10
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+}
+
+// readMultipart: baca multipart/form-data secara streaming (tanpa
+// ParseMultipartForm yang menampung file ke memori/disk). onFile menerima
+// body part apa adanya; ukuran total sudah dibatasi oleh limitBody.
+func readMultipart(r *http.Request, onField func(name, value string) error, onFile func(name string, body io.Reader) error) error {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return badUpload("invalid multipart body")
+	}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := part.FormName()
+		if part.FileName() == "" {
+			b, err := io.ReadAll(io.LimitReader(part, maxFieldBytes+1))
+			if err != nil {
+				return err
+			}
+			if len(b) > maxFieldBytes {
+				return badUpload("field %s too long", name)
+			}
+			err = onField(name, strings.TrimSpace(string(b)))
+			part.Close()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err = onFile(name, part)
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeUploadErr: 413 kalau body melebihi batas, status dari uploadError,
+// selain itu 400.
+func writeUploadErr(w http.ResponseWriter, err error) {
+	var tooBig *http.MaxBytesError
+	var ue *uploadError
+	switch {
+	case errors.As(err, &tooBig):
+		http.Error(w, "upload too large", http.StatusRequestEntityTooLarge)
+	case errors.As(err, &ue):
+		http.Error(w, ue.msg, ue.code)
+	default:
+		http.Error(w, "invalid upload: "+err.Error(), http.StatusBadRequest)
+	}
+}
@@ -4,39 +4,98 @@ import (
 	"database/sql"
 	"net/http"
 
+	"absensi/internal/face"
 	"absensi/internal/http/handlers"
+	"absensi/internal/liveness"
 	"absensi/internal/repo"
+	"absensi/internal/storage"
 )
 
-func New(db *sql.DB) http.Handler {
+func New(db *sql.DB, store storage.Store) http.Handler {
 	mux := http.NewServeMux()
 
 	uh := &handlers.AuthHandler{
 		Users:       repo.NewUserRepo(db),
 		RefreshRepo: repo.NewRefreshRepo(db),
+		Invites:     repo.NewInvitationRepo(db),
 	}
 	ah := &handlers.AttendanceHandler{
 		Users:      repo.NewUserRepo(db),
 		Attendance: repo.NewAttendanceRepo(db),
+		Faces:      repo.NewFaceRepo(db),
+		Flags:      repo.NewFlagRepo(db),
+		Face:       face.NewLBPVerifier(),
+		Liveness:   repo.NewLivenessRepo(db),
+		Live:       liveness.NewMotionChecker(),
+		Store:      store,
+		Hashes:     repo.NewPhotoHashRepo(db),
+		Presence:   repo.NewPresenceRepo(db),
+		QR:         repo.NewQRKioskRepo(db),
+		Devices:    repo.NewDeviceRepo(db),
+		Schedules:  repo.NewScheduleRepo(db),
+		Leaves:     repo.NewLeaveRepo(db),
+	}
+	kh := &handlers.KioskHandler{
+		Users:   repo.NewUserRepo(db),
+		QR:      repo.NewQRKioskRepo(db),
+		Devices: repo.NewKioskRepo(db),
+		Att:     ah,
 	}
 
+	oh := &handlers.OvertimeHandler{
+		Overtime: repo.NewOvertimeRepo(db),
+		Att:      ah,
+	}
 	lh := &handlers.LeaveHandler{
-		Leaves: repo.NewLeaveRepo(db),
+		Leaves:   repo.NewLeaveRepo(db),
+		Users:    repo.NewUserRepo(db),
+		Policies: repo.NewLeavePolicyRepo(db),
+		Store:    store,
+	}
+	adm := &handlers.AdminHandler{
+		Users:       repo.NewUserRepo(db),
+		RefreshRepo: repo.NewRefreshRepo(db),
+		Invites:     repo.NewInvitationRepo(db),
+	}
+	imp := &handlers.ImpersonationHandler{
+		Users: repo.NewUserRepo(db),
+		Audit: repo.NewAuditRepo(db),
+	}
+	idem := &handlers.IdempotencyHandler{
+		Keys: repo.NewIdempotencyRepo(db),
 	}
 
+	// backend lokal melayani file sendiri; S3 memakai presigned URL langsung
+	if local, ok := store.(*storage.Local); ok {
+		mux.Handle("GET /files/", http.StripPrefix("/files/", local))
+	}
+
+	mux.HandleFunc("GET /invitations", uh.GetInvitation)
 	mux.HandleFunc("POST /register", uh.Register)
 	mux.HandleFunc("POST /login", uh.Login)
 	mux.HandleFunc("POST /refresh", uh.RefreshToken)
 	mux.HandleFunc("POST /logout", uh.Logout)
 	mux.HandleFunc("GET /get-user", uh.GetUser)
+	mux.HandleFunc("GET /me", uh.GetMe)
+	mux.HandleFunc("PATCH /me", uh.UpdateMe)
+	mux.HandleFunc("GET /me/face", ah.GetMyFace)
+	mux.HandleFunc("POST /me/face", ah.EnrollMyFace)
+	mux.HandleFunc("GET /me/devices", ah.ListMyDevices)
+	mux.HandleFunc("POST /me/devices", ah.RegisterMyDevice)
+	mux.HandleFunc("DELETE /me/devices/{id}", ah.RevokeMyDevice)
+	mux.HandleFunc("GET /me/home-location", ah.GetHomeLocation)
+	mux.HandleFunc("PUT /me/home-location", ah.SetHomeLocation)
 
 	mux.HandleFunc("GET /config/office", ah.GetOfficeConfig)
 	mux.HandleFunc("POST /attendance/status", ah.Status)
 	mux.HandleFunc("POST /attendance/check-in", ah.CheckIn)
 	mux.HandleFunc("POST /attendance/check-out", ah.CheckOut)
+	mux.HandleFunc("POST /attendance/offline", ah.Offline)
+	mux.HandleFunc("POST /attendance/events", ah.PostEvent)
 	mux.HandleFunc("POST /attendance/debug/reset-today", ah.DebugResetToday)
 	mux.HandleFunc("GET /attendance/marks", ah.GetMarks)
 	mux.HandleFunc("GET /attendance/day", ah.GetDay)
+	mux.HandleFunc("GET /attendance/photo", ah.GetPhoto)
 
 	mux.HandleFunc("GET /leave/quota", lh.GetQuota)
 	mux.HandleFunc("POST /leave/cuti/request", lh.RequestCuti)
@@ -49,5 +108,71 @@ func New(db *sql.DB) http.Handler {
 	mux.HandleFunc("POST /leave/sakit/{id}/reject", lh.RejectSakit)
 	mux.HandleFunc("GET /leave/sakit/list", lh.ListSakit)
 
-	return mux
+	mux.HandleFunc("POST /leave/wfh/request", lh.RequestWFH)
+	mux.HandleFunc("POST /leave/wfh/{id}/approve", lh.ApproveWFH)
+	mux.HandleFunc("POST /leave/wfh/{id}/reject", lh.RejectWFH)
+	mux.HandleFunc("GET /leave/wfh/list", lh.ListWFH)
+
+	mux.HandleFunc("POST /leave/dinas/request", lh.RequestDinas)
+	mux.HandleFunc("POST /leave/dinas/{id}/approve", lh.ApproveDinas)
+	mux.HandleFunc("POST /leave/dinas/{id}/reject", lh.RejectDinas)
+	mux.HandleFunc("GET /leave/dinas/list", lh.ListDinas)
+
+	mux.HandleFunc("POST /leave/izin/request", lh.RequestIzin)
+	mux.HandleFunc("POST /leave/izin/{id}/approve", lh.ApproveIzin)
+	mux.HandleFunc("POST /leave/izin/{id}/reject", lh.RejectIzin)
+	mux.HandleFunc("GET /leave/izin/list", lh.ListIzin)
+
+	mux.HandleFunc("POST /overtime/request", oh.Request)
+	mux.HandleFunc("POST /overtime/claim", oh.Claim)
+	mux.HandleFunc("GET /overtime/list", oh.List)
+	mux.HandleFunc("GET /overtime/approvals", oh.Approvals)
+	mux.HandleFunc("POST /overtime/{id}/approve", oh.Approve)
+	mux.HandleFunc("POST /overtime/{id}/reject", oh.Reject)
+	mux.HandleFunc("GET /admin/overtime/payroll", oh.Payroll)
+	mux.HandleFunc("GET /admin/leave-policies", lh.ListPolicies)
+	mux.HandleFunc("PUT /admin/leave-policies", lh.PutPolicy)
+	mux.HandleFunc("DELETE /admin/leave-policies/{id}", lh.DeletePolicy)
+
+	mux.HandleFunc("GET /admin/users", adm.ListUsers)
+	mux.HandleFunc("POST /admin/users", adm.CreateUser)
+	mux.HandleFunc("POST /admin/users/import", adm.ImportUsers)
+	mux.HandleFunc("GET /admin/users/export", adm.ExportUsers)
+	mux.HandleFunc("GET /admin/users/{id}", adm.GetUser)
+	mux.HandleFunc("PATCH /admin/users/{id}", adm.UpdateUser)
+	mux.HandleFunc("POST /admin/users/{id}/deactivate", adm.DeactivateUser)
+	mux.HandleFunc("POST /admin/users/{id}/reactivate", adm.ReactivateUser)
+	mux.HandleFunc("PUT /admin/users/{id}/face", ah.AdminEnrollFace)
+	mux.HandleFunc("DELETE /admin/users/{id}/face", ah.AdminDeleteFace)
+	mux.HandleFunc("GET /admin/users/{id}/leave-policy", lh.GetUserPolicy)
+	mux.HandleFunc("PUT /admin/users/{id}/leave-override", lh.PutUserOverride)
+	mux.HandleFunc("DELETE /admin/users/{id}/leave-override", lh.DeleteUserOverride)
+
+	mux.HandleFunc("GET /admin/attendance/flags", ah.ListFlags)
+	mux.HandleFunc("POST /admin/attendance/flags/{id}/resolve", ah.ResolveFlag)
+	mux.HandleFunc("GET /admin/presence-proofs", ah.ListPresenceProofs)
+	mux.HandleFunc("POST /admin/presence-proofs", ah.CreatePresenceProof)
+	mux.HandleFunc("DELETE /admin/presence-proofs/{id}", ah.DeletePresenceProof)
+
+	mux.HandleFunc("GET /admin/kiosks/qr", kh.ListQR)
+	mux.HandleFunc("PUT /admin/kiosks/qr/{office}", kh.RotateQR)
+	mux.HandleFunc("DELETE /admin/kiosks/qr/{office}", kh.DeleteQR)
+	mux.HandleFunc("GET /admin/kiosks/devices", kh.ListDevices)
+	mux.HandleFunc("POST /admin/kiosks/devices", kh.CreateDevice)
+	mux.HandleFunc("POST /admin/kiosks/devices/{id}/revoke", kh.RevokeDevice)
+	mux.HandleFunc("GET /admin/badges", kh.ListBadges)
+	mux.HandleFunc("PUT /admin/badges/{uid}", kh.PutBadge)
+	mux.HandleFunc("DELETE /admin/badges/{uid}", kh.DeleteBadge)
+
+	mux.HandleFunc("GET /kiosk/qr/{office}", kh.CurrentQR)
+	mux.HandleFunc("POST /kiosk/punch", kh.Punch)
+
+	mux.HandleFunc("POST /admin/invitations", adm.CreateInvitation)
+	mux.HandleFunc("GET /admin/invitations", adm.ListInvitations)
+	mux.HandleFunc("POST /admin/invitations/{id}/revoke", adm.RevokeInvitation)
+
+	mux.HandleFunc("POST /admin/impersonate", imp.Start)
+	mux.HandleFunc("GET /admin/impersonation/audit", imp.ListAudit)
+
+	return imp.Guard(adm.RequireActive(idem.Wrap(mux)))
 }
@@ -0,0 +1,110 @@
+// Package kioskqr: kode QR berputar untuk tablet kiosk di pintu masuk.
+// Kode diturunkan dari secret kantor + jendela waktu 30 detik (mirip TOTP),
+// jadi tablet bisa menghitungnya sendiri tanpa koneksi.
+//
+// Payload QR: "absensi-qr:1:<office>:<step>:<code>" (office di-escape URL,
+// termasuk ":").
+package kioskqr
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Step       = 30 * time.Second
+	SecretSize = 32
+	prefix     = "absensi-qr:1:"
+	codeLen    = 12 // hex
+)
+
+var (
+	ErrMalformed = errors.New("malformed qr payload")
+	ErrExpired   = errors.New("qr code expired")
+	ErrInvalid   = errors.New("invalid qr code")
+)
+
+// NewSecret: secret acak untuk satu kantor.
+func NewSecret() ([]byte, error) {
+	b := make([]byte, SecretSize)
+	_, err := rand.Read(b)
+	return b, err
+}
+
+// StepAt: nomor jendela waktu untuk t.
+func StepAt(t time.Time) int64 { return t.Unix() / int64(Step/time.Second) }
+
+// StepEnd: akhir jendela step (kode berganti).
+func StepEnd(step int64) time.Time {
+	return time.Unix((step+1)*int64(Step/time.Second), 0).UTC()
+}
+
+// Code: HMAC-SHA256(secret, office || step) dipotong 12 hex.
+func Code(secret []byte, office string, step int64) string {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(office))
+	m.Write([]byte{0})
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(step))
+	m.Write(b[:])
+	return hex.EncodeToString(m.Sum(nil))[:codeLen]
+}
+
+// Payload: isi QR yang ditampilkan kiosk pada waktu t.
+func Payload(secret []byte, office string, t time.Time) string {
+	step := StepAt(t)
+	return prefix + escapeOffice(office) + ":" + strconv.FormatInt(step, 10) + ":" + Code(secret, office, step)
+}
+
+// escapeOffice: PathEscape tidak meng-escape ":" (pemisah payload).
+func escapeOffice(office string) string {
+	return strings.ReplaceAll(url.PathEscape(office), ":", "%3A")
+}
+
+// Scan: hasil parse payload, belum diverifikasi.
+type Scan struct {
+	Office string
+	Step   int64
+	Code   string
+}
+
+func Parse(payload string) (Scan, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(payload), prefix)
+	if !ok {
+		return Scan{}, ErrMalformed
+	}
+	parts := strings.Split(rest, ":")
+	if len(parts) != 3 || len(parts[2]) != codeLen {
+		return Scan{}, ErrMalformed
+	}
+	office, err := url.PathUnescape(parts[0])
+	if err != nil || office == "" {
+		return Scan{}, ErrMalformed
+	}
+	step, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return Scan{}, ErrMalformed
+	}
+	return Scan{Office: office, Step: step, Code: strings.ToLower(parts[2])}, nil
+}
+
+// Verify: kode benar dan step = sekarang atau satu sebelumnya (toleransi
+// scan di detik-detik terakhir + latensi upload).
+func Verify(secret []byte, s Scan, now time.Time) error {
+	cur := StepAt(now)
+	if s.Step > cur || s.Step < cur-1 {
+		return ErrExpired
+	}
+	if !hmac.Equal([]byte(Code(secret, s.Office, s.Step)), []byte(s.Code)) {
+		return ErrInvalid
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package kioskqr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Unix(1754000000, 0)
+	good := Payload(secret, "Pabrik A:1", now)
+
+	tests := []struct {
+		name    string
+		payload string
+		want    Scan
+		err     error
+	}{
+		{"generated", good, Scan{Office: "Pabrik A:1", Step: StepAt(now), Code: Code(secret, "Pabrik A:1", StepAt(now))}, nil},
+		{"upper case code", "absensi-qr:1:HQ:5:ABCDEF012345", Scan{Office: "HQ", Step: 5, Code: "abcdef012345"}, nil},
+		{"wrong prefix", "absensi-qr:2:HQ:5:abcdef012345", Scan{}, ErrMalformed},
+		{"short code", "absensi-qr:1:HQ:5:abcdef", Scan{}, ErrMalformed},
+		{"extra part", "absensi-qr:1:HQ:x:5:abcdef012345", Scan{}, ErrMalformed},
+		{"empty office", "absensi-qr:1::5:abcdef012345", Scan{}, ErrMalformed},
+		{"bad step", "absensi-qr:1:HQ:five:abcdef012345", Scan{}, ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.payload)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Fatalf("Parse = %+v, %v; want %+v, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+	if strings.Contains(strings.TrimPrefix(good, prefix), " ") {
+		t.Fatalf("office not escaped: %s", good)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	shown := time.Unix(1754000010, 0) // awal satu step
+	s, err := Parse(Payload(secret, "HQ", shown))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		scan   func(Scan) Scan
+		secret []byte
+		now    time.Time
+		err    error
+	}{
+		{"same step", nil, secret, shown.Add(29 * time.Second), nil},
+		{"previous step", nil, secret, shown.Add(Step + 29*time.Second), nil},
+		{"two steps late", nil, secret, shown.Add(2 * Step), ErrExpired},
+		{"step from the future", nil, secret, shown.Add(-time.Second), ErrExpired},
+		{"wrong secret", nil, []byte("other"), shown, ErrInvalid},
+		{"other office", func(s Scan) Scan { s.Office = "Pabrik A"; return s }, secret, shown, ErrInvalid},
+		{"replayed code with new step", func(s Scan) Scan { s.Step++; return s }, secret, shown.Add(Step), ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := s
+			if tt.scan != nil {
+				sc = tt.scan(sc)
+			}
+			if err := Verify(tt.secret, sc, tt.now); !errors.Is(err, tt.err) {
+				t.Fatalf("Verify = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestStepEnd(t *testing.T) {
+	now := time.Unix(1754000010, 0)
+	end := StepEnd(StepAt(now))
+	if !end.After(now) || end.Sub(now) > Step || StepAt(end) != StepAt(now)+1 {
+		t.Fatalf("StepEnd = %v for %v", end, now)
+	}
+}
@@ -0,0 +1,78 @@
+// Package liveness: challenge-response untuk selfie absensi supaya foto
+// cetak / layar tidak bisa dipakai. Server memberi challenge sekali pakai
+// (gerakan kepala) lewat /attendance/status; klien mengirim beberapa frame
+// yang menjawab challenge itu saat check-in / check-out.
+package liveness
+
+import (
+	"context"
+	"crypto/rand"
+	"image"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Gerakan yang bisa diminta. Arah kiri/kanan dalam koordinat gambar seperti
+// yang diterima server (frame kamera depan TIDAK di-mirror oleh klien).
+const (
+	ActionTurnLeft  = "turn_left"
+	ActionTurnRight = "turn_right"
+	ActionNod       = "nod"
+)
+
+var actions = []string{ActionTurnLeft, ActionTurnRight, ActionNod}
+
+// Batas jumlah frame per jawaban.
+const (
+	MinFrames     = 3
+	MaxFrames     = 8
+	DefaultFrames = 4 // jumlah frame yang diminta di challenge
+)
+
+// Status liveness yang disimpan di baris absensi.
+const (
+	StatusPassed   = "passed"
+	StatusDisabled = "disabled" // LIVENESS_REQUIRED=false dan klien tidak kirim frame
+)
+
+// Result: hasil pemeriksaan frame. Reason diisi kalau tidak lolos.
+type Result struct {
+	Passed bool
+	Reason string  // static_frames | wrong_direction | insufficient_motion
+	Motion float64 // rata-rata beda piksel antar frame (0..1)
+	Shift  float64 // pergeseran fitur wajah searah gerakan (fraksi lebar/tinggi)
+}
+
+// Checker memeriksa apakah frames menjawab action. Bisa diganti dengan
+// model head-pose / layanan eksternal; default-nya MotionChecker.
+type Checker interface {
+	Check(ctx context.Context, action string, frames []image.Image) (Result, error)
+}
+
+// RandomAction: pilih gerakan acak untuk challenge baru.
+func RandomAction() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(actions))))
+	if err != nil {
+		return "", err
+	}
+	return actions[n.Int64()], nil
+}
+
+// Required: kalau false, check-in tanpa frame tetap diterima
+// (LIVENESS_REQUIRED, default true). Frame yang dikirim tetap diverifikasi.
+func Required() bool {
+	if b, err := strconv.ParseBool(os.Getenv("LIVENESS_REQUIRED")); err == nil {
+		return b
+	}
+	return true
+}
+
+// TTL: masa berlaku challenge (LIVENESS_TTL_SEC, default 60 detik, maks 300).
+func TTL() time.Duration {
+	if n, err := strconv.Atoi(os.Getenv("LIVENESS_TTL_SEC")); err == nil && n > 0 && n <= 300 {
+		return time.Duration(n) * time.Second
+	}
+	return 60 * time.Second
+}
@@ -0,0 +1,119 @@
+package liveness
+
+import (
+	"context"
+	"errors"
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	motionSide = 64
+	minMotion  = 0.015 // beda rata-rata antar frame; foto yang dipegang diam ≈ 0
+	minShift   = 0.04  // pergeseran pusat fitur minimal (4% sisi frame)
+)
+
+// MotionChecker: heuristik tanpa model. Tiap frame di-grayscale & dikecilkan,
+// lalu dihitung pusat massa gradien (tepi mata/hidung/mulut) di area tengah.
+// Menoleh menggeser pusat itu ke samping, mengangguk ke atas-bawah, sementara
+// latar belakang relatif diam. Cukup untuk menolak foto diam; foto yang
+// digerakkan tangan masih mungkin lolos, jadi tetap dikombinasikan dengan
+// verifikasi wajah dan review admin.
+type MotionChecker struct{}
+
+func NewMotionChecker() *MotionChecker { return &MotionChecker{} }
+
+func (MotionChecker) Check(ctx context.Context, action string, frames []image.Image) (Result, error) {
+	if len(frames) < MinFrames {
+		return Result{}, errors.New("liveness: not enough frames")
+	}
+
+	grays := make([]*image.NRGBA, len(frames))
+	for i, f := range frames {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		grays[i] = imaging.Grayscale(imaging.Fill(f, motionSide, motionSide, imaging.Center, imaging.Box))
+	}
+
+	var motion float64
+	for i := 1; i < len(grays); i++ {
+		motion += meanAbsDiff(grays[i-1], grays[i])
+	}
+	motion /= float64(len(grays) - 1)
+	if motion < minMotion {
+		return Result{Reason: "static_frames", Motion: motion}, nil
+	}
+
+	cx0, cy0 := gradientCentroid(grays[0])
+	var shift float64
+	for _, g := range grays[1:] {
+		cx, cy := gradientCentroid(g)
+		var s float64
+		switch action {
+		case ActionTurnLeft:
+			s = cx0 - cx
+		case ActionTurnRight:
+			s = cx - cx0
+		case ActionNod:
+			s = cy - cy0
+			if s < 0 {
+				s = -s
+			}
+		default:
+			return Result{}, errors.New("liveness: unknown action")
+		}
+		if s > shift {
+			shift = s
+		}
+	}
+
+	res := Result{Motion: motion, Shift: shift}
+	switch {
+	case shift >= minShift:
+		res.Passed = true
+	case shift <= -minShift:
+		res.Reason = "wrong_direction"
+	default:
+		res.Reason = "insufficient_motion"
+	}
+	return res, nil
+}
+
+func meanAbsDiff(a, b *image.NRGBA) float64 {
+	var sum int
+	for y := 0; y < motionSide; y++ {
+		for x := 0; x < motionSide; x++ {
+			d := int(a.Pix[y*a.Stride+x*4]) - int(b.Pix[y*b.Stride+x*4])
+			if d < 0 {
+				d = -d
+			}
+			sum += d
+		}
+	}
+	return float64(sum) / float64(motionSide*motionSide*255)
+}
+
+// gradientCentroid: pusat massa magnitudo gradien di 60% area tengah,
+// dinormalisasi 0..1 terhadap sisi frame.
+func gradientCentroid(g *image.NRGBA) (float64, float64) {
+	lo, hi := motionSide/5, motionSide*4/5
+	px := func(x, y int) float64 { return float64(g.Pix[y*g.Stride+x*4]) }
+
+	var sx, sy, sw float64
+	for y := lo; y < hi; y++ {
+		for x := lo; x < hi; x++ {
+			gx := px(x+1, y) - px(x-1, y)
+			gy := px(x, y+1) - px(x, y-1)
+			m := gx*gx + gy*gy
+			sx += m * float64(x)
+			sy += m * float64(y)
+			sw += m
+		}
+	}
+	if sw == 0 {
+		return 0.5, 0.5
+	}
+	return sx / sw / motionSide, sy / sw / motionSide
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Invitation: undangan pendaftaran dari admin. Profile berisi data yang
+// sudah diisi admin (jabatan, office, data HR) dan tidak bisa diubah pendaftar.
+type Invitation struct {
+	ID        string
+	Profile   User // Username opsional; Jabatan wajib
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+	UsedBy    string
+	RevokedAt sql.NullTime
+}
+
+// Status: pending | used | expired | revoked
+func (inv Invitation) Status(now time.Time) string {
+	switch {
+	case inv.UsedAt.Valid:
+		return "used"
+	case inv.RevokedAt.Valid:
+		return "revoked"
+	case now.After(inv.ExpiresAt):
+		return "expired"
+	}
+	return "pending"
+}
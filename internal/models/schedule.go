@@ -0,0 +1,70 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkSchedule: jadwal kerja (jam masuk/pulang + hari kerja).
+type WorkSchedule struct {
+	Code      string
+	Name      string
+	StartTime string // "HH:MM" waktu kantor
+	EndTime   string // "HH:MM"
+	WorkDays  string // ISO weekday dipisah koma, 1=Senin ... 7=Minggu
+
+	BreakMinMinutes int  // 0 = tanpa minimum
+	BreakMaxMinutes int  // 0 = tanpa batas
+	BreakOutside    bool // istirahat boleh di luar geofence
+
+	CreatedAt time.Time
+}
+
+func (s WorkSchedule) BreakMin() time.Duration {
+	return time.Duration(s.BreakMinMinutes) * time.Minute
+}
+
+func (s WorkSchedule) BreakMax() time.Duration {
+	return time.Duration(s.BreakMaxMinutes) * time.Minute
+}
+
+// IsWorkDay: apakah tanggal t termasuk hari kerja jadwal ini.
+func (s WorkSchedule) IsWorkDay(t time.Time) bool {
+	wd := int(t.Weekday())
+	if wd == 0 {
+		wd = 7
+	}
+	for _, p := range strings.Split(s.WorkDays, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(p)); err == nil && n == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// EndOn: jam pulang pada tanggal date (anchor 00:00 lokal kantor).
+func (s WorkSchedule) EndOn(date time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", s.EndTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, date.Location()), nil
+}
+
+// StartOn: jam masuk pada tanggal date.
+func (s WorkSchedule) StartOn(date time.Time) (time.Time, error) { return ClockOn(date, s.StartTime) }
+
+// ClockOn: jam "HH:MM" pada tanggal date (anchor 00:00 lokal kantor).
+func ClockOn(date time.Time, hhmm string) (time.Time, error) {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, date.Location()), nil
+}
+
+// SixDayWeek: jadwal 6 hari kerja seminggu (tarif lembur hari libur berbeda).
+func (s WorkSchedule) SixDayWeek() bool {
+	return len(strings.Split(s.WorkDays, ",")) >= 6
+}
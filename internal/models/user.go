@@ -1,11 +1,51 @@
 package models
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	RoleEmployee = "employee"
+	RoleAdmin    = "admin"
+)
+
+// Jenis kepegawaian
+const (
+	EmploymentTetap   = "tetap"
+	EmploymentKontrak = "kontrak"
+	EmploymentMagang  = "magang"
+)
 
 type User struct {
-	ID           string
-	Username     string
-	PasswordHash string
-	Jabatan      string
-	CreatedAt    time.Time
+	ID            string
+	Username      string
+	PasswordHash  string
+	Jabatan       string
+	Role          string
+	Office        string
+	IsActive      bool
+	DeactivatedAt sql.NullTime
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	// data master HR
+	FullName        string
+	NIP             string
+	Email           string
+	Phone           string
+	Department      string
+	HireDate        sql.NullTime
+	EmploymentType  string
+	ContractEndDate sql.NullTime
+	HasPhoto        bool // foto profil disimpan terpisah (lihat UserRepo.GetPhoto)
+
+	ManagerID    string // atasan langsung ("" = tidak ada)
+	ScheduleCode string // work_schedules.code ("" = belum diatur)
+}
+
+func (u User) IsAdmin() bool { return u.Role == RoleAdmin }
+
+func ValidEmploymentType(t string) bool {
+	return t == EmploymentTetap || t == EmploymentKontrak || t == EmploymentMagang
 }
@@ -0,0 +1,80 @@
+// Package overtime: perhitungan upah lembur sesuai Kepmenakertrans
+// No. 102/2004 dan PP 35/2021.
+//
+//   - upah sejam = 1/173 × upah sebulan
+//   - hari kerja: jam pertama 1,5×, jam berikutnya 2×; maks. 4 jam sehari
+//   - hari istirahat / libur, 5 hari kerja seminggu: 8 jam pertama 2×,
+//     jam ke-9 3×, jam ke-10 dan ke-11 4×
+//   - hari istirahat / libur, 6 hari kerja seminggu: 7 jam pertama 2×,
+//     jam ke-8 3×, jam ke-9 dan ke-10 4×
+//
+// Perhitungan per menit; pembulatan (kalau ada) urusan payroll.
+package overtime
+
+import "math"
+
+// HourlyDivisor: upah sejam = upah sebulan / HourlyDivisor.
+const HourlyDivisor = 173
+
+// Jenis hari untuk tarif lembur.
+const (
+	Workday = "workday"
+	RestDay = "rest_day"
+)
+
+// MaxWorkdayMinutes: batas lembur di hari kerja (PP 35/2021 pasal 26).
+const MaxWorkdayMinutes = 4 * 60
+
+// Tier: sebagian menit lembur dengan pengali yang sama.
+type Tier struct {
+	Minutes    int
+	Multiplier float64
+}
+
+type band struct {
+	upTo       int // menit kumulatif; 0 = sisa
+	multiplier float64
+}
+
+func bands(dayType string, sixDayWeek bool) []band {
+	switch {
+	case dayType != RestDay:
+		return []band{{60, 1.5}, {MaxWorkdayMinutes, 2}}
+	case sixDayWeek:
+		return []band{{7 * 60, 2}, {8 * 60, 3}, {10 * 60, 4}}
+	default:
+		return []band{{8 * 60, 2}, {9 * 60, 3}, {11 * 60, 4}}
+	}
+}
+
+// MaxMinutes: lembur terlama yang punya tarif untuk jenis hari tsb.
+func MaxMinutes(dayType string, sixDayWeek bool) int {
+	b := bands(dayType, sixDayWeek)
+	return b[len(b)-1].upTo
+}
+
+// Tiers: pecah menit lembur ke tier tarif. Kelebihan di atas MaxMinutes
+// tidak dihitung.
+func Tiers(minutes int, dayType string, sixDayWeek bool) []Tier {
+	var out []Tier
+	prev := 0
+	for _, b := range bands(dayType, sixDayWeek) {
+		if minutes <= prev {
+			break
+		}
+		n := min(minutes, b.upTo) - prev
+		out = append(out, Tier{Minutes: n, Multiplier: b.multiplier})
+		prev = b.upTo
+	}
+	return out
+}
+
+// WeightedHours: jam lembur setelah dikali pengali ("jam lembur terhitung");
+// upah lembur = WeightedHours × upah sebulan / HourlyDivisor.
+func WeightedHours(tiers []Tier) float64 {
+	var h float64
+	for _, t := range tiers {
+		h += float64(t.Minutes) / 60 * t.Multiplier
+	}
+	return math.Round(h*100) / 100
+}
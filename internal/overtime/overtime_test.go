@@ -0,0 +1,61 @@
+package overtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTiers(t *testing.T) {
+	tests := []struct {
+		name     string
+		minutes  int
+		dayType  string
+		sixDay   bool
+		want     []Tier
+		weighted float64
+	}{
+		{"none", 0, Workday, false, nil, 0},
+		{"workday 30m", 30, Workday, false, []Tier{{30, 1.5}}, 0.75},
+		{"workday 1h", 60, Workday, false, []Tier{{60, 1.5}}, 1.5},
+		{"workday 3h", 180, Workday, false, []Tier{{60, 1.5}, {120, 2}}, 5.5},
+		{"workday capped at 4h", 300, Workday, false, []Tier{{60, 1.5}, {180, 2}}, 7.5},
+		{"workday ignores six-day flag", 90, Workday, true, []Tier{{60, 1.5}, {30, 2}}, 2.5},
+		{"rest day 5d 8h", 480, RestDay, false, []Tier{{480, 2}}, 16},
+		{"rest day 5d 9h", 540, RestDay, false, []Tier{{480, 2}, {60, 3}}, 19},
+		{"rest day 5d 11h", 660, RestDay, false, []Tier{{480, 2}, {60, 3}, {120, 4}}, 27},
+		{"rest day 5d capped", 720, RestDay, false, []Tier{{480, 2}, {60, 3}, {120, 4}}, 27},
+		{"rest day 6d 7h", 420, RestDay, true, []Tier{{420, 2}}, 14},
+		{"rest day 6d 8h30", 510, RestDay, true, []Tier{{420, 2}, {60, 3}, {30, 4}}, 19},
+		{"rest day 6d capped", 700, RestDay, true, []Tier{{420, 2}, {60, 3}, {120, 4}}, 25},
+		{"rounded to 2 decimals", 7, Workday, false, []Tier{{7, 1.5}}, 0.18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Tiers(tt.minutes, tt.dayType, tt.sixDay)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Tiers = %v, want %v", got, tt.want)
+			}
+			if w := WeightedHours(got); w != tt.weighted {
+				t.Fatalf("WeightedHours = %v, want %v", w, tt.weighted)
+			}
+		})
+	}
+}
+
+func TestMaxMinutes(t *testing.T) {
+	tests := []struct {
+		dayType string
+		sixDay  bool
+		want    int
+	}{
+		{Workday, false, MaxWorkdayMinutes},
+		{Workday, true, MaxWorkdayMinutes},
+		{RestDay, false, 11 * 60},
+		{RestDay, true, 10 * 60},
+	}
+	for _, tt := range tests {
+		if got := MaxMinutes(tt.dayType, tt.sixDay); got != tt.want {
+			t.Errorf("MaxMinutes(%s, %v) = %d, want %d", tt.dayType, tt.sixDay, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Jenis kejadian di attendance_events.
+const (
+	EventCheckIn    = "check_in"
+	EventCheckOut   = "check_out"
+	EventVisit      = "visit" // kunjungan klien (sales / lapangan)
+	EventBreakStart = "break_start"
+	EventBreakEnd   = "break_end"
+)
+
+var (
+	ErrNotCheckedIn      = errors.New("not checked in")
+	ErrAlreadyCheckedOut = errors.New("already checked out")
+	ErrBreakOpen         = errors.New("break already started")
+	ErrNoOpenBreak       = errors.New("no break in progress")
+	ErrBreakTooShort     = errors.New("break shorter than schedule minimum")
+)
+
+// AttendanceEvent: satu kejadian di timeline harian.
+type AttendanceEvent struct {
+	ID        string
+	Type      string
+	At        time.Time
+	Lat       sql.NullFloat64
+	Lng       sql.NullFloat64
+	DistanceM sql.NullFloat64
+	PhotoKey  sql.NullString
+	Note      sql.NullString
+	Presence  sql.NullString
+	Details   map[string]any
+	CreatedAt time.Time
+}
+
+// event: baris attendance_events untuk check-in / check-out.
+func (p Punch) event(typ string, at time.Time) AttendanceEvent {
+	ev := AttendanceEvent{
+		Type:     typ,
+		At:       at,
+		PhotoKey: sql.NullString{String: p.PhotoKey, Valid: p.PhotoKey != ""},
+		Presence: sql.NullString{String: p.Presence, Valid: p.Presence != ""},
+	}
+	if !p.NoLocation {
+		ev.Lat = sql.NullFloat64{Float64: p.Lat, Valid: true}
+		ev.Lng = sql.NullFloat64{Float64: p.Lng, Valid: true}
+		ev.DistanceM = sql.NullFloat64{Float64: p.DistanceM, Valid: true}
+	}
+	return ev
+}
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type rowsQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func insertEvent(ctx context.Context, db rowQuerier, userID string, date time.Time, ev AttendanceEvent) error {
+	_, err := insertEventReturning(ctx, db, userID, date, ev)
+	return err
+}
+
+func insertEventReturning(ctx context.Context, db rowQuerier, userID string, date time.Time, ev AttendanceEvent) (AttendanceEvent, error) {
+	details, err := jsonArg(ev.Details)
+	if err != nil {
+		return ev, err
+	}
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO attendance_events (user_id, date, type, at, lat, lng, distance_m, photo_key, note, presence, details)
+		VALUES ($1, $2::date, $3, $4, $5, $6, $7, $8, $9, $10, $11::jsonb)
+		RETURNING id::text, created_at`,
+		userID, date.Format("2006-01-02"), ev.Type, ev.At, ev.Lat, ev.Lng, ev.DistanceM,
+		ev.PhotoKey, ev.Note, ev.Presence, details).Scan(&ev.ID, &ev.CreatedAt)
+	return ev, err
+}
+
+// AddEvent: catat kunjungan / istirahat. Harus sudah check-in dan belum
+// check-out; break_start/break_end harus berselang-seling. Untuk break_end
+// dikembalikan lama istirahatnya; lebih pendek dari minBreak → ErrBreakTooShort.
+func (r *AttendanceRepo) AddEvent(ctx context.Context, userID string, date time.Time, ev AttendanceEvent, minBreak time.Duration) (AttendanceEvent, time.Duration, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return ev, 0, err
+	}
+	defer tx.Rollback()
+
+	// kunci baris cache harian supaya event paralel tidak balapan; status
+	// hari itu sendiri dibaca dari timeline
+	var id string
+	err = tx.QueryRowContext(ctx, `
+		SELECT id::text FROM attendance_days
+		WHERE user_id = $1 AND date = $2::date
+		FOR UPDATE`, userID, date.Format("2006-01-02")).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ev, 0, ErrNotCheckedIn
+	}
+	if err != nil {
+		return ev, 0, err
+	}
+	events, err := listEvents(ctx, tx, userID, date)
+	if err != nil {
+		return ev, 0, err
+	}
+	day := Summarize(events, ev.At)
+	if !day.CheckInAt.Valid {
+		return ev, 0, ErrNotCheckedIn
+	}
+	if day.CheckOutAt.Valid {
+		return ev, 0, ErrAlreadyCheckedOut
+	}
+
+	var dur time.Duration
+	switch {
+	case ev.Type == EventBreakStart && day.OpenBreak.Valid:
+		return ev, 0, ErrBreakOpen
+	case ev.Type == EventBreakEnd && !day.OpenBreak.Valid:
+		return ev, 0, ErrNoOpenBreak
+	case ev.Type == EventBreakEnd:
+		dur = ev.At.Sub(day.OpenBreak.Time)
+		if dur < minBreak {
+			return ev, dur, ErrBreakTooShort
+		}
+	}
+
+	ev, err = insertEventReturning(ctx, tx, userID, date, ev)
+	if err != nil {
+		return ev, 0, err
+	}
+	return ev, dur, tx.Commit()
+}
+
+// ListEvents: timeline satu hari, urut waktu.
+func (r *AttendanceRepo) ListEvents(ctx context.Context, userID string, date time.Time) ([]AttendanceEvent, error) {
+	return listEvents(ctx, r.DB, userID, date)
+}
+
+func listEvents(ctx context.Context, db rowsQuerier, userID string, date time.Time) ([]AttendanceEvent, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id::text, type, at, lat, lng, distance_m, photo_key, note, presence, details::text, created_at
+		FROM attendance_events
+		WHERE user_id = $1 AND date = $2::date
+		ORDER BY at, created_at`, userID, date.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AttendanceEvent
+	for rows.Next() {
+		ev, _, err := scanEvent(rows, false)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ev)
+	}
+	return out, rows.Err()
+}
+
+// ListEventsRange: timeline per tanggal (key yyyy-mm-dd) untuk [from, to).
+func (r *AttendanceRepo) ListEventsRange(ctx context.Context, userID string, from, to time.Time) (map[string][]AttendanceEvent, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id::text, type, at, lat, lng, distance_m, photo_key, note, presence, details::text, created_at, date
+		FROM attendance_events
+		WHERE user_id = $1 AND date >= $2::date AND date < $3::date
+		ORDER BY date, at, created_at`, userID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string][]AttendanceEvent{}
+	for rows.Next() {
+		ev, date, err := scanEvent(rows, true)
+		if err != nil {
+			return nil, err
+		}
+		k := date.Format("2006-01-02")
+		out[k] = append(out[k], ev)
+	}
+	return out, rows.Err()
+}
+
+// scanEvent: satu baris attendance_events; withDate = kolom date ikut di akhir.
+func scanEvent(rows *sql.Rows, withDate bool) (AttendanceEvent, time.Time, error) {
+	var ev AttendanceEvent
+	var details sql.NullString
+	var date time.Time
+	dest := []any{&ev.ID, &ev.Type, &ev.At, &ev.Lat, &ev.Lng, &ev.DistanceM,
+		&ev.PhotoKey, &ev.Note, &ev.Presence, &details, &ev.CreatedAt}
+	if withDate {
+		dest = append(dest, &date)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return AttendanceEvent{}, time.Time{}, err
+	}
+	if details.Valid {
+		_ = json.Unmarshal([]byte(details.String), &ev.Details)
+	}
+	return ev, date, nil
+}
+
+// DaySummary: ringkasan satu hari yang diturunkan dari attendance_events
+// (sumber kebenaran; kolom jam di attendance_days hanya cache).
+type DaySummary struct {
+	CheckInAt  sql.NullTime // check_in pertama
+	CheckOutAt sql.NullTime // check_out terakhir
+	OpenBreak  sql.NullTime // break_start yang belum ditutup
+	Visits     int
+	Breaks     int
+
+	BreakSeconds     int64 // istirahat terbuka dihitung sampai check-out / until
+	WorkedSeconds    int64 // bruto; 0 sebelum check-out
+	NetWorkedSeconds int64
+}
+
+// Summarize: ringkas timeline (urut waktu). until = batas istirahat yang
+// masih terbuka kalau belum check-out (biasanya now).
+func Summarize(events []AttendanceEvent, until time.Time) DaySummary {
+	var s DaySummary
+	var breakTotal time.Duration
+	for _, ev := range events {
+		switch ev.Type {
+		case EventCheckIn:
+			if !s.CheckInAt.Valid {
+				s.CheckInAt = sql.NullTime{Time: ev.At, Valid: true}
+			}
+		case EventCheckOut:
+			s.CheckOutAt = sql.NullTime{Time: ev.At, Valid: true}
+		case EventVisit:
+			s.Visits++
+		case EventBreakStart:
+			if !s.OpenBreak.Valid {
+				s.OpenBreak = sql.NullTime{Time: ev.At, Valid: true}
+				s.Breaks++
+			}
+		case EventBreakEnd:
+			if s.OpenBreak.Valid {
+				breakTotal += ev.At.Sub(s.OpenBreak.Time)
+				s.OpenBreak = sql.NullTime{}
+			}
+		}
+	}
+	if s.CheckOutAt.Valid {
+		until = s.CheckOutAt.Time
+	}
+	if s.OpenBreak.Valid && until.After(s.OpenBreak.Time) {
+		breakTotal += until.Sub(s.OpenBreak.Time)
+	}
+	s.BreakSeconds = int64(breakTotal.Seconds())
+	if s.CheckInAt.Valid && s.CheckOutAt.Valid {
+		s.WorkedSeconds = int64(s.CheckOutAt.Time.Sub(s.CheckInAt.Time).Seconds())
+		s.NetWorkedSeconds = max(s.WorkedSeconds-s.BreakSeconds, 0)
+	}
+	return s
+}
+
+type txQuerier interface {
+	rowQuerier
+	rowsQuerier
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// rebuildDay: ringkas ulang timeline hari itu dan tulis jam masuk/pulang ke
+// cache attendance_days (dalam transaksi yang sama dengan event-nya).
+func rebuildDay(ctx context.Context, db txQuerier, userID string, date, now time.Time) ([]AttendanceEvent, error) {
+	events, err := listEvents(ctx, db, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	s := Summarize(events, now)
+	_, err = db.ExecContext(ctx, `
+		UPDATE attendance_days SET check_in_at = $3, check_out_at = $4, updated_at = NOW()
+		WHERE user_id = $1 AND date = $2::date
+		  AND (check_in_at IS DISTINCT FROM $3 OR check_out_at IS DISTINCT FROM $4)`,
+		userID, date.Format("2006-01-02"), s.CheckInAt, s.CheckOutAt)
+	return events, err
+}
+
+// SetWorked: isi jam masuk/pulang, bruto, istirahat, dan neto dari timeline
+// hari itu. Hari tanpa event check-in (data lama) memakai jam dari cache.
+func (ad *AttendanceDay) SetWorked(events []AttendanceEvent, now time.Time) {
+	s := Summarize(events, now)
+	if !s.CheckInAt.Valid && ad.CheckInAt.Valid {
+		legacy := []AttendanceEvent{{Type: EventCheckIn, At: ad.CheckInAt.Time}}
+		if ad.CheckOutAt.Valid {
+			legacy = append(legacy, AttendanceEvent{Type: EventCheckOut, At: ad.CheckOutAt.Time})
+		}
+		s = Summarize(append(legacy, events...), now)
+	}
+	ad.CheckInAt, ad.CheckOutAt = s.CheckInAt, s.CheckOutAt
+	ad.OpenBreakAt = s.OpenBreak
+	ad.BreakSeconds = s.BreakSeconds
+	ad.WorkedSeconds = s.WorkedSeconds
+	ad.NetWorkedSeconds = s.NetWorkedSeconds
+}
@@ -0,0 +1,161 @@
+package repo
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time { return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+	ev := func(typ string, h, m int) AttendanceEvent { return AttendanceEvent{Type: typ, At: at(h, m)} }
+
+	tests := []struct {
+		name   string
+		events []AttendanceEvent
+		until  time.Time
+		want   DaySummary
+	}{
+		{
+			name:   "empty",
+			events: nil,
+			until:  at(12, 0),
+			want:   DaySummary{},
+		},
+		{
+			name:   "checked in, no check-out yet",
+			events: []AttendanceEvent{ev(EventCheckIn, 8, 0), ev(EventVisit, 10, 0)},
+			until:  at(12, 0),
+			want: DaySummary{
+				CheckInAt: sql.NullTime{Time: at(8, 0), Valid: true},
+				Visits:    1,
+			},
+		},
+		{
+			name: "closed breaks",
+			events: []AttendanceEvent{
+				ev(EventCheckIn, 8, 0),
+				ev(EventBreakStart, 12, 0), ev(EventBreakEnd, 13, 0),
+				ev(EventBreakStart, 15, 0), ev(EventBreakEnd, 15, 30),
+				ev(EventCheckOut, 17, 0),
+			},
+			until: at(20, 0),
+			want: DaySummary{
+				CheckInAt:        sql.NullTime{Time: at(8, 0), Valid: true},
+				CheckOutAt:       sql.NullTime{Time: at(17, 0), Valid: true},
+				Breaks:           2,
+				BreakSeconds:     90 * 60,
+				WorkedSeconds:    9 * 3600,
+				NetWorkedSeconds: 9*3600 - 90*60,
+			},
+		},
+		{
+			name: "open break counted until check-out",
+			events: []AttendanceEvent{
+				ev(EventCheckIn, 8, 0), ev(EventBreakStart, 16, 0), ev(EventCheckOut, 17, 0),
+			},
+			until: at(20, 0),
+			want: DaySummary{
+				CheckInAt:        sql.NullTime{Time: at(8, 0), Valid: true},
+				CheckOutAt:       sql.NullTime{Time: at(17, 0), Valid: true},
+				OpenBreak:        sql.NullTime{Time: at(16, 0), Valid: true},
+				Breaks:           1,
+				BreakSeconds:     3600,
+				WorkedSeconds:    9 * 3600,
+				NetWorkedSeconds: 8 * 3600,
+			},
+		},
+		{
+			name:   "open break before check-out counted until now",
+			events: []AttendanceEvent{ev(EventCheckIn, 8, 0), ev(EventBreakStart, 12, 0)},
+			until:  at(12, 20),
+			want: DaySummary{
+				CheckInAt:    sql.NullTime{Time: at(8, 0), Valid: true},
+				OpenBreak:    sql.NullTime{Time: at(12, 0), Valid: true},
+				Breaks:       1,
+				BreakSeconds: 20 * 60,
+			},
+		},
+		{
+			name:   "break longer than day clamps net to zero",
+			events: []AttendanceEvent{ev(EventBreakStart, 7, 0), ev(EventCheckIn, 8, 0), ev(EventCheckOut, 9, 0)},
+			until:  at(9, 0),
+			want: DaySummary{
+				CheckInAt:     sql.NullTime{Time: at(8, 0), Valid: true},
+				CheckOutAt:    sql.NullTime{Time: at(9, 0), Valid: true},
+				OpenBreak:     sql.NullTime{Time: at(7, 0), Valid: true},
+				Breaks:        1,
+				BreakSeconds:  2 * 3600,
+				WorkedSeconds: 3600,
+			},
+		},
+		{
+			name:   "first check-in and last check-out win",
+			events: []AttendanceEvent{ev(EventCheckIn, 8, 0), ev(EventCheckIn, 9, 0), ev(EventCheckOut, 16, 0), ev(EventCheckOut, 17, 0)},
+			until:  at(18, 0),
+			want: DaySummary{
+				CheckInAt:        sql.NullTime{Time: at(8, 0), Valid: true},
+				CheckOutAt:       sql.NullTime{Time: at(17, 0), Valid: true},
+				WorkedSeconds:    9 * 3600,
+				NetWorkedSeconds: 9 * 3600,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summarize(tt.events, tt.until); got != tt.want {
+				t.Fatalf("Summarize() =\n %+v\nwant\n %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetWorked(t *testing.T) {
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+	valid := func(h int) sql.NullTime { return sql.NullTime{Time: at(h), Valid: true} }
+
+	tests := []struct {
+		name              string
+		cache             AttendanceDay
+		events            []AttendanceEvent
+		wantIn, wantOut   sql.NullTime
+		gross, brk, netto int64
+	}{
+		{
+			name:  "events override cache",
+			cache: AttendanceDay{CheckInAt: valid(7), CheckOutAt: valid(18)},
+			events: []AttendanceEvent{
+				{Type: EventCheckIn, At: at(8)},
+				{Type: EventBreakStart, At: at(12)}, {Type: EventBreakEnd, At: at(13)},
+				{Type: EventCheckOut, At: at(17)},
+			},
+			wantIn: valid(8), wantOut: valid(17),
+			gross: 9 * 3600, brk: 3600, netto: 8 * 3600,
+		},
+		{
+			name:   "legacy day without events uses cache",
+			cache:  AttendanceDay{CheckInAt: valid(8), CheckOutAt: valid(16)},
+			wantIn: valid(8), wantOut: valid(16),
+			gross: 8 * 3600, netto: 8 * 3600,
+		},
+		{
+			name:  "nothing recorded",
+			cache: AttendanceDay{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ad := tt.cache
+			ad.SetWorked(tt.events, at(20))
+			if ad.CheckInAt != tt.wantIn || ad.CheckOutAt != tt.wantOut {
+				t.Fatalf("in/out = %v/%v, want %v/%v", ad.CheckInAt, ad.CheckOutAt, tt.wantIn, tt.wantOut)
+			}
+			if ad.WorkedSeconds != tt.gross || ad.BreakSeconds != tt.brk || ad.NetWorkedSeconds != tt.netto {
+				t.Fatalf("gross/break/net = %d/%d/%d, want %d/%d/%d",
+					ad.WorkedSeconds, ad.BreakSeconds, ad.NetWorkedSeconds, tt.gross, tt.brk, tt.netto)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -11,73 +12,194 @@ type AttendanceRepo struct{ DB *sql.DB }
 
 func NewAttendanceRepo(db *sql.DB) *AttendanceRepo { return &AttendanceRepo{DB: db} }
 
+// Mode kerja harian (attendance_days.mode).
+const (
+	ModeOffice = "office"
+	ModeWFH    = "wfh"
+	ModeDinas  = "dinas" // perjalanan dinas
+)
+
+// AttendanceDay: ringkasan satu hari. CheckInAt/CheckOutAt & jam kerja
+// diturunkan dari attendance_events (lihat SetWorked); kolomnya di
+// attendance_days hanya cache.
 type AttendanceDay struct {
 	ID            string
 	UserID        string
 	Date          time.Time // anchor 00:00 lokal, tapi simpan sebagai DATE di DB
 	CheckInAt     sql.NullTime
 	CheckOutAt    sql.NullTime
-	WorkedSeconds int64
+	Mode          string // ModeOffice / ModeWFH / ModeDinas
+	WorkedSeconds int64  // bruto: check-out − check-in
+
+	BreakSeconds     int64 // total istirahat
+	NetWorkedSeconds int64 // bruto − istirahat
+
+	// OpenBreakAt: break_start tanpa break_end; setelah check-out berarti
+	// istirahat itu ditutup oleh check-out.
+	OpenBreakAt sql.NullTime
 }
 
 func (r *AttendanceRepo) GetByUserAndDate(ctx context.Context, userID string, date time.Time) (AttendanceDay, error) {
 	q := `
 	SELECT id::text, user_id, date,
-	       check_in_at, check_out_at
+	       check_in_at, check_out_at, mode
 	FROM attendance_days
 	WHERE user_id=$1 AND date=$2::date
 	`
 	var ad AttendanceDay
 	err := r.DB.QueryRowContext(ctx, q, userID, date.Format("2006-01-02")).
-		Scan(&ad.ID, &ad.UserID, &ad.Date, &ad.CheckInAt, &ad.CheckOutAt)
+		Scan(&ad.ID, &ad.UserID, &ad.Date, &ad.CheckInAt, &ad.CheckOutAt, &ad.Mode)
 	if err == sql.ErrNoRows {
 		return AttendanceDay{}, nil
 	}
 	if err != nil {
 		return AttendanceDay{}, err
 	}
-	// hitung worked_seconds kalau ada check-out
-	if ad.CheckInAt.Valid && ad.CheckOutAt.Valid {
-		ad.WorkedSeconds = int64(ad.CheckOutAt.Time.Sub(ad.CheckInAt.Time).Seconds())
+	// hitung worked_seconds (bruto/neto) kalau ada check-out
+	events, err := r.ListEvents(ctx, userID, date)
+	if err != nil {
+		return AttendanceDay{}, err
 	}
+	ad.SetWorked(events, time.Now())
 	return ad, nil
 }
 
+// Punch: data satu kejadian check-in / check-out.
+type Punch struct {
+	Lat, Lng, DistanceM float64
+	NoLocation          bool   // tanpa GPS (QR kiosk / Wi-Fi): lat, lng, jarak & skor risiko NULL
+	PhotoKey            string // object key di blob store ("" = tanpa foto, mis. kiosk kartu)
+
+	FaceScore  sql.NullFloat64 // kosong kalau user belum enroll wajah
+	FaceStatus string          // face.StatusMatch / StatusMismatch / StatusNotEnrolled
+	Liveness   string          // liveness.StatusPassed / StatusDisabled
+	Exif       map[string]any  // metadata EXIF selfie; nil = NULL
+
+	RiskScore int            // skor risiko spoofing GPS 0..100
+	Geo       map[string]any // sinyal lokasi perangkat + alasan risiko; nil = NULL
+
+	Presence    string // gps | wifi | ble
+	PresenceRef string // BSSID / beacon yang cocok (kosong untuk gps)
+
+	// event offline: waktu diterima server & perangkat penanda tangan
+	// (zero / "" = online, diterima saat kejadian)
+	ReceivedAt time.Time
+	DeviceID   string
+
+	Mode string // ModeOffice / ModeWFH / ModeDinas ("" = office); hanya check-in
+}
+
+func (p Punch) mode() string {
+	if p.Mode == "" {
+		return ModeOffice
+	}
+	return p.Mode
+}
+
+func (p Punch) received() any {
+	if p.ReceivedAt.IsZero() {
+		return nil
+	}
+	return p.ReceivedAt
+}
+
+// loc: argumen lat, lng, jarak, skor risiko (NULL kalau NoLocation).
+func (p Punch) loc() (lat, lng, dist, risk any) {
+	if p.NoLocation {
+		return nil, nil, nil, nil
+	}
+	return p.Lat, p.Lng, p.DistanceM, p.RiskScore
+}
+
+// jsonArg: map → argumen ::jsonb (nil = NULL).
+func jsonArg(m map[string]any) (any, error) {
+	if m == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
 // Insert check-in jika belum ada; kalau baris sudah ada dan check_in_at NULL → isi sekarang.
 func (r *AttendanceRepo) DoCheckIn(
 	ctx context.Context,
 	userID string, date time.Time, now time.Time,
-	lat, lng, dist float64,
-	photoB64 string,
+	p Punch,
 ) (AttendanceDay, error) {
 	q := `
 	INSERT INTO attendance_days (
-		user_id, date, check_in_at, check_in_lat, check_in_lng, check_in_distance_m, check_in_photo_b64
-	) VALUES ($1, $2::date, $3, $4, $5, $6, $7)
+		user_id, date, check_in_at, check_in_lat, check_in_lng, check_in_distance_m, check_in_photo_key,
+		check_in_face_score, check_in_face_status, check_in_liveness, check_in_exif,
+		check_in_risk_score, check_in_geo, check_in_presence, check_in_presence_ref,
+		check_in_received_at, check_in_device_id, mode
+	) VALUES ($1, $2::date, $3, $4, $5, $6, NULLIF($7,''), $8, NULLIF($9,''), NULLIF($10,''), $11::jsonb, $12, $13::jsonb,
+		NULLIF($14,''), NULLIF($15,''), $16, NULLIF($17,'')::uuid, $18)
 	ON CONFLICT (user_id, date)
 	DO UPDATE SET
 		check_in_at = COALESCE(attendance_days.check_in_at, EXCLUDED.check_in_at),
 		check_in_lat = COALESCE(attendance_days.check_in_lat, EXCLUDED.check_in_lat),
 		check_in_lng = COALESCE(attendance_days.check_in_lng, EXCLUDED.check_in_lng),
 		check_in_distance_m = COALESCE(attendance_days.check_in_distance_m, EXCLUDED.check_in_distance_m),
-		check_in_photo_b64 = COALESCE(attendance_days.check_in_photo_b64, EXCLUDED.check_in_photo_b64),
+		check_in_photo_key = COALESCE(attendance_days.check_in_photo_key, EXCLUDED.check_in_photo_key),
+		check_in_face_score = COALESCE(attendance_days.check_in_face_score, EXCLUDED.check_in_face_score),
+		check_in_face_status = COALESCE(attendance_days.check_in_face_status, EXCLUDED.check_in_face_status),
+		check_in_liveness = COALESCE(attendance_days.check_in_liveness, EXCLUDED.check_in_liveness),
+		check_in_exif = COALESCE(attendance_days.check_in_exif, EXCLUDED.check_in_exif),
+		check_in_risk_score = COALESCE(attendance_days.check_in_risk_score, EXCLUDED.check_in_risk_score),
+		check_in_geo = COALESCE(attendance_days.check_in_geo, EXCLUDED.check_in_geo),
+		check_in_presence = COALESCE(attendance_days.check_in_presence, EXCLUDED.check_in_presence),
+		check_in_presence_ref = COALESCE(attendance_days.check_in_presence_ref, EXCLUDED.check_in_presence_ref),
+		check_in_received_at = COALESCE(attendance_days.check_in_received_at, EXCLUDED.check_in_received_at),
+		check_in_device_id = COALESCE(attendance_days.check_in_device_id, EXCLUDED.check_in_device_id),
+		mode = EXCLUDED.mode,
 		updated_at = NOW()
 	WHERE attendance_days.check_in_at IS NULL
-	RETURNING id::text, user_id, date, check_in_at, check_out_at
+	RETURNING id::text, user_id, date, check_in_at, check_out_at, mode
 	`
+	exif, err := jsonArg(p.Exif)
+	if err != nil {
+		return AttendanceDay{}, err
+	}
+	geo, err := jsonArg(p.Geo)
+	if err != nil {
+		return AttendanceDay{}, err
+	}
+	lat, lng, dist, risk := p.loc()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return AttendanceDay{}, err
+	}
+	defer tx.Rollback()
+
 	var ad AttendanceDay
-	err := r.DB.QueryRowContext(ctx, q,
-		userID, date.Format("2006-01-02"), now, lat, lng, dist, photoB64,
-	).Scan(&ad.ID, &ad.UserID, &ad.Date, &ad.CheckInAt, &ad.CheckOutAt)
-	return ad, err
+	err = tx.QueryRowContext(ctx, q,
+		userID, date.Format("2006-01-02"), now, lat, lng, dist, p.PhotoKey,
+		p.FaceScore, p.FaceStatus, p.Liveness, exif, risk, geo,
+		p.Presence, p.PresenceRef, p.received(), p.DeviceID, p.mode(),
+	).Scan(&ad.ID, &ad.UserID, &ad.Date, &ad.CheckInAt, &ad.CheckOutAt, &ad.Mode)
+	if err != nil {
+		return AttendanceDay{}, err
+	}
+	if err := insertEvent(ctx, tx, userID, date, p.event(EventCheckIn, now)); err != nil {
+		return AttendanceDay{}, err
+	}
+	events, err := rebuildDay(ctx, tx, userID, date, now)
+	if err != nil {
+		return AttendanceDay{}, err
+	}
+	ad.SetWorked(events, now)
+	return ad, tx.Commit()
 }
 
 // Update check-out jika sudah check-in dan check-out masih NULL
 func (r *AttendanceRepo) DoCheckOut(
 	ctx context.Context,
 	userID string, date time.Time, now time.Time,
-	lat, lng, dist float64,
-	photoB64 string,
+	p Punch,
 ) (AttendanceDay, error) {
 	q := `
 	UPDATE attendance_days
@@ -86,19 +208,65 @@ func (r *AttendanceRepo) DoCheckOut(
 		check_out_lat=$4,
 		check_out_lng=$5,
 		check_out_distance_m=$6,
-		check_out_photo_b64=$7,
+		check_out_photo_key=NULLIF($7,''),
+		check_out_face_score=$8,
+		check_out_face_status=NULLIF($9,''),
+		check_out_liveness=NULLIF($10,''),
+		check_out_exif=$11::jsonb,
+		check_out_risk_score=$12,
+		check_out_geo=$13::jsonb,
+		check_out_presence=NULLIF($14,''),
+		check_out_presence_ref=NULLIF($15,''),
+		check_out_received_at=$16,
+		check_out_device_id=NULLIF($17,'')::uuid,
 		updated_at=NOW()
 	WHERE user_id=$1 AND date=$2::date AND check_in_at IS NOT NULL AND check_out_at IS NULL
-	RETURNING id::text, user_id, date, check_in_at, check_out_at
+	  AND check_in_at <= $3
+	RETURNING id::text, user_id, date, check_in_at, check_out_at, mode
 	`
+	exif, err := jsonArg(p.Exif)
+	if err != nil {
+		return AttendanceDay{}, err
+	}
+	geo, err := jsonArg(p.Geo)
+	if err != nil {
+		return AttendanceDay{}, err
+	}
+	lat, lng, dist, risk := p.loc()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return AttendanceDay{}, err
+	}
+	defer tx.Rollback()
+
 	var ad AttendanceDay
-	err := r.DB.QueryRowContext(ctx, q,
-		userID, date.Format("2006-01-02"), now, lat, lng, dist, photoB64,
-	).Scan(&ad.ID, &ad.UserID, &ad.Date, &ad.CheckInAt, &ad.CheckOutAt)
-	return ad, err
+	err = tx.QueryRowContext(ctx, q,
+		userID, date.Format("2006-01-02"), now, lat, lng, dist, p.PhotoKey,
+		p.FaceScore, p.FaceStatus, p.Liveness, exif, risk, geo,
+		p.Presence, p.PresenceRef, p.received(), p.DeviceID,
+	).Scan(&ad.ID, &ad.UserID, &ad.Date, &ad.CheckInAt, &ad.CheckOutAt, &ad.Mode)
+	if err != nil {
+		return AttendanceDay{}, err
+	}
+	if err := insertEvent(ctx, tx, userID, date, p.event(EventCheckOut, now)); err != nil {
+		return AttendanceDay{}, err
+	}
+	events, err := rebuildDay(ctx, tx, userID, date, now)
+	if err != nil {
+		return AttendanceDay{}, err
+	}
+	ad.SetWorked(events, now)
+	return ad, tx.Commit()
 }
 
 func (r *AttendanceRepo) ResetToday(ctx context.Context, userID, yyyymmdd string) (int64, error) {
+	if _, err := r.DB.ExecContext(ctx, `
+		DELETE FROM attendance_events
+		WHERE user_id = $1 AND date = $2::date
+	`, userID, yyyymmdd); err != nil {
+		return 0, fmt.Errorf("delete attendance_events: %w", err)
+	}
 	res, err := r.DB.ExecContext(ctx, `
 		DELETE FROM attendance_days
 		WHERE user_id = $1 AND date = $2::date
@@ -109,9 +277,22 @@ func (r *AttendanceRepo) ResetToday(ctx context.Context, userID, yyyymmdd string
 	return res.RowsAffected()
 }
 
-func (r *AttendanceRepo) ListMarkedDays(ctx context.Context, userID string, from, to time.Time) ([]time.Time, error) {
+// MarkedDay: tanggal yang ada absensinya + mode kerja & jam in/out.
+type MarkedDay struct {
+	Date       time.Time
+	Mode       string
+	CheckInAt  sql.NullTime
+	CheckOutAt sql.NullTime
+
+	// dari attendance_events (lihat SetWorked); 0 sebelum check-out
+	WorkedSeconds    int64
+	BreakSeconds     int64
+	NetWorkedSeconds int64
+}
+
+func (r *AttendanceRepo) ListMarkedDays(ctx context.Context, userID string, from, to time.Time) ([]MarkedDay, error) {
 	const q = `
-		SELECT date
+		SELECT date, mode, check_in_at, check_out_at
 		FROM attendance_days
 		WHERE user_id = $1
 		  AND date >= $2::date
@@ -129,15 +310,32 @@ func (r *AttendanceRepo) ListMarkedDays(ctx context.Context, userID string, from
 	}
 	defer rows.Close()
 
-	var out []time.Time
+	var out []MarkedDay
 	for rows.Next() {
-		var d time.Time
-		if err := rows.Scan(&d); err != nil {
+		var d MarkedDay
+		if err := rows.Scan(&d.Date, &d.Mode, &d.CheckInAt, &d.CheckOutAt); err != nil {
 			return nil, err
 		}
 		out = append(out, d)
 	}
-	return out, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	events, err := r.ListEventsRange(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	for i, d := range out {
+		ad := AttendanceDay{CheckInAt: d.CheckInAt, CheckOutAt: d.CheckOutAt}
+		ad.SetWorked(events[d.Date.Format("2006-01-02")], now)
+		out[i].CheckInAt, out[i].CheckOutAt = ad.CheckInAt, ad.CheckOutAt
+		out[i].WorkedSeconds = ad.WorkedSeconds
+		out[i].BreakSeconds = ad.BreakSeconds
+		out[i].NetWorkedSeconds = ad.NetWorkedSeconds
+	}
+	return out, nil
 }
 
 // GetDayRaw: ambil detail in/out untuk 1 hari.
@@ -146,20 +344,47 @@ type DayRaw struct {
 	InLat      sql.NullFloat64
 	InLng      sql.NullFloat64
 	InDist     sql.NullFloat64
-	InPhotoB64 sql.NullString
+	InPhotoB64 sql.NullString // data lama sebelum blob store
+	InPhotoKey sql.NullString
+	InFaceScr  sql.NullFloat64
+	InFaceStat sql.NullString
+	InLiveness sql.NullString
+	InExif     sql.NullString // JSON
+	InRisk     sql.NullInt64
+	InGeo      sql.NullString // JSON
+	InPresence sql.NullString
+	InPresRef  sql.NullString
+	InReceived sql.NullTime // diisi kalau event offline
 
 	CheckOutAt  sql.NullTime
 	OutLat      sql.NullFloat64
 	OutLng      sql.NullFloat64
 	OutDist     sql.NullFloat64
 	OutPhotoB64 sql.NullString
+	OutPhotoKey sql.NullString
+	OutFaceScr  sql.NullFloat64
+	OutFaceStat sql.NullString
+	OutLiveness sql.NullString
+	OutExif     sql.NullString
+	OutRisk     sql.NullInt64
+	OutGeo      sql.NullString
+	OutPresence sql.NullString
+	OutPresRef  sql.NullString
+	OutReceived sql.NullTime
+
+	Mode string // ModeOffice / ModeWFH / ModeDinas ("" = belum ada absensi)
 }
 
 func (r *AttendanceRepo) GetDayRaw(ctx context.Context, userID string, date time.Time) (DayRaw, error) {
 	const q = `
 		SELECT
-			check_in_at,  check_in_lat,  check_in_lng,  check_in_distance_m,  check_in_photo_b64,
-			check_out_at, check_out_lat, check_out_lng, check_out_distance_m, check_out_photo_b64
+			check_in_at,  check_in_lat,  check_in_lng,  check_in_distance_m,  check_in_photo_b64,  check_in_photo_key,
+			check_in_face_score,  check_in_face_status,  check_in_liveness,  check_in_exif::text,
+			check_in_risk_score,  check_in_geo::text,  check_in_presence,  check_in_presence_ref,  check_in_received_at,
+			check_out_at, check_out_lat, check_out_lng, check_out_distance_m, check_out_photo_b64, check_out_photo_key,
+			check_out_face_score, check_out_face_status, check_out_liveness, check_out_exif::text,
+			check_out_risk_score, check_out_geo::text, check_out_presence, check_out_presence_ref, check_out_received_at,
+			mode
 		FROM attendance_days
 		WHERE user_id = $1 AND date = $2::date
 		LIMIT 1;
@@ -168,11 +393,37 @@ func (r *AttendanceRepo) GetDayRaw(ctx context.Context, userID string, date time
 	err := r.DB.QueryRowContext(ctx, q,
 		userID, date.Format("2006-01-02"),
 	).Scan(
-		&dr.CheckInAt, &dr.InLat, &dr.InLng, &dr.InDist, &dr.InPhotoB64,
-		&dr.CheckOutAt, &dr.OutLat, &dr.OutLng, &dr.OutDist, &dr.OutPhotoB64,
+		&dr.CheckInAt, &dr.InLat, &dr.InLng, &dr.InDist, &dr.InPhotoB64, &dr.InPhotoKey,
+		&dr.InFaceScr, &dr.InFaceStat, &dr.InLiveness, &dr.InExif, &dr.InRisk, &dr.InGeo, &dr.InPresence, &dr.InPresRef, &dr.InReceived,
+		&dr.CheckOutAt, &dr.OutLat, &dr.OutLng, &dr.OutDist, &dr.OutPhotoB64, &dr.OutPhotoKey,
+		&dr.OutFaceScr, &dr.OutFaceStat, &dr.OutLiveness, &dr.OutExif, &dr.OutRisk, &dr.OutGeo, &dr.OutPresence, &dr.OutPresRef, &dr.OutReceived,
+		&dr.Mode,
 	)
 	if err == sql.ErrNoRows {
 		return DayRaw{}, nil
 	}
 	return dr, err
 }
+
+// LastLocation: lokasi absen (check-in / check-out) terakhir user sebelum t.
+func (r *AttendanceRepo) LastLocation(ctx context.Context, userID string, before time.Time) (lat, lng float64, at time.Time, ok bool, err error) {
+	const q = `
+		SELECT lat, lng, at FROM (
+			SELECT check_in_lat AS lat, check_in_lng AS lng, check_in_at AS at
+			FROM attendance_days WHERE user_id = $1 AND check_in_at < $2 AND check_in_lat IS NOT NULL
+			UNION ALL
+			SELECT check_out_lat, check_out_lng, check_out_at
+			FROM attendance_days WHERE user_id = $1 AND check_out_at < $2 AND check_out_lat IS NOT NULL
+		) e
+		ORDER BY at DESC
+		LIMIT 1
+	`
+	err = r.DB.QueryRowContext(ctx, q, userID, before).Scan(&lat, &lng, &at)
+	if err == sql.ErrNoRows {
+		return 0, 0, time.Time{}, false, nil
+	}
+	if err != nil {
+		return 0, 0, time.Time{}, false, err
+	}
+	return lat, lng, at, true, nil
+}
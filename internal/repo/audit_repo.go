@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type AuditRepo struct{ DB *sql.DB }
+
+func NewAuditRepo(db *sql.DB) *AuditRepo { return &AuditRepo{DB: db} }
+
+// ImpersonationEntry: satu baris audit impersonation.
+type ImpersonationEntry struct {
+	ID        int64
+	AdminID   string
+	UserID    string
+	Action    string // start | request
+	Method    string
+	Path      string
+	Status    int
+	ReadOnly  bool
+	Reason    string
+	IP        string
+	UserAgent string
+	At        time.Time
+}
+
+func (r *AuditRepo) LogImpersonation(ctx context.Context, e ImpersonationEntry) error {
+	if e.Action == "" {
+		e.Action = "request"
+	}
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO impersonation_audit
+		  (admin_id, user_id, action, method, path, status, read_only, reason, ip, user_agent)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,NULLIF($8,''),NULLIF($9,''),NULLIF($10,''))`,
+		e.AdminID, e.UserID, e.Action, e.Method, e.Path, e.Status, e.ReadOnly, e.Reason, e.IP, e.UserAgent)
+	return err
+}
+
+// ListImpersonation: audit terbaru, filter admin/user opsional.
+func (r *AuditRepo) ListImpersonation(ctx context.Context, adminID, userID string, limit, offset int) ([]ImpersonationEntry, error) {
+	var where []string
+	var args []any
+	if adminID != "" {
+		args = append(args, adminID)
+		where = append(where, fmt.Sprintf("admin_id = $%d", len(args)))
+	}
+	if userID != "" {
+		args = append(args, userID)
+		where = append(where, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	cond := ""
+	if len(where) > 0 {
+		cond = " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, limit, offset)
+	q := fmt.Sprintf(`
+		SELECT id, admin_id::text, user_id::text, action, method, path, status, read_only,
+		       COALESCE(reason,''), COALESCE(ip,''), COALESCE(user_agent,''), at
+		FROM impersonation_audit%s
+		ORDER BY at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, cond, len(args)-1, len(args))
+
+	rows, err := r.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ImpersonationEntry
+	for rows.Next() {
+		var e ImpersonationEntry
+		if err := rows.Scan(&e.ID, &e.AdminID, &e.UserID, &e.Action, &e.Method, &e.Path, &e.Status, &e.ReadOnly,
+			&e.Reason, &e.IP, &e.UserAgent, &e.At); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+)
+
+const zeroUUID = "00000000-0000-0000-0000-000000000000"
+
+// LegacyBlob: satu foto / bukti base64 lama yang belum dipindah ke blob store.
+type LegacyBlob struct {
+	ID     string // attendance_days.id / leave_requests.id
+	UserID string
+	Date   string // YYYY-MM-DD
+	Column string // check_in | check_out | proof
+	Base64 string
+}
+
+// NextLegacyPhotos: foto absensi yang masih tersimpan di kolom *_photo_b64,
+// urut id setelah afterID ("" = dari awal) supaya baris yang gagal dipindah
+// tidak diambil berulang.
+func (r *AttendanceRepo) NextLegacyPhotos(ctx context.Context, afterID string, limit int) ([]LegacyBlob, error) {
+	if afterID == "" {
+		afterID = zeroUUID
+	}
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id::text, user_id::text, to_char(date,'YYYY-MM-DD'),
+		       CASE WHEN check_in_photo_key  IS NULL THEN COALESCE(check_in_photo_b64,'')  ELSE '' END,
+		       CASE WHEN check_out_photo_key IS NULL THEN COALESCE(check_out_photo_b64,'') ELSE '' END
+		FROM attendance_days
+		WHERE id > $1::uuid
+		  AND ((check_in_photo_b64  <> '' AND check_in_photo_key  IS NULL)
+		    OR (check_out_photo_b64 <> '' AND check_out_photo_key IS NULL))
+		ORDER BY id
+		LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []LegacyBlob
+	for rows.Next() {
+		var id, userID, date, in, outB64 string
+		if err := rows.Scan(&id, &userID, &date, &in, &outB64); err != nil {
+			return nil, err
+		}
+		if in != "" {
+			out = append(out, LegacyBlob{ID: id, UserID: userID, Date: date, Column: "check_in", Base64: in})
+		}
+		if outB64 != "" {
+			out = append(out, LegacyBlob{ID: id, UserID: userID, Date: date, Column: "check_out", Base64: outB64})
+		}
+	}
+	return out, rows.Err()
+}
+
+// SetPhotoKey: simpan key hasil pemindahan lalu kosongkan kolom base64.
+func (r *AttendanceRepo) SetPhotoKey(ctx context.Context, id, column, key string) error {
+	var q string
+	switch column {
+	case "check_in":
+		q = `UPDATE attendance_days SET check_in_photo_key=$2, check_in_photo_b64=NULL WHERE id=$1`
+	case "check_out":
+		q = `UPDATE attendance_days SET check_out_photo_key=$2, check_out_photo_b64=NULL WHERE id=$1`
+	default:
+		return fmt.Errorf("unknown photo column %q", column)
+	}
+	_, err := r.DB.ExecContext(ctx, q, id, key)
+	return err
+}
+
+// NextLegacyProofs: bukti sakit yang masih di proof_base64.
+func (r *LeaveRepo) NextLegacyProofs(ctx context.Context, afterID string, limit int) ([]LegacyBlob, error) {
+	if afterID == "" {
+		afterID = zeroUUID
+	}
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id::text, user_id::text, to_char(start_date,'YYYY-MM-DD'), 'proof', proof_base64
+		FROM leave_requests
+		WHERE id > $1::uuid AND proof_base64 <> '' AND proof_key IS NULL
+		ORDER BY id
+		LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []LegacyBlob
+	for rows.Next() {
+		var b LegacyBlob
+		if err := rows.Scan(&b.ID, &b.UserID, &b.Date, &b.Column, &b.Base64); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func (r *LeaveRepo) SetProofKey(ctx context.Context, id, key string) error {
+	_, err := r.DB.ExecContext(ctx,
+		`UPDATE leave_requests SET proof_key=$2, proof_base64=NULL WHERE id=$1`, id, key)
+	return err
+}
@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type DeviceRepo struct{ DB *sql.DB }
+
+func NewDeviceRepo(db *sql.DB) *DeviceRepo { return &DeviceRepo{DB: db} }
+
+// UserDevice: perangkat user dengan public key ed25519 untuk absen offline.
+type UserDevice struct {
+	ID         string
+	UserID     string
+	Name       string
+	PublicKey  []byte
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
+
+const deviceCols = `id::text, user_id::text, name, public_key, created_at, last_used_at, revoked_at`
+
+func scanDevice(s interface{ Scan(...any) error }) (UserDevice, error) {
+	var d UserDevice
+	err := s.Scan(&d.ID, &d.UserID, &d.Name, &d.PublicKey, &d.CreatedAt, &d.LastUsedAt, &d.RevokedAt)
+	return d, err
+}
+
+func (r *DeviceRepo) Register(ctx context.Context, userID, name string, pub []byte) (UserDevice, error) {
+	return scanDevice(r.DB.QueryRowContext(ctx, `
+		INSERT INTO user_devices (user_id, name, public_key)
+		VALUES ($1::uuid, $2, $3)
+		RETURNING `+deviceCols, userID, name, pub))
+}
+
+func (r *DeviceRepo) Get(ctx context.Context, id string) (UserDevice, error) {
+	return scanDevice(r.DB.QueryRowContext(ctx,
+		`SELECT `+deviceCols+` FROM user_devices WHERE id = $1`, id))
+}
+
+func (r *DeviceRepo) ListByUser(ctx context.Context, userID string) ([]UserDevice, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+deviceCols+` FROM user_devices WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []UserDevice
+	for rows.Next() {
+		d, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// Revoke: hanya perangkat milik userID yang masih aktif.
+func (r *DeviceRepo) Revoke(ctx context.Context, userID, id string) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE user_devices SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+func (r *DeviceRepo) Touch(ctx context.Context, id string) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE user_devices SET last_used_at = NOW() WHERE id = $1`, id)
+	return err
+}
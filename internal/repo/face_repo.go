@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type FaceRepo struct{ DB *sql.DB }
+
+func NewFaceRepo(db *sql.DB) *FaceRepo { return &FaceRepo{DB: db} }
+
+// FaceEnrollment: foto referensi wajah user.
+type FaceEnrollment struct {
+	UserID     string
+	PhotoB64   string
+	EnrolledBy string
+	EnrolledAt time.Time
+}
+
+// Get: ok=false kalau user belum enroll.
+func (r *FaceRepo) Get(ctx context.Context, userID string) (FaceEnrollment, bool, error) {
+	var fe FaceEnrollment
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT user_id::text, photo_base64, COALESCE(enrolled_by::text,''), enrolled_at
+		FROM face_enrollments WHERE user_id=$1`, userID).
+		Scan(&fe.UserID, &fe.PhotoB64, &fe.EnrolledBy, &fe.EnrolledAt)
+	if err == sql.ErrNoRows {
+		return FaceEnrollment{}, false, nil
+	}
+	if err != nil {
+		return FaceEnrollment{}, false, err
+	}
+	return fe, true, nil
+}
+
+// Enroll: simpan / ganti foto referensi. replace=false → gagal (false)
+// kalau sudah ada (dipakai untuk self-enroll pertama kali).
+func (r *FaceRepo) Enroll(ctx context.Context, userID, photoB64, enrolledBy string, replace bool) (bool, error) {
+	q := `
+		INSERT INTO face_enrollments (user_id, photo_base64, enrolled_by)
+		VALUES ($1, $2, NULLIF($3,'')::uuid)
+		ON CONFLICT (user_id) DO NOTHING`
+	if replace {
+		q = `
+		INSERT INTO face_enrollments (user_id, photo_base64, enrolled_by)
+		VALUES ($1, $2, NULLIF($3,'')::uuid)
+		ON CONFLICT (user_id) DO UPDATE SET
+			photo_base64 = EXCLUDED.photo_base64,
+			enrolled_by  = EXCLUDED.enrolled_by,
+			enrolled_at  = NOW()`
+	}
+	res, err := r.DB.ExecContext(ctx, q, userID, photoB64, enrolledBy)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+func (r *FaceRepo) Delete(ctx context.Context, userID string) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `DELETE FROM face_enrollments WHERE user_id=$1`, userID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
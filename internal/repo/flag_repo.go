@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+type FlagRepo struct{ DB *sql.DB }
+
+func NewFlagRepo(db *sql.DB) *FlagRepo { return &FlagRepo{DB: db} }
+
+// AttendanceFlag: satu item antrian review admin.
+type AttendanceFlag struct {
+	ID         string
+	UserID     string
+	Username   string
+	Date       time.Time
+	Event      string // check_in | check_out
+	Reason     string // face_mismatch, ...
+	Details    map[string]any
+	Status     string // pending | approved | rejected
+	Note       sql.NullString
+	CreatedAt  time.Time
+	ResolvedAt sql.NullTime
+	ResolvedBy sql.NullString
+}
+
+func (r *FlagRepo) Create(ctx context.Context, userID string, date time.Time, event, reason string, details map[string]any) (string, error) {
+	if details == nil {
+		details = map[string]any{}
+	}
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return "", err
+	}
+	var id string
+	err = r.DB.QueryRowContext(ctx, `
+		INSERT INTO attendance_flags (user_id, date, event, reason, details)
+		VALUES ($1, $2::date, $3, $4, $5::jsonb)
+		RETURNING id::text`,
+		userID, date.Format("2006-01-02"), event, reason, string(raw)).Scan(&id)
+	return id, err
+}
+
+// List: antrian review, status "" / "all" = semua.
+func (r *FlagRepo) List(ctx context.Context, status, reason string, limit, offset int) ([]AttendanceFlag, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT f.id::text, f.user_id::text, u.username, f.date, f.event, f.reason, f.details::text,
+		       f.status, f.note, f.created_at, f.resolved_at, f.resolved_by::text
+		FROM attendance_flags f
+		JOIN users u ON u.id = f.user_id
+		WHERE ($1 = '' OR $1 = 'all' OR f.status = $1)
+		  AND ($2 = '' OR f.reason = $2)
+		ORDER BY f.created_at DESC
+		LIMIT $3 OFFSET $4`, status, reason, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AttendanceFlag
+	for rows.Next() {
+		var f AttendanceFlag
+		var details string
+		if err := rows.Scan(&f.ID, &f.UserID, &f.Username, &f.Date, &f.Event, &f.Reason, &details,
+			&f.Status, &f.Note, &f.CreatedAt, &f.ResolvedAt, &f.ResolvedBy); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(details), &f.Details)
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// Resolve: putuskan flag yang masih pending (approved = absensi sah).
+func (r *FlagRepo) Resolve(ctx context.Context, id, status, resolvedBy, note string) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE attendance_flags
+		SET status=$2, resolved_by=$3::uuid, note=NULLIF($4,''), resolved_at=NOW()
+		WHERE id=$1 AND status='pending'`, id, status, resolvedBy, note)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
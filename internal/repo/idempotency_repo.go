@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type IdempotencyRepo struct{ DB *sql.DB }
+
+func NewIdempotencyRepo(db *sql.DB) *IdempotencyRepo { return &IdempotencyRepo{DB: db} }
+
+// IdempotentResponse: request tersimpan untuk satu (scope, key).
+type IdempotentResponse struct {
+	Method      string
+	Path        string
+	RequestHash string
+	Status      sql.NullInt64 // NULL = masih diproses
+	ContentType sql.NullString
+	Body        []byte
+	LeaseToken  string // diisi kalau Begin mengklaim key; wajib untuk Complete/Release
+}
+
+// Begin: klaim key. created=true → request ini yang pertama (atau mengambil
+// alih key yang lease-nya habis tanpa response) dan harus diproses; selain
+// itu kembalikan data yang sudah tersimpan. Ambil alih hanya untuk request
+// yang sama (method, path, hash body); request tersimpan tidak ditimpa.
+func (r *IdempotencyRepo) Begin(ctx context.Context, scope, key, method, path, reqHash string, ttl, lease time.Duration) (IdempotentResponse, bool, error) {
+	// buang key kedaluwarsa milik scope ini (termasuk key ini sendiri)
+	if _, err := r.DB.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE scope = $1 AND expires_at < NOW()`, scope); err != nil {
+		return IdempotentResponse{}, false, err
+	}
+
+	var token string
+	err := r.DB.QueryRowContext(ctx, `
+		INSERT INTO idempotency_keys (scope, key, method, path, request_hash, expires_at, locked_until, lease_token)
+		VALUES ($1, $2, $3, $4, $5, NOW() + make_interval(secs => $6), NOW() + make_interval(secs => $7), gen_random_uuid())
+		ON CONFLICT (scope, key) DO UPDATE
+		SET expires_at = EXCLUDED.expires_at, locked_until = EXCLUDED.locked_until,
+		    lease_token = EXCLUDED.lease_token
+		WHERE idempotency_keys.status IS NULL
+		  AND COALESCE(idempotency_keys.locked_until, idempotency_keys.created_at) < NOW()
+		  AND idempotency_keys.method = EXCLUDED.method
+		  AND idempotency_keys.path = EXCLUDED.path
+		  AND idempotency_keys.request_hash = EXCLUDED.request_hash
+		RETURNING lease_token::text`,
+		scope, key, method, path, reqHash, ttl.Seconds(), lease.Seconds()).Scan(&token)
+	if err == nil {
+		return IdempotentResponse{LeaseToken: token}, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return IdempotentResponse{}, false, err
+	}
+
+	var ir IdempotentResponse
+	err = r.DB.QueryRowContext(ctx, `
+		SELECT method, path, request_hash, status, content_type, body
+		FROM idempotency_keys WHERE scope = $1 AND key = $2`, scope, key).
+		Scan(&ir.Method, &ir.Path, &ir.RequestHash, &ir.Status, &ir.ContentType, &ir.Body)
+	return ir, false, err
+}
+
+// Complete: simpan response request pertama. Hanya pemegang lease terakhir;
+// attempt lama yang selesai setelah diambil alih retry tidak menimpa apa pun.
+func (r *IdempotencyRepo) Complete(ctx context.Context, scope, key, lease string, status int, contentType string, body []byte) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status = $4, content_type = NULLIF($5,''), body = $6
+		WHERE scope = $1 AND key = $2 AND lease_token = $3::uuid`, scope, key, lease, status, contentType, body)
+	return err
+}
+
+// Release: lepas key (response tidak layak diputar ulang, mis. 5xx) supaya retry diproses lagi.
+// Sama seperti Complete, hanya untuk pemegang lease.
+func (r *IdempotencyRepo) Release(ctx context.Context, scope, key, lease string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND lease_token = $3::uuid`, scope, key, lease)
+	return err
+}
@@ -0,0 +1,155 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"absensi/internal/models"
+)
+
+var (
+	ErrInviteNotFound = errors.New("invitation not found")
+	ErrInviteUsed     = errors.New("invitation already used")
+	ErrInviteExpired  = errors.New("invitation expired or revoked")
+	ErrInviteUsername = errors.New("username does not match invitation")
+)
+
+type InvitationRepo struct{ DB *sql.DB }
+
+func NewInvitationRepo(db *sql.DB) *InvitationRepo { return &InvitationRepo{DB: db} }
+
+const inviteCols = `id::text, COALESCE(username,''), jabatan, role, COALESCE(office,''),
+	COALESCE(full_name,''), COALESCE(nip,''), COALESCE(email,''), COALESCE(phone,''),
+	COALESCE(department,''), hire_date, COALESCE(employment_type,''), contract_end_date,
+	COALESCE(manager_id::text,''), COALESCE(schedule_code,''),
+	COALESCE(created_by::text,''), created_at, expires_at, used_at, COALESCE(used_by::text,''), revoked_at`
+
+func scanInvitation(s rowScanner) (models.Invitation, error) {
+	var inv models.Invitation
+	p := &inv.Profile
+	err := s.Scan(&inv.ID, &p.Username, &p.Jabatan, &p.Role, &p.Office,
+		&p.FullName, &p.NIP, &p.Email, &p.Phone,
+		&p.Department, &p.HireDate, &p.EmploymentType, &p.ContractEndDate,
+		&p.ManagerID, &p.ScheduleCode,
+		&inv.CreatedBy, &inv.CreatedAt, &inv.ExpiresAt, &inv.UsedAt, &inv.UsedBy, &inv.RevokedAt)
+	return inv, err
+}
+
+// Create: simpan undangan baru; tokenHash = SHA-256 hex dari token asli.
+func (r *InvitationRepo) Create(ctx context.Context, tokenHash string, inv models.Invitation) (models.Invitation, error) {
+	p := inv.Profile
+	if p.Role == "" {
+		p.Role = models.RoleEmployee
+	}
+	q := `INSERT INTO user_invitations (token_hash, username, jabatan, role, office,
+	        full_name, nip, email, phone, department, hire_date, employment_type, contract_end_date,
+	        manager_id, schedule_code, created_by, expires_at)
+	      VALUES ($1, NULLIF($2,''), $3, $4, NULLIF($5,''),
+	        NULLIF($6,''), NULLIF($7,''), NULLIF($8,''), NULLIF($9,''), NULLIF($10,''), $11::date, NULLIF($12,''), $13::date,
+	        NULLIF($14,'')::uuid, NULLIF($15,''), NULLIF($16,'')::uuid, $17)
+	      RETURNING ` + inviteCols
+	return scanInvitation(r.DB.QueryRowContext(ctx, q,
+		tokenHash, p.Username, p.Jabatan, p.Role, p.Office,
+		p.FullName, p.NIP, p.Email, p.Phone, p.Department, nullDate(p.HireDate), p.EmploymentType, nullDate(p.ContractEndDate),
+		p.ManagerID, p.ScheduleCode, inv.CreatedBy, inv.ExpiresAt))
+}
+
+// GetByTokenHash: ErrInviteNotFound kalau token tidak dikenal.
+func (r *InvitationRepo) GetByTokenHash(ctx context.Context, tokenHash string) (models.Invitation, error) {
+	inv, err := scanInvitation(r.DB.QueryRowContext(ctx,
+		`SELECT `+inviteCols+` FROM user_invitations WHERE token_hash=$1`, tokenHash))
+	if err == sql.ErrNoRows {
+		return models.Invitation{}, ErrInviteNotFound
+	}
+	return inv, err
+}
+
+// List: daftar undangan terbaru. status: pending|used|expired|revoked|all.
+func (r *InvitationRepo) List(ctx context.Context, status string, limit, offset int) ([]models.Invitation, error) {
+	cond := ""
+	switch status {
+	case "pending":
+		cond = `WHERE used_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()`
+	case "used":
+		cond = `WHERE used_at IS NOT NULL`
+	case "revoked":
+		cond = `WHERE used_at IS NULL AND revoked_at IS NOT NULL`
+	case "expired":
+		cond = `WHERE used_at IS NULL AND revoked_at IS NULL AND expires_at <= NOW()`
+	}
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+inviteCols+` FROM user_invitations `+cond+`
+		 ORDER BY created_at DESC LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Invitation
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, inv)
+	}
+	return out, rows.Err()
+}
+
+// Revoke: batalkan undangan yang belum dipakai.
+func (r *InvitationRepo) Revoke(ctx context.Context, id string) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE user_invitations SET revoked_at = NOW()
+		WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// Accept: pakai undangan untuk membuat user (satu transaksi, undangan
+// dikunci FOR UPDATE supaya token benar-benar sekali pakai). Jabatan, role
+// dan data HR diambil dari undangan, bukan dari pendaftar.
+func (r *InvitationRepo) Accept(ctx context.Context, tokenHash, username, passHash string, now time.Time) (models.User, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return models.User{}, err
+	}
+	defer tx.Rollback()
+
+	inv, err := scanInvitation(tx.QueryRowContext(ctx,
+		`SELECT `+inviteCols+` FROM user_invitations WHERE token_hash=$1 FOR UPDATE`, tokenHash))
+	if err == sql.ErrNoRows {
+		return models.User{}, ErrInviteNotFound
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	switch inv.Status(now) {
+	case "used":
+		return models.User{}, ErrInviteUsed
+	case "expired", "revoked":
+		return models.User{}, ErrInviteExpired
+	}
+	if inv.Profile.Username != "" && username != inv.Profile.Username {
+		return models.User{}, ErrInviteUsername
+	}
+
+	in := inv.Profile
+	in.Username = username
+	in.PasswordHash = passHash
+	u, err := insertUser(ctx, tx, in)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE user_invitations SET used_at=$2, used_by=$3::uuid WHERE id=$1`,
+		inv.ID, now, u.ID); err != nil {
+		return models.User{}, err
+	}
+	return u, tx.Commit()
+}
@@ -0,0 +1,144 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type KioskRepo struct{ DB *sql.DB }
+
+func NewKioskRepo(db *sql.DB) *KioskRepo { return &KioskRepo{DB: db} }
+
+// KioskDevice: tablet kiosk dengan kredensial sendiri.
+type KioskDevice struct {
+	ID         string
+	Name       string
+	Office     string // wajib; hanya pegawai kantor ini yang bisa absen
+	SecretHash string
+	Lat, Lng   sql.NullFloat64 // lokasi tetap kiosk
+	CreatedBy  sql.NullString
+	CreatedAt  time.Time
+	LastSeenAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
+
+const kioskCols = `id::text, name, office, secret_hash, lat, lng, created_by::text, created_at, last_seen_at, revoked_at`
+
+func scanKiosk(s interface{ Scan(...any) error }) (KioskDevice, error) {
+	var d KioskDevice
+	err := s.Scan(&d.ID, &d.Name, &d.Office, &d.SecretHash, &d.Lat, &d.Lng,
+		&d.CreatedBy, &d.CreatedAt, &d.LastSeenAt, &d.RevokedAt)
+	return d, err
+}
+
+func (r *KioskRepo) CreateDevice(ctx context.Context, d KioskDevice) (KioskDevice, error) {
+	return scanKiosk(r.DB.QueryRowContext(ctx, `
+		INSERT INTO kiosk_devices (name, office, secret_hash, lat, lng, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6::uuid)
+		RETURNING `+kioskCols,
+		d.Name, d.Office, d.SecretHash, d.Lat, d.Lng, d.CreatedBy))
+}
+
+func (r *KioskRepo) GetDevice(ctx context.Context, id string) (KioskDevice, error) {
+	return scanKiosk(r.DB.QueryRowContext(ctx,
+		`SELECT `+kioskCols+` FROM kiosk_devices WHERE id = $1`, id))
+}
+
+func (r *KioskRepo) ListDevices(ctx context.Context) ([]KioskDevice, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+kioskCols+` FROM kiosk_devices ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []KioskDevice
+	for rows.Next() {
+		d, err := scanKiosk(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *KioskRepo) RevokeDevice(ctx context.Context, id string) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE kiosk_devices SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+func (r *KioskRepo) TouchDevice(ctx context.Context, id string) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE kiosk_devices SET last_seen_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// Badge: kartu RFID/NFC milik satu pegawai.
+type Badge struct {
+	UID       string
+	UserID    string
+	Username  string
+	Label     sql.NullString
+	CreatedBy sql.NullString
+	CreatedAt time.Time
+}
+
+// PutBadge: daftarkan kartu; kartu yang sudah terdaftar dipindah ke user baru.
+func (r *KioskRepo) PutBadge(ctx context.Context, b Badge) (Badge, error) {
+	err := r.DB.QueryRowContext(ctx, `
+		INSERT INTO badges (uid, user_id, label, created_by)
+		VALUES ($1, $2::uuid, NULLIF($3,''), $4::uuid)
+		ON CONFLICT (uid) DO UPDATE SET
+			user_id = EXCLUDED.user_id, label = EXCLUDED.label,
+			created_by = EXCLUDED.created_by, created_at = NOW()
+		RETURNING created_at`,
+		b.UID, b.UserID, b.Label.String, b.CreatedBy).Scan(&b.CreatedAt)
+	return b, err
+}
+
+func (r *KioskRepo) DeleteBadge(ctx context.Context, uid string) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `DELETE FROM badges WHERE uid = $1`, uid)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// ListBadges: userID "" = semua.
+func (r *KioskRepo) ListBadges(ctx context.Context, userID string) ([]Badge, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT b.uid, b.user_id::text, u.username, b.label, b.created_by::text, b.created_at
+		FROM badges b
+		JOIN users u ON u.id = b.user_id
+		WHERE ($1 = '' OR b.user_id::text = $1)
+		ORDER BY u.username, b.uid`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Badge
+	for rows.Next() {
+		var b Badge
+		if err := rows.Scan(&b.UID, &b.UserID, &b.Username, &b.Label, &b.CreatedBy, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// BadgeUser: user pemilik kartu; sql.ErrNoRows kalau tidak terdaftar.
+func (r *KioskRepo) BadgeUser(ctx context.Context, uid string) (string, error) {
+	var userID string
+	err := r.DB.QueryRowContext(ctx, `SELECT user_id::text FROM badges WHERE uid = $1`, uid).Scan(&userID)
+	return userID, err
+}
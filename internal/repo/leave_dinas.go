@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DinasRequest: pengajuan perjalanan dinas (leave_requests kind 'dinas').
+// Keperluan ada di Reason.
+type DinasRequest struct {
+	LeaveRequest
+	Destination string
+	Lat, Lng    float64 // titik tujuan
+	RadiusM     float64 // geofence tujuan
+	DecidedBy   sql.NullString
+}
+
+const dinasCols = `id::text, user_id::text, kind, status, reason,
+	start_date, end_date, days, created_at, decided_at,
+	destination, dest_lat, dest_lng, dest_radius_m, decided_by::text`
+
+func scanDinas(s rowScanner) (DinasRequest, error) {
+	var dr DinasRequest
+	err := s.Scan(&dr.ID, &dr.UserID, &dr.Kind, &dr.Status, &dr.Reason,
+		&dr.StartDate, &dr.EndDate, &dr.Days, &dr.CreatedAt, &dr.DecidedAt,
+		&dr.Destination, &dr.Lat, &dr.Lng, &dr.RadiusM, &dr.DecidedBy)
+	return dr, err
+}
+
+// CreateDinasPending: insert pengajuan dinas status pending.
+func (r *LeaveRepo) CreateDinasPending(ctx context.Context, userID string, start, end time.Time, days int, purpose string,
+	destination string, lat, lng, radiusM float64) (string, error) {
+	const q = `
+		INSERT INTO leave_requests
+		  (user_id, kind,    status,   reason, start_date, end_date, days,
+		   destination, dest_lat, dest_lng, dest_radius_m)
+		VALUES
+		  ($1,     'dinas', 'pending', $2,     $3::date,   $4::date, $5,
+		   $6,          $7,       $8,       $9)
+		RETURNING id::text
+	`
+	var id string
+	err := r.DB.QueryRowContext(ctx, q, userID, purpose,
+		start.Format("2006-01-02"),
+		end.Format("2006-01-02"),
+		days, destination, lat, lng, radiusM,
+	).Scan(&id)
+	return id, err
+}
+
+// HasDinasOverlap: bentrok dengan pengajuan dinas lain (pending/approved).
+func (r *LeaveRepo) HasDinasOverlap(ctx context.Context, userID string, start, end time.Time) (bool, error) {
+	const q = `
+		SELECT EXISTS (
+		  SELECT 1
+		  FROM leave_requests
+		  WHERE user_id = $1
+		    AND kind = 'dinas'
+		    AND status IN ('pending','approved')
+		    AND NOT ($3::date < start_date OR $2::date > end_date)
+		)
+	`
+	var exists bool
+	err := r.DB.QueryRowContext(ctx, q, userID,
+		start.Format("2006-01-02"),
+		end.Format("2006-01-02"),
+	).Scan(&exists)
+	return exists, err
+}
+
+// GetDinas: satu pengajuan dinas (sql.ErrNoRows kalau tidak ada).
+func (r *LeaveRepo) GetDinas(ctx context.Context, id string) (DinasRequest, error) {
+	return scanDinas(r.DB.QueryRowContext(ctx,
+		`SELECT `+dinasCols+` FROM leave_requests WHERE id = $1 AND kind = 'dinas'`, id))
+}
+
+// DecideDinas: set approved / rejected (hanya jika masih pending).
+func (r *LeaveRepo) DecideDinas(ctx context.Context, id, status, decidedBy string) (bool, error) {
+	return r.decide(ctx, "dinas", id, status, decidedBy)
+}
+
+// ListDinasByYearStatus: daftar pengajuan dinas user dlm 1 tahun, filter status optional.
+func (r *LeaveRepo) ListDinasByYearStatus(ctx context.Context, userID string, year int, status string) ([]DinasRequest, error) {
+	q := `SELECT ` + dinasCols + `
+		FROM leave_requests
+		WHERE user_id = $1
+		  AND kind = 'dinas'
+		  AND EXTRACT(YEAR FROM start_date) = $2
+		  AND ($3 = '' OR $3 = 'all' OR status = $3)
+		ORDER BY start_date DESC, created_at DESC`
+	rows, err := r.DB.QueryContext(ctx, q, userID, year, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DinasRequest
+	for rows.Next() {
+		dr, err := scanDinas(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, dr)
+	}
+	return out, rows.Err()
+}
+
+// ApprovedDinas: perjalanan dinas yang disetujui dan mencakup tanggal date.
+func (r *LeaveRepo) ApprovedDinas(ctx context.Context, userID string, date time.Time) (DinasRequest, bool, error) {
+	dr, err := scanDinas(r.DB.QueryRowContext(ctx, `
+		SELECT `+dinasCols+`
+		FROM leave_requests
+		WHERE user_id = $1 AND kind = 'dinas' AND status = 'approved'
+		  AND $2::date BETWEEN start_date AND end_date
+		ORDER BY decided_at DESC
+		LIMIT 1`, userID, date.Format("2006-01-02")))
+	if err == sql.ErrNoRows {
+		return DinasRequest{}, false, nil
+	}
+	if err != nil {
+		return DinasRequest{}, false, err
+	}
+	return dr, true, nil
+}
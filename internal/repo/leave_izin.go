@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Jenis izin sebagian hari.
+const (
+	IzinLateArrival = "late_arrival" // datang terlambat
+	IzinEarlyLeave  = "early_leave"  // pulang lebih awal
+	IzinStepOut     = "step_out"     // keluar sementara
+)
+
+// IzinRequest: izin sebagian hari (leave_requests kind 'izin').
+// StartDate = EndDate; rentang jam "HH:MM" di StartTime/EndTime.
+type IzinRequest struct {
+	LeaveRequest
+	Type      string
+	StartTime string
+	EndTime   string
+	DecidedBy sql.NullString
+}
+
+const izinCols = `id::text, user_id::text, kind, status, reason,
+	start_date, end_date, days, created_at, decided_at,
+	izin_type, to_char(start_time,'HH24:MI'), to_char(end_time,'HH24:MI'), decided_by::text`
+
+func scanIzin(s rowScanner) (IzinRequest, error) {
+	var ir IzinRequest
+	err := s.Scan(&ir.ID, &ir.UserID, &ir.Kind, &ir.Status, &ir.Reason,
+		&ir.StartDate, &ir.EndDate, &ir.Days, &ir.CreatedAt, &ir.DecidedAt,
+		&ir.Type, &ir.StartTime, &ir.EndTime, &ir.DecidedBy)
+	return ir, err
+}
+
+func (r *LeaveRepo) listIzin(ctx context.Context, q string, args ...any) ([]IzinRequest, error) {
+	rows, err := r.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []IzinRequest
+	for rows.Next() {
+		ir, err := scanIzin(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ir)
+	}
+	return out, rows.Err()
+}
+
+// CreateIzinPending: insert izin status pending. days = 0 (bukan hari penuh,
+// tidak memotong kuota cuti).
+func (r *LeaveRepo) CreateIzinPending(
+	ctx context.Context,
+	userID string,
+	date time.Time,
+	izinType, startTime, endTime, reason string,
+) (string, error) {
+	const q = `
+		INSERT INTO leave_requests
+		  (user_id, kind,   status,   reason, start_date, end_date, days, izin_type, start_time, end_time)
+		VALUES
+		  ($1,     'izin', 'pending', $2,     $3::date,   $3::date, 0,    $4,        $5::time,   $6::time)
+		RETURNING id::text
+	`
+	var id string
+	err := r.DB.QueryRowContext(ctx, q, userID, reason,
+		date.Format("2006-01-02"), izinType, startTime, endTime,
+	).Scan(&id)
+	return id, err
+}
+
+// HasIzinOverlap: bentrok jam dengan izin lain di tanggal yang sama (pending/approved).
+func (r *LeaveRepo) HasIzinOverlap(ctx context.Context, userID string, date time.Time, startTime, endTime string) (bool, error) {
+	const q = `
+		SELECT EXISTS (
+		  SELECT 1
+		  FROM leave_requests
+		  WHERE user_id = $1
+		    AND kind = 'izin'
+		    AND status IN ('pending','approved')
+		    AND start_date = $2::date
+		    AND start_time < $4::time AND end_time > $3::time
+		)
+	`
+	var exists bool
+	err := r.DB.QueryRowContext(ctx, q, userID,
+		date.Format("2006-01-02"), startTime, endTime,
+	).Scan(&exists)
+	return exists, err
+}
+
+// GetIzin: satu izin (sql.ErrNoRows kalau tidak ada).
+func (r *LeaveRepo) GetIzin(ctx context.Context, id string) (IzinRequest, error) {
+	return scanIzin(r.DB.QueryRowContext(ctx,
+		`SELECT `+izinCols+` FROM leave_requests WHERE id = $1 AND kind = 'izin'`, id))
+}
+
+// DecideIzin: set approved / rejected (hanya jika masih pending).
+func (r *LeaveRepo) DecideIzin(ctx context.Context, id, status, decidedBy string) (bool, error) {
+	return r.decide(ctx, "izin", id, status, decidedBy)
+}
+
+// ListIzinByYearStatus: daftar izin user dlm 1 tahun, filter status optional.
+func (r *LeaveRepo) ListIzinByYearStatus(ctx context.Context, userID string, year int, status string) ([]IzinRequest, error) {
+	return r.listIzin(ctx, `SELECT `+izinCols+`
+		FROM leave_requests
+		WHERE user_id = $1
+		  AND kind = 'izin'
+		  AND EXTRACT(YEAR FROM start_date) = $2
+		  AND ($3 = '' OR $3 = 'all' OR status = $3)
+		ORDER BY start_date DESC, start_time DESC`, userID, year, status)
+}
+
+// ApprovedIzin: izin yang disetujui dengan tanggal di [from, to).
+func (r *LeaveRepo) ApprovedIzin(ctx context.Context, userID string, from, to time.Time) ([]IzinRequest, error) {
+	return r.listIzin(ctx, `SELECT `+izinCols+`
+		FROM leave_requests
+		WHERE user_id = $1 AND kind = 'izin' AND status = 'approved'
+		  AND start_date >= $2::date AND start_date < $3::date
+		ORDER BY start_date, start_time`,
+		userID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+}
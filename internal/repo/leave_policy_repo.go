@@ -0,0 +1,217 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type LeavePolicyRepo struct{ DB *sql.DB }
+
+// ErrDefaultPolicy: kebijakan umum cuti tidak boleh dihapus.
+var ErrDefaultPolicy = errors.New("default leave policy cannot be deleted")
+
+// ValidLeavePolicyKind: jenis cuti yang memakai kuota (sama dengan CHECK
+// di migrasi 023). Baru cuti tahunan; sakit tidak dibatasi kuota.
+func ValidLeavePolicyKind(kind string) bool {
+	return kind == "cuti"
+}
+
+func NewLeavePolicyRepo(db *sql.DB) *LeavePolicyRepo { return &LeavePolicyRepo{DB: db} }
+
+// LeavePolicy: jatah tahunan satu jenis cuti untuk cakupan jabatan /
+// jenis kepegawaian (NULL = semua).
+type LeavePolicy struct {
+	ID              string
+	Kind            string
+	Jabatan         sql.NullString
+	EmploymentType  sql.NullString
+	AnnualDays      int
+	ProbationMonths int
+	UpdatedBy       sql.NullString
+	UpdatedAt       time.Time
+}
+
+// LeaveOverride: jatah tahunan khusus satu user (Year NULL = setiap tahun).
+type LeaveOverride struct {
+	ID         string
+	UserID     string
+	Kind       string
+	Year       sql.NullInt64
+	AnnualDays int
+	Note       sql.NullString
+	UpdatedBy  sql.NullString
+	UpdatedAt  time.Time
+}
+
+// IsDefault: kebijakan umum cuti (semua jabatan & jenis kepegawaian).
+func (p LeavePolicy) IsDefault() bool {
+	return p.Kind == "cuti" && !p.Jabatan.Valid && !p.EmploymentType.Valid
+}
+
+const leavePolicyCols = `id::text, kind, jabatan, employment_type, annual_days, probation_months,
+	updated_by::text, updated_at`
+
+func scanLeavePolicy(s rowScanner) (LeavePolicy, error) {
+	var p LeavePolicy
+	err := s.Scan(&p.ID, &p.Kind, &p.Jabatan, &p.EmploymentType, &p.AnnualDays, &p.ProbationMonths,
+		&p.UpdatedBy, &p.UpdatedAt)
+	return p, err
+}
+
+const leaveOverrideCols = `id::text, user_id::text, kind, year, annual_days, note, updated_by::text, updated_at`
+
+func scanLeaveOverride(s rowScanner) (LeaveOverride, error) {
+	var o LeaveOverride
+	err := s.Scan(&o.ID, &o.UserID, &o.Kind, &o.Year, &o.AnnualDays, &o.Note, &o.UpdatedBy, &o.UpdatedAt)
+	return o, err
+}
+
+// ListPolicies: semua kebijakan, filter kind optional.
+func (r *LeavePolicyRepo) ListPolicies(ctx context.Context, kind string) ([]LeavePolicy, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT `+leavePolicyCols+`
+		FROM leave_policies
+		WHERE ($1 = '' OR kind = $1)
+		ORDER BY kind, jabatan NULLS FIRST, employment_type NULLS FIRST`, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []LeavePolicy
+	for rows.Next() {
+		p, err := scanLeavePolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// UpsertPolicy: buat / ganti kebijakan untuk cakupan (kind, jabatan, employment_type).
+func (r *LeavePolicyRepo) UpsertPolicy(ctx context.Context, p LeavePolicy) (LeavePolicy, error) {
+	return scanLeavePolicy(r.DB.QueryRowContext(ctx, `
+		INSERT INTO leave_policies (kind, jabatan, employment_type, annual_days, probation_months, updated_by)
+		VALUES ($1, NULLIF($2,''), NULLIF($3,''), $4, $5, $6)
+		ON CONFLICT (kind, lower(COALESCE(jabatan,'')), COALESCE(employment_type,''))
+		DO UPDATE SET annual_days = EXCLUDED.annual_days,
+		              probation_months = EXCLUDED.probation_months,
+		              updated_by = EXCLUDED.updated_by,
+		              updated_at = NOW()
+		RETURNING `+leavePolicyCols,
+		p.Kind, p.Jabatan.String, p.EmploymentType.String, p.AnnualDays, p.ProbationMonths, p.UpdatedBy))
+}
+
+// GetPolicy: satu kebijakan (sql.ErrNoRows kalau tidak ada).
+func (r *LeavePolicyRepo) GetPolicy(ctx context.Context, id string) (LeavePolicy, error) {
+	return scanLeavePolicy(r.DB.QueryRowContext(ctx, `SELECT `+leavePolicyCols+`
+		FROM leave_policies WHERE id = $1`, id))
+}
+
+// DeletePolicy: hapus satu kebijakan; ErrDefaultPolicy untuk kebijakan umum cuti.
+func (r *LeavePolicyRepo) DeletePolicy(ctx context.Context, id string) (bool, error) {
+	p, err := r.GetPolicy(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if p.IsDefault() {
+		return false, ErrDefaultPolicy
+	}
+	res, err := r.DB.ExecContext(ctx, `
+		DELETE FROM leave_policies
+		WHERE id = $1 AND NOT (kind = 'cuti' AND jabatan IS NULL AND employment_type IS NULL)`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// MatchPolicy: kebijakan paling spesifik untuk jabatan & jenis kepegawaian
+// user; ok=false kalau tidak ada yang cocok.
+func (r *LeavePolicyRepo) MatchPolicy(ctx context.Context, kind, jabatan, employmentType string) (LeavePolicy, bool, error) {
+	p, err := scanLeavePolicy(r.DB.QueryRowContext(ctx, `SELECT `+leavePolicyCols+`
+		FROM leave_policies
+		WHERE kind = $1
+		  AND (jabatan IS NULL OR lower(jabatan) = lower($2))
+		  AND (employment_type IS NULL OR employment_type = $3)
+		ORDER BY (jabatan IS NOT NULL) DESC, (employment_type IS NOT NULL) DESC
+		LIMIT 1`, kind, jabatan, employmentType))
+	if err == sql.ErrNoRows {
+		return LeavePolicy{}, false, nil
+	}
+	if err != nil {
+		return LeavePolicy{}, false, err
+	}
+	return p, true, nil
+}
+
+// GetOverride: override user untuk tahun tsb (year spesifik dulu, lalu
+// yang berlaku setiap tahun); ok=false kalau tidak ada.
+func (r *LeavePolicyRepo) GetOverride(ctx context.Context, userID, kind string, year int) (LeaveOverride, bool, error) {
+	o, err := scanLeaveOverride(r.DB.QueryRowContext(ctx, `SELECT `+leaveOverrideCols+`
+		FROM leave_policy_overrides
+		WHERE user_id = $1 AND kind = $2 AND (year = $3 OR year IS NULL)
+		ORDER BY year NULLS LAST
+		LIMIT 1`, userID, kind, year))
+	if err == sql.ErrNoRows {
+		return LeaveOverride{}, false, nil
+	}
+	if err != nil {
+		return LeaveOverride{}, false, err
+	}
+	return o, true, nil
+}
+
+// ListOverrides: semua override milik user.
+func (r *LeavePolicyRepo) ListOverrides(ctx context.Context, userID string) ([]LeaveOverride, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT `+leaveOverrideCols+`
+		FROM leave_policy_overrides
+		WHERE user_id = $1
+		ORDER BY kind, year NULLS FIRST`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []LeaveOverride
+	for rows.Next() {
+		o, err := scanLeaveOverride(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// UpsertOverride: buat / ganti override untuk (user, kind, year).
+func (r *LeavePolicyRepo) UpsertOverride(ctx context.Context, o LeaveOverride) (LeaveOverride, error) {
+	return scanLeaveOverride(r.DB.QueryRowContext(ctx, `
+		INSERT INTO leave_policy_overrides (user_id, kind, year, annual_days, note, updated_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5,''), $6)
+		ON CONFLICT (user_id, kind, COALESCE(year, 0))
+		DO UPDATE SET annual_days = EXCLUDED.annual_days,
+		              note = EXCLUDED.note,
+		              updated_by = EXCLUDED.updated_by,
+		              updated_at = NOW()
+		RETURNING `+leaveOverrideCols,
+		o.UserID, o.Kind, o.Year, o.AnnualDays, o.Note.String, o.UpdatedBy))
+}
+
+// DeleteOverride: hapus override (user, kind, year); year NULL = yang setiap tahun.
+func (r *LeavePolicyRepo) DeleteOverride(ctx context.Context, userID, kind string, year sql.NullInt64) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		DELETE FROM leave_policy_overrides
+		WHERE user_id = $1 AND kind = $2 AND year IS NOT DISTINCT FROM $3`, userID, kind, year)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestLeavePolicyIsDefault(t *testing.T) {
+	str := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+	tests := []struct {
+		p    LeavePolicy
+		want bool
+	}{
+		{LeavePolicy{Kind: "cuti"}, true},
+		{LeavePolicy{Kind: "sakit"}, false},
+		{LeavePolicy{Kind: "cuti", Jabatan: str("Staff")}, false},
+		{LeavePolicy{Kind: "cuti", EmploymentType: str("kontrak")}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.p.IsDefault(); got != tt.want {
+			t.Errorf("IsDefault(%+v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestValidLeavePolicyKind(t *testing.T) {
+	for kind, want := range map[string]bool{"cuti": true, "sakit": false, "izin": false, "wfh": false, "": false} {
+		if got := ValidLeavePolicyKind(kind); got != want {
+			t.Errorf("ValidLeavePolicyKind(%q) = %v, want %v", kind, got, want)
+		}
+	}
+}
@@ -196,7 +196,8 @@ type SickRequest struct {
 	Days        int
 	CreatedAt   time.Time
 	DecidedAt   sql.NullTime
-	ProofBase64 sql.NullString
+	ProofKey    sql.NullString // object key di blob store
+	LegacyProof bool           // bukti masih di proof_base64 (belum dipindah)
 }
 
 // CreateSakitPending: insert pengajuan sakit (status pending), bukti wajib.
@@ -206,11 +207,11 @@ func (r *LeaveRepo) CreateSakitPending(
 	start, end time.Time,
 	days int,
 	reason string,
-	proofBase64 string,
+	proofKey string,
 ) (string, error) {
 	const q = `
 		INSERT INTO leave_requests
-		  (user_id, kind,  status,  reason, start_date, end_date, days, proof_base64)
+		  (user_id, kind,  status,  reason, start_date, end_date, days, proof_key)
 		VALUES
 		  ($1,     'sakit','pending',$2,     $3::date,  $4::date, $5,   $6)
 		RETURNING id::text
@@ -220,7 +221,7 @@ func (r *LeaveRepo) CreateSakitPending(
 		start.Format("2006-01-02"),
 		end.Format("2006-01-02"),
 		days,
-		proofBase64,
+		proofKey,
 	).Scan(&id); err != nil {
 		return "", err
 	}
@@ -266,7 +267,7 @@ func (r *LeaveRepo) ListSakitByYearStatus(
 	base := `
 		SELECT id::text, user_id::text, kind, status, reason,
 		       start_date, end_date, days, created_at, decided_at,
-		       proof_base64
+		       proof_key, COALESCE(proof_base64,'') <> ''
 		FROM leave_requests
 		WHERE user_id = $1
 		  AND kind = 'sakit'
@@ -295,7 +296,7 @@ func (r *LeaveRepo) ListSakitByYearStatus(
 		if err := rows.Scan(
 			&sr.ID, &sr.UserID, &sr.Kind, &sr.Status, &sr.Reason,
 			&sr.StartDate, &sr.EndDate, &sr.Days, &sr.CreatedAt, &sr.DecidedAt,
-			&sr.ProofBase64,
+			&sr.ProofKey, &sr.LegacyProof,
 		); err != nil {
 			return nil, err
 		}
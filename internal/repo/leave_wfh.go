@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// WFHRequest: pengajuan kerja dari rumah (leave_requests kind 'wfh').
+type WFHRequest struct {
+	LeaveRequest
+	Lat, Lng  sql.NullFloat64 // lokasi rumah saat diajukan; NULL = kebijakan anywhere
+	DecidedBy sql.NullString
+}
+
+const wfhCols = `id::text, user_id::text, kind, status, reason,
+	start_date, end_date, days, created_at, decided_at,
+	wfh_lat, wfh_lng, decided_by::text`
+
+func scanWFH(s rowScanner) (WFHRequest, error) {
+	var wr WFHRequest
+	err := s.Scan(&wr.ID, &wr.UserID, &wr.Kind, &wr.Status, &wr.Reason,
+		&wr.StartDate, &wr.EndDate, &wr.Days, &wr.CreatedAt, &wr.DecidedAt,
+		&wr.Lat, &wr.Lng, &wr.DecidedBy)
+	return wr, err
+}
+
+// CreateWFHPending: insert pengajuan WFH status pending.
+func (r *LeaveRepo) CreateWFHPending(
+	ctx context.Context,
+	userID string,
+	start, end time.Time,
+	days int,
+	reason string,
+	lat, lng sql.NullFloat64,
+) (string, error) {
+	const q = `
+		INSERT INTO leave_requests
+		  (user_id, kind,  status,   reason, start_date, end_date, days, wfh_lat, wfh_lng)
+		VALUES
+		  ($1,     'wfh', 'pending', $2,     $3::date,   $4::date, $5,   $6,      $7)
+		RETURNING id::text
+	`
+	var id string
+	err := r.DB.QueryRowContext(ctx, q, userID, reason,
+		start.Format("2006-01-02"),
+		end.Format("2006-01-02"),
+		days, lat, lng,
+	).Scan(&id)
+	return id, err
+}
+
+// HasWFHOverlap: bentrok dengan pengajuan WFH lain (pending/approved).
+func (r *LeaveRepo) HasWFHOverlap(ctx context.Context, userID string, start, end time.Time) (bool, error) {
+	const q = `
+		SELECT EXISTS (
+		  SELECT 1
+		  FROM leave_requests
+		  WHERE user_id = $1
+		    AND kind = 'wfh'
+		    AND status IN ('pending','approved')
+		    AND NOT ($3::date < start_date OR $2::date > end_date)
+		)
+	`
+	var exists bool
+	err := r.DB.QueryRowContext(ctx, q, userID,
+		start.Format("2006-01-02"),
+		end.Format("2006-01-02"),
+	).Scan(&exists)
+	return exists, err
+}
+
+// GetWFH: satu pengajuan WFH (sql.ErrNoRows kalau tidak ada).
+func (r *LeaveRepo) GetWFH(ctx context.Context, id string) (WFHRequest, error) {
+	return scanWFH(r.DB.QueryRowContext(ctx,
+		`SELECT `+wfhCols+` FROM leave_requests WHERE id = $1 AND kind = 'wfh'`, id))
+}
+
+// DecideWFH: set approved / rejected (hanya jika masih pending).
+func (r *LeaveRepo) DecideWFH(ctx context.Context, id, status, decidedBy string) (bool, error) {
+	return r.decide(ctx, "wfh", id, status, decidedBy)
+}
+
+// decide: approve / reject pengajuan kind tertentu oleh atasan / admin.
+func (r *LeaveRepo) decide(ctx context.Context, kind, id, status, decidedBy string) (bool, error) {
+	const q = `
+		UPDATE leave_requests
+		SET status = $3, decided_at = NOW(), decided_by = $4
+		WHERE id = $1 AND kind = $2 AND status = 'pending'
+	`
+	res, err := r.DB.ExecContext(ctx, q, id, kind, status, decidedBy)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// ListWFHByYearStatus: daftar pengajuan WFH user dlm 1 tahun, filter status optional.
+func (r *LeaveRepo) ListWFHByYearStatus(ctx context.Context, userID string, year int, status string) ([]WFHRequest, error) {
+	q := `SELECT ` + wfhCols + `
+		FROM leave_requests
+		WHERE user_id = $1
+		  AND kind = 'wfh'
+		  AND EXTRACT(YEAR FROM start_date) = $2
+		  AND ($3 = '' OR $3 = 'all' OR status = $3)
+		ORDER BY start_date DESC, created_at DESC`
+	rows, err := r.DB.QueryContext(ctx, q, userID, year, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WFHRequest
+	for rows.Next() {
+		wr, err := scanWFH(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, wr)
+	}
+	return out, rows.Err()
+}
+
+// ApprovedWFH: pengajuan WFH yang disetujui dan mencakup tanggal date.
+func (r *LeaveRepo) ApprovedWFH(ctx context.Context, userID string, date time.Time) (WFHRequest, bool, error) {
+	wr, err := scanWFH(r.DB.QueryRowContext(ctx, `
+		SELECT `+wfhCols+`
+		FROM leave_requests
+		WHERE user_id = $1 AND kind = 'wfh' AND status = 'approved'
+		  AND $2::date BETWEEN start_date AND end_date
+		ORDER BY decided_at DESC
+		LIMIT 1`, userID, date.Format("2006-01-02")))
+	if err == sql.ErrNoRows {
+		return WFHRequest{}, false, nil
+	}
+	if err != nil {
+		return WFHRequest{}, false, err
+	}
+	return wr, true, nil
+}
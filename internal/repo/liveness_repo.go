@@ -0,0 +1,61 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrChallengeInvalid: challenge tidak ada, milik user lain, kedaluwarsa,
+// atau sudah dipakai.
+var ErrChallengeInvalid = errors.New("liveness challenge invalid")
+
+type LivenessRepo struct{ DB *sql.DB }
+
+func NewLivenessRepo(db *sql.DB) *LivenessRepo { return &LivenessRepo{DB: db} }
+
+type LivenessChallenge struct {
+	ID        string
+	UserID    string
+	Action    string
+	Frames    int
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// Issue: buat challenge baru; sekalian bersihkan challenge lama milik user.
+func (r *LivenessRepo) Issue(ctx context.Context, userID, action string, frames int, ttl time.Duration) (LivenessChallenge, error) {
+	if _, err := r.DB.ExecContext(ctx, `
+		DELETE FROM liveness_challenges
+		WHERE user_id=$1 AND (used_at IS NOT NULL OR expires_at < NOW())`, userID); err != nil {
+		return LivenessChallenge{}, err
+	}
+
+	c := LivenessChallenge{UserID: userID, Action: action, Frames: frames}
+	err := r.DB.QueryRowContext(ctx, `
+		INSERT INTO liveness_challenges (user_id, action, frames, expires_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+		RETURNING id::text, issued_at, expires_at`,
+		userID, action, frames, ttl.Seconds(),
+	).Scan(&c.ID, &c.IssuedAt, &c.ExpiresAt)
+	return c, err
+}
+
+// Consume: tandai challenge terpakai (sekali pakai, juga kalau jawaban
+// akhirnya gagal) dan kembalikan isinya.
+func (r *LivenessRepo) Consume(ctx context.Context, id, userID string) (LivenessChallenge, error) {
+	c := LivenessChallenge{ID: id, UserID: userID}
+	err := r.DB.QueryRowContext(ctx, `
+		UPDATE liveness_challenges SET used_at=NOW()
+		WHERE id=$1 AND user_id=$2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING action, frames, issued_at, expires_at`, id, userID).
+		Scan(&c.Action, &c.Frames, &c.IssuedAt, &c.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return LivenessChallenge{}, ErrChallengeInvalid
+	}
+	if err != nil {
+		return LivenessChallenge{}, err
+	}
+	return c, nil
+}
@@ -0,0 +1,170 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type OvertimeRepo struct{ DB *sql.DB }
+
+func NewOvertimeRepo(db *sql.DB) *OvertimeRepo { return &OvertimeRepo{DB: db} }
+
+// Jenis pengajuan lembur.
+const (
+	OvertimePre   = "pre"   // diajukan sebelum lembur
+	OvertimeClaim = "claim" // diklaim setelah lembur
+)
+
+// OvertimeRequest: pengajuan lembur + data absensi & jadwal hari itu untuk
+// menghitung lembur aktual.
+type OvertimeRequest struct {
+	ID              string
+	UserID          string
+	Username        string
+	Date            time.Time
+	Kind            string
+	Minutes         int // diajukan
+	Reason          sql.NullString
+	Status          string        // pending | approved | rejected
+	ApprovedMinutes sql.NullInt64 // batas dari atasan; NULL = sesuai Minutes
+	DecidedBy       sql.NullString
+	DecidedAt       sql.NullTime
+	DecisionNote    sql.NullString
+	CreatedAt       time.Time
+
+	CheckInAt    sql.NullTime
+	CheckOutAt   sql.NullTime
+	BreakSeconds int64          // total istirahat hari itu (dari attendance_events)
+	ScheduleEnd  sql.NullString // "HH:MM"; NULL = user tanpa jadwal
+	WorkDays     sql.NullString // jadwal saat diputuskan; pending = jadwal user sekarang
+}
+
+const overtimeSelect = `
+	SELECT o.id::text, o.user_id::text, u.username, o.date, o.kind, o.minutes, o.reason,
+	       o.status, o.approved_minutes, o.decided_by::text, o.decided_at, o.decision_note, o.created_at,
+	       a.check_in_at, a.check_out_at,
+	       CASE WHEN o.decided_at IS NULL THEN to_char(s.end_time,'HH24:MI') ELSE o.schedule_end END,
+	       CASE WHEN o.decided_at IS NULL THEN s.work_days ELSE o.work_days END
+	FROM overtime_requests o
+	JOIN users u ON u.id = o.user_id
+	LEFT JOIN attendance_days a ON a.user_id = o.user_id AND a.date = o.date
+	LEFT JOIN work_schedules s ON s.code = u.schedule_code`
+
+func scanOvertime(s rowScanner) (OvertimeRequest, error) {
+	var o OvertimeRequest
+	err := s.Scan(&o.ID, &o.UserID, &o.Username, &o.Date, &o.Kind, &o.Minutes, &o.Reason,
+		&o.Status, &o.ApprovedMinutes, &o.DecidedBy, &o.DecidedAt, &o.DecisionNote, &o.CreatedAt,
+		&o.CheckInAt, &o.CheckOutAt, &o.ScheduleEnd, &o.WorkDays)
+	return o, err
+}
+
+func (r *OvertimeRepo) list(ctx context.Context, q string, args ...any) ([]OvertimeRequest, error) {
+	rows, err := r.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []OvertimeRequest
+	for rows.Next() {
+		o, err := scanOvertime(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range out {
+		if err := r.fillBreaks(ctx, &out[i]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// fillBreaks: total istirahat hari lembur (hanya yang sudah check-out).
+func (r *OvertimeRepo) fillBreaks(ctx context.Context, o *OvertimeRequest) error {
+	if !o.CheckOutAt.Valid {
+		return nil
+	}
+	events, err := listEvents(ctx, r.DB, o.UserID, o.Date)
+	if err != nil {
+		return err
+	}
+	ad := AttendanceDay{CheckInAt: o.CheckInAt, CheckOutAt: o.CheckOutAt}
+	ad.SetWorked(events, o.CheckOutAt.Time)
+	o.BreakSeconds = ad.BreakSeconds
+	return nil
+}
+
+// Create: pengajuan / klaim baru (unique violation kalau hari itu sudah ada
+// pengajuan pending / approved).
+func (r *OvertimeRepo) Create(ctx context.Context, userID string, date time.Time, kind string, minutes int, reason string) (string, error) {
+	var id string
+	err := r.DB.QueryRowContext(ctx, `
+		INSERT INTO overtime_requests (user_id, date, kind, minutes, reason)
+		VALUES ($1, $2::date, $3, $4, NULLIF($5,''))
+		RETURNING id::text`,
+		userID, date.Format("2006-01-02"), kind, minutes, reason).Scan(&id)
+	return id, err
+}
+
+// Get: satu pengajuan (sql.ErrNoRows kalau tidak ada).
+func (r *OvertimeRepo) Get(ctx context.Context, id string) (OvertimeRequest, error) {
+	o, err := scanOvertime(r.DB.QueryRowContext(ctx, overtimeSelect+` WHERE o.id = $1`, id))
+	if err != nil {
+		return OvertimeRequest{}, err
+	}
+	return o, r.fillBreaks(ctx, &o)
+}
+
+// ListByUser: pengajuan user dengan tanggal di [from, to).
+func (r *OvertimeRepo) ListByUser(ctx context.Context, userID string, from, to time.Time) ([]OvertimeRequest, error) {
+	return r.list(ctx, overtimeSelect+`
+		WHERE o.user_id = $1 AND o.date >= $2::date AND o.date < $3::date
+		ORDER BY o.date DESC, o.created_at DESC`,
+		userID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+}
+
+// ListForApprover: pengajuan bawahan langsung approverID (all=true → semua
+// user, untuk admin), filter status optional.
+func (r *OvertimeRepo) ListForApprover(ctx context.Context, approverID string, all bool, status string) ([]OvertimeRequest, error) {
+	return r.list(ctx, overtimeSelect+`
+		WHERE ($2 OR u.manager_id = $1::uuid)
+		  AND ($3 = '' OR $3 = 'all' OR o.status = $3)
+		ORDER BY o.date DESC, o.created_at DESC
+		LIMIT 500`, approverID, all, status)
+}
+
+// ListApproved: lembur yang disetujui dengan tanggal di [from, to) (payroll).
+func (r *OvertimeRepo) ListApproved(ctx context.Context, from, to time.Time) ([]OvertimeRequest, error) {
+	return r.list(ctx, overtimeSelect+`
+		WHERE o.status = 'approved' AND o.date >= $1::date AND o.date < $2::date
+		ORDER BY u.username, o.date`,
+		from.Format("2006-01-02"), to.Format("2006-01-02"))
+}
+
+// Decide: approve / reject (hanya jika masih pending). approvedMinutes 0 = sesuai pengajuan.
+// Jadwal kerja user saat ini ikut disimpan (lihat migrasi 021).
+func (r *OvertimeRepo) Decide(ctx context.Context, id, status string, approvedMinutes int, decidedBy, note string) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE overtime_requests
+		SET status = $2, approved_minutes = NULLIF($3, 0), decided_by = $4,
+		    decided_at = NOW(), decision_note = NULLIF($5,''),
+		    schedule_end = (SELECT to_char(s.end_time,'HH24:MI') FROM users u
+		                    JOIN work_schedules s ON s.code = u.schedule_code
+		                    WHERE u.id = overtime_requests.user_id),
+		    work_days = (SELECT s.work_days FROM users u
+		                 JOIN work_schedules s ON s.code = u.schedule_code
+		                 WHERE u.id = overtime_requests.user_id)
+		WHERE id = $1 AND status = 'pending'`,
+		id, status, approvedMinutes, decidedBy, note)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type PhotoHashRepo struct{ DB *sql.DB }
+
+func NewPhotoHashRepo(db *sql.DB) *PhotoHashRepo { return &PhotoHashRepo{DB: db} }
+
+// PhotoMatch: foto lama yang mirip dengan foto baru.
+type PhotoMatch struct {
+	UserID   string
+	Username string
+	Date     time.Time
+	Event    string
+	Distance int
+}
+
+// FindSimilar: foto sejak `since` dengan jarak Hamming ≤ maxDist, terdekat dulu.
+// Scan linear di jendela waktu itu; bit_count() butuh PG14, jadi hitung bit
+// lewat representasi teks bit(64) supaya jalan di versi lama juga.
+func (r *PhotoHashRepo) FindSimilar(ctx context.Context, hash uint64, maxDist int, since time.Time, limit int) ([]PhotoMatch, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT user_id::text, username, date, event, dist FROM (
+			SELECT p.user_id, u.username, p.date, p.event,
+			       length(replace(((p.hash # $1)::bit(64))::text, '0', '')) AS dist
+			FROM photo_hashes p
+			JOIN users u ON u.id = p.user_id
+			WHERE p.created_at >= $2
+		) x
+		WHERE dist <= $3
+		ORDER BY dist, date DESC
+		LIMIT $4`, int64(hash), since, maxDist, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PhotoMatch
+	for rows.Next() {
+		var m PhotoMatch
+		if err := rows.Scan(&m.UserID, &m.Username, &m.Date, &m.Event, &m.Distance); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (r *PhotoHashRepo) Insert(ctx context.Context, userID string, date time.Time, event string, hash uint64) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO photo_hashes (user_id, date, event, hash)
+		VALUES ($1, $2::date, $3, $4)`,
+		userID, date.Format("2006-01-02"), event, int64(hash))
+	return err
+}
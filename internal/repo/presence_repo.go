@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type PresenceRepo struct{ DB *sql.DB }
+
+func NewPresenceRepo(db *sql.DB) *PresenceRepo { return &PresenceRepo{DB: db} }
+
+// PresenceProof: BSSID Wi-Fi / beacon BLE terpercaya milik satu kantor.
+type PresenceProof struct {
+	ID         string
+	Office     string // "" = semua kantor
+	Kind       string // wifi | ble
+	Identifier string
+	Label      sql.NullString
+	CreatedBy  sql.NullString
+	CreatedAt  time.Time
+}
+
+func (r *PresenceRepo) Create(ctx context.Context, p PresenceProof) (PresenceProof, error) {
+	err := r.DB.QueryRowContext(ctx, `
+		INSERT INTO presence_proofs (office, kind, identifier, label, created_by)
+		VALUES ($1, $2, $3, NULLIF($4,''), $5::uuid)
+		RETURNING id::text, created_at`,
+		p.Office, p.Kind, p.Identifier, p.Label.String, p.CreatedBy).Scan(&p.ID, &p.CreatedAt)
+	return p, err
+}
+
+// List: office "" = semua; kind "" = semua.
+func (r *PresenceRepo) List(ctx context.Context, office, kind string) ([]PresenceProof, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id::text, office, kind, identifier, label, created_by::text, created_at
+		FROM presence_proofs
+		WHERE ($1 = '' OR office = $1) AND ($2 = '' OR kind = $2)
+		ORDER BY office, kind, identifier`, office, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []PresenceProof
+	for rows.Next() {
+		var p PresenceProof
+		if err := rows.Scan(&p.ID, &p.Office, &p.Kind, &p.Identifier, &p.Label, &p.CreatedBy, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (r *PresenceRepo) Delete(ctx context.Context, id string) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `DELETE FROM presence_proofs WHERE id=$1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// Match: cari identifier hasil scan yang terdaftar untuk kantor user (atau
+// untuk semua kantor). Wi-Fi didahulukan dari BLE. ok=false kalau tidak ada.
+func (r *PresenceRepo) Match(ctx context.Context, office string, wifi, ble []string) (kind, identifier string, ok bool, err error) {
+	if len(wifi) == 0 && len(ble) == 0 {
+		return "", "", false, nil
+	}
+	err = r.DB.QueryRowContext(ctx, `
+		SELECT kind, identifier
+		FROM presence_proofs
+		WHERE office IN ('', $1)
+		  AND ((kind = 'wifi' AND identifier = ANY($2)) OR (kind = 'ble' AND identifier = ANY($3)))
+		ORDER BY kind DESC, identifier
+		LIMIT 1`, office, wifi, ble).Scan(&kind, &identifier)
+	if err == sql.ErrNoRows {
+		return "", "", false, nil
+	}
+	if err != nil {
+		return "", "", false, err
+	}
+	return kind, identifier, true, nil
+}
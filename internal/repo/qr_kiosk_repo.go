@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type QRKioskRepo struct{ DB *sql.DB }
+
+func NewQRKioskRepo(db *sql.DB) *QRKioskRepo { return &QRKioskRepo{DB: db} }
+
+// QRKiosk: secret kode QR berputar milik satu kantor.
+type QRKiosk struct {
+	Office    string
+	Secret    []byte
+	CreatedBy sql.NullString
+	CreatedAt time.Time
+	RotatedAt time.Time
+}
+
+// Rotate: buat secret kantor, atau ganti kalau sudah ada (kode lama langsung mati).
+func (r *QRKioskRepo) Rotate(ctx context.Context, office string, secret []byte, by string) (QRKiosk, error) {
+	k := QRKiosk{Office: office, Secret: secret}
+	err := r.DB.QueryRowContext(ctx, `
+		INSERT INTO qr_kiosks (office, secret, created_by)
+		VALUES ($1, $2, $3::uuid)
+		ON CONFLICT (office) DO UPDATE SET secret = EXCLUDED.secret, rotated_at = NOW()
+		RETURNING created_by::text, created_at, rotated_at`,
+		office, secret, by).Scan(&k.CreatedBy, &k.CreatedAt, &k.RotatedAt)
+	return k, err
+}
+
+func (r *QRKioskRepo) Get(ctx context.Context, office string) (QRKiosk, error) {
+	var k QRKiosk
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT office, secret, created_by::text, created_at, rotated_at
+		FROM qr_kiosks WHERE office = $1`, office).
+		Scan(&k.Office, &k.Secret, &k.CreatedBy, &k.CreatedAt, &k.RotatedAt)
+	return k, err
+}
+
+// List: tanpa secret.
+func (r *QRKioskRepo) List(ctx context.Context) ([]QRKiosk, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT office, created_by::text, created_at, rotated_at
+		FROM qr_kiosks ORDER BY office`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []QRKiosk
+	for rows.Next() {
+		var k QRKiosk
+		if err := rows.Scan(&k.Office, &k.CreatedBy, &k.CreatedAt, &k.RotatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+func (r *QRKioskRepo) Delete(ctx context.Context, office string) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `DELETE FROM qr_kiosks WHERE office = $1`, office)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
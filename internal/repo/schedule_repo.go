@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"absensi/internal/models"
+)
+
+type ScheduleRepo struct{ DB *sql.DB }
+
+func NewScheduleRepo(db *sql.DB) *ScheduleRepo { return &ScheduleRepo{DB: db} }
+
+const scheduleCols = `code, name, to_char(start_time,'HH24:MI'), to_char(end_time,'HH24:MI'), work_days,
+	break_min_minutes, COALESCE(break_max_minutes,0), break_outside, created_at`
+
+func scanSchedule(s rowScanner) (models.WorkSchedule, error) {
+	var ws models.WorkSchedule
+	err := s.Scan(&ws.Code, &ws.Name, &ws.StartTime, &ws.EndTime, &ws.WorkDays,
+		&ws.BreakMinMinutes, &ws.BreakMaxMinutes, &ws.BreakOutside, &ws.CreatedAt)
+	return ws, err
+}
+
+func (r *ScheduleRepo) GetByCode(ctx context.Context, code string) (models.WorkSchedule, error) {
+	return scanSchedule(r.DB.QueryRowContext(ctx,
+		`SELECT `+scheduleCols+` FROM work_schedules WHERE code=$1`, code))
+}
+
+func (r *ScheduleRepo) List(ctx context.Context) ([]models.WorkSchedule, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT `+scheduleCols+` FROM work_schedules ORDER BY code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.WorkSchedule
+	for rows.Next() {
+		ws, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ws)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,211 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"absensi/internal/models"
+)
+
+// ImportUser: satu baris import pegawai yang sudah lolos validasi field
+// (lihat package userimport). Field string kosong = tidak diubah.
+type ImportUser struct {
+	Line         int
+	User         models.User // Username wajib; Role/Jabatan wajib utk user baru
+	PasswordHash string      // "" = tidak diubah (wajib utk user baru)
+	ManagerRef   string      // username atau NIP atasan
+	Active       *bool
+}
+
+// ImportOutcome: hasil per baris.
+type ImportOutcome struct {
+	Line     int      `json:"line"`
+	Username string   `json:"username"`
+	Action   string   `json:"action"` // create | update | error
+	UserID   string   `json:"user_id,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// Import: upsert semua baris (key = username) dalam satu transaksi.
+// Tiap baris dibungkus SAVEPOINT supaya error DB (NIP/email dobel, jadwal
+// tidak dikenal, dsb.) tercatat per baris. Transaksi hanya di-commit kalau
+// dryRun=false dan tidak ada satu pun baris yang error.
+func (r *UserRepo) Import(ctx context.Context, rows []ImportUser, dryRun bool) ([]ImportOutcome, bool, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	out := make([]ImportOutcome, len(rows))
+	failed := false
+
+	// pass 1: upsert data user (tanpa atasan, karena atasan bisa ada di baris lain)
+	for i, row := range rows {
+		o := &out[i]
+		o.Line, o.Username = row.Line, row.User.Username
+
+		id, action, err := withSavepoint(ctx, tx, func() (string, string, error) {
+			return upsertImported(ctx, tx, row)
+		})
+		if err != nil {
+			o.Action, o.Errors = "error", []string{importErrMessage(err)}
+			failed = true
+			continue
+		}
+		o.Action, o.UserID = action, id
+	}
+
+	// pass 2: atasan langsung
+	for i, row := range rows {
+		o := &out[i]
+		if o.Action == "error" || row.ManagerRef == "" {
+			continue
+		}
+		_, _, err := withSavepoint(ctx, tx, func() (string, string, error) {
+			var mgrID string
+			err := tx.QueryRowContext(ctx, `
+				SELECT id::text FROM users
+				WHERE username = $1 OR nip = $1
+				ORDER BY (username = $1) DESC
+				LIMIT 1`, row.ManagerRef).Scan(&mgrID)
+			if err == sql.ErrNoRows {
+				return "", "", fmt.Errorf("manager %q not found", row.ManagerRef)
+			}
+			if err != nil {
+				return "", "", err
+			}
+			if mgrID == o.UserID {
+				return "", "", fmt.Errorf("user cannot be their own manager")
+			}
+			_, err = tx.ExecContext(ctx,
+				`UPDATE users SET manager_id = $2::uuid, updated_at = NOW() WHERE id = $1`, o.UserID, mgrID)
+			return "", "", err
+		})
+		if err != nil {
+			o.Action, o.Errors = "error", []string{importErrMessage(err)}
+			failed = true
+		}
+	}
+
+	if dryRun || failed {
+		return out, false, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
+
+func withSavepoint(ctx context.Context, tx *sql.Tx, fn func() (string, string, error)) (string, string, error) {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT import_row`); err != nil {
+		return "", "", err
+	}
+	a, b, err := fn()
+	if err != nil {
+		if _, rbErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT import_row`); rbErr != nil {
+			return "", "", rbErr
+		}
+		return "", "", err
+	}
+	_, err = tx.ExecContext(ctx, `RELEASE SAVEPOINT import_row`)
+	return a, b, err
+}
+
+func upsertImported(ctx context.Context, tx *sql.Tx, row ImportUser) (string, string, error) {
+	u := row.User
+
+	var id string
+	err := tx.QueryRowContext(ctx, `SELECT id::text FROM users WHERE username = $1`, u.Username).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		return "", "", err
+	}
+
+	var active any
+	if row.Active != nil {
+		active = *row.Active
+	}
+
+	if id == "" {
+		if row.PasswordHash == "" {
+			return "", "", fmt.Errorf("password required for new user")
+		}
+		if u.Jabatan == "" {
+			return "", "", fmt.Errorf("jabatan required for new user")
+		}
+		if u.Role == "" {
+			u.Role = models.RoleEmployee
+		}
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO users (username, password_hash, jabatan, role, office,
+			  full_name, nip, email, phone, department, hire_date, employment_type, contract_end_date,
+			  schedule_code, is_active, deactivated_at)
+			VALUES ($1,$2,$3,$4,NULLIF($5,''),
+			  NULLIF($6,''),NULLIF($7,''),NULLIF($8,''),NULLIF($9,''),NULLIF($10,''),$11::date,NULLIF($12,''),$13::date,
+			  NULLIF($14,''), COALESCE($15::boolean, TRUE), CASE WHEN $15::boolean = FALSE THEN NOW() END)
+			RETURNING id::text`,
+			u.Username, row.PasswordHash, u.Jabatan, u.Role, u.Office,
+			u.FullName, u.NIP, u.Email, u.Phone, u.Department, nullDate(u.HireDate), u.EmploymentType, nullDate(u.ContractEndDate),
+			u.ScheduleCode, active,
+		).Scan(&id)
+		return id, "create", err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users SET
+		  password_hash     = COALESCE(NULLIF($2,''), password_hash),
+		  jabatan           = COALESCE(NULLIF($3,''), jabatan),
+		  role              = COALESCE(NULLIF($4,''), role),
+		  office            = COALESCE(NULLIF($5,''), office),
+		  full_name         = COALESCE(NULLIF($6,''), full_name),
+		  nip               = COALESCE(NULLIF($7,''), nip),
+		  email             = COALESCE(NULLIF($8,''), email),
+		  phone             = COALESCE(NULLIF($9,''), phone),
+		  department        = COALESCE(NULLIF($10,''), department),
+		  hire_date         = COALESCE($11::date, hire_date),
+		  employment_type   = COALESCE(NULLIF($12,''), employment_type),
+		  contract_end_date = COALESCE($13::date, contract_end_date),
+		  schedule_code     = COALESCE(NULLIF($14,''), schedule_code),
+		  is_active         = COALESCE($15::boolean, is_active),
+		  deactivated_at    = CASE
+		                        WHEN $15::boolean = FALSE THEN COALESCE(deactivated_at, NOW())
+		                        WHEN $15::boolean = TRUE  THEN NULL
+		                        ELSE deactivated_at
+		                      END,
+		  updated_at        = NOW()
+		WHERE id = $1`,
+		id, row.PasswordHash, u.Jabatan, u.Role, u.Office,
+		u.FullName, u.NIP, u.Email, u.Phone, u.Department, nullDate(u.HireDate), u.EmploymentType, nullDate(u.ContractEndDate),
+		u.ScheduleCode, active,
+	)
+	if err != nil {
+		return "", "", err
+	}
+	if row.Active != nil && !*row.Active {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE refresh_tokens SET revoked=TRUE WHERE user_id=$1 AND revoked=FALSE`, id); err != nil {
+			return "", "", err
+		}
+	}
+	return id, "update", nil
+}
+
+// importErrMessage: terjemahkan error constraint Postgres ke pesan per baris.
+func importErrMessage(err error) string {
+	low := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(low, "users_nip_key"):
+		return "nip already used by another user"
+	case strings.Contains(low, "users_email_key"):
+		return "email already used by another user"
+	case strings.Contains(low, "schedule_code"):
+		return "unknown schedule"
+	case strings.Contains(low, "users_employment_type_check"):
+		return "invalid employment_type"
+	case strings.Contains(low, "users_role_check"):
+		return "invalid role"
+	}
+	return err.Error()
+}
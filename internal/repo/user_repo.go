@@ -3,6 +3,8 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"absensi/internal/models"
@@ -12,23 +14,97 @@ type UserRepo struct{ DB *sql.DB }
 
 func NewUserRepo(db *sql.DB) *UserRepo { return &UserRepo{DB: db} }
 
-func (r *UserRepo) Create(ctx context.Context, username, passHash, jabatan string) (models.User, error) {
-	q := `INSERT INTO users (username, password_hash, jabatan)
-	      VALUES ($1,$2,$3)
-	      RETURNING id::text, username, jabatan, created_at;`
+// kolom standar untuk scanUser (urutan harus sama)
+const userCols = `id::text, username, password_hash, jabatan, role, COALESCE(office,''),
+	is_active, deactivated_at, created_at, updated_at,
+	COALESCE(full_name,''), COALESCE(nip,''), COALESCE(email,''), COALESCE(phone,''),
+	COALESCE(department,''), hire_date, COALESCE(employment_type,''), contract_end_date,
+	(photo_base64 IS NOT NULL), COALESCE(manager_id::text,''), COALESCE(schedule_code,'')`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(s rowScanner) (models.User, error) {
 	var u models.User
-	err := r.DB.QueryRowContext(ctx, q, username, passHash, jabatan).
-		Scan(&u.ID, &u.Username, &u.Jabatan, &u.CreatedAt)
+	err := s.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Jabatan, &u.Role, &u.Office,
+		&u.IsActive, &u.DeactivatedAt, &u.CreatedAt, &u.UpdatedAt,
+		&u.FullName, &u.NIP, &u.Email, &u.Phone,
+		&u.Department, &u.HireDate, &u.EmploymentType, &u.ContractEndDate,
+		&u.HasPhoto, &u.ManagerID, &u.ScheduleCode)
 	return u, err
 }
 
+func (r *UserRepo) Create(ctx context.Context, username, passHash, jabatan string) (models.User, error) {
+	return r.Insert(ctx, models.User{
+		Username:     username,
+		PasswordHash: passHash,
+		Jabatan:      jabatan,
+		Role:         models.RoleEmployee,
+	})
+}
+
+// Insert: buat user baru lengkap dengan role, office & data HR (dipakai admin).
+func (r *UserRepo) Insert(ctx context.Context, in models.User) (models.User, error) {
+	return insertUser(ctx, r.DB, in)
+}
+
+// InsertWithPhoto: Insert + foto profil dalam satu transaksi.
+func (r *UserRepo) InsertWithPhoto(ctx context.Context, in models.User, photoB64 string) (models.User, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return models.User{}, err
+	}
+	defer tx.Rollback()
+
+	u, err := insertUser(ctx, tx, in)
+	if err != nil {
+		return models.User{}, err
+	}
+	if photoB64 != "" {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE users SET photo_base64 = $2 WHERE id = $1`, u.ID, photoB64); err != nil {
+			return models.User{}, err
+		}
+		u.HasPhoto = true
+	}
+	return u, tx.Commit()
+}
+
+// queryRower: *sql.DB atau *sql.Tx.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func insertUser(ctx context.Context, db queryRower, in models.User) (models.User, error) {
+	if in.Role == "" {
+		in.Role = models.RoleEmployee
+	}
+	q := `INSERT INTO users (username, password_hash, jabatan, role, office,
+	        full_name, nip, email, phone, department, hire_date, employment_type, contract_end_date,
+	        manager_id, schedule_code)
+	      VALUES ($1,$2,$3,$4,NULLIF($5,''),
+	        NULLIF($6,''),NULLIF($7,''),NULLIF($8,''),NULLIF($9,''),NULLIF($10,''),$11::date,NULLIF($12,''),$13::date,
+	        NULLIF($14,'')::uuid, NULLIF($15,''))
+	      RETURNING ` + userCols + `;`
+	return scanUser(db.QueryRowContext(ctx, q,
+		in.Username, in.PasswordHash, in.Jabatan, in.Role, in.Office,
+		in.FullName, in.NIP, in.Email, in.Phone, in.Department,
+		nullDate(in.HireDate), in.EmploymentType, nullDate(in.ContractEndDate),
+		in.ManagerID, in.ScheduleCode))
+}
+
+// nullDate: sql.NullTime → "yyyy-mm-dd" atau NULL.
+func nullDate(t sql.NullTime) any {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time.Format("2006-01-02")
+}
+
 func (r *UserRepo) GetByUsername(ctx context.Context, username string) (models.User, error) {
-	q := `SELECT id::text, username, password_hash, jabatan, created_at
-	      FROM users WHERE username=$1;`
-	var u models.User
-	err := r.DB.QueryRowContext(ctx, q, username).
-		Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Jabatan, &u.CreatedAt)
-	return u, err
+	q := `SELECT ` + userCols + ` FROM users WHERE username=$1;`
+	return scanUser(r.DB.QueryRowContext(ctx, q, username))
 }
 
 type RefreshRepo struct{ DB *sql.DB }
@@ -48,6 +124,13 @@ func (r *RefreshRepo) Revoke(ctx context.Context, token string) error {
 	return err
 }
 
+// RevokeAllForUser: cabut semua refresh token milik user (mis. saat dinonaktifkan).
+func (r *RefreshRepo) RevokeAllForUser(ctx context.Context, userID string) error {
+	_, err := r.DB.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked=TRUE WHERE user_id=$1 AND revoked=FALSE`, userID)
+	return err
+}
+
 func (r *RefreshRepo) IsValid(ctx context.Context, token string, now time.Time) (string, bool, error) {
 	var userID string
 	var revoked bool
@@ -68,9 +151,228 @@ func (r *RefreshRepo) IsValid(ctx context.Context, token string, now time.Time)
 }
 
 func (r *UserRepo) GetByID(ctx context.Context, id string) (models.User, error) {
-	q := `SELECT id::text, username, password_hash, jabatan, created_at FROM users WHERE id=$1;`
-	var u models.User
-	err := r.DB.QueryRowContext(ctx, q, id).
-		Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Jabatan, &u.CreatedAt)
-	return u, err
+	q := `SELECT ` + userCols + ` FROM users WHERE id=$1;`
+	return scanUser(r.DB.QueryRowContext(ctx, q, id))
+}
+
+// IsActive: status aktif user (sql.ErrNoRows kalau tidak ada). Dipanggil
+// per request, jadi tidak memuat kolom lain.
+func (r *UserRepo) IsActive(ctx context.Context, id string) (bool, error) {
+	var active bool
+	err := r.DB.QueryRowContext(ctx, `SELECT is_active FROM users WHERE id=$1`, id).Scan(&active)
+	return active, err
+}
+
+// UserFilter: filter + paginasi untuk daftar user (admin).
+type UserFilter struct {
+	Query   string // cari di username / nama / NIP (ILIKE)
+	Jabatan string
+	Office  string
+	Status  string // "active" | "inactive" | "all"/""
+	Limit   int    // 0 = tanpa batas (mis. untuk export)
+	Offset  int
+}
+
+// List: daftar user sesuai filter, plus total baris (sebelum paginasi).
+func (r *UserRepo) List(ctx context.Context, f UserFilter) ([]models.User, int, error) {
+	cond, args := f.where()
+
+	var total int
+	if err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`+cond, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	q := fmt.Sprintf(`SELECT %s FROM users%s ORDER BY username`, userCols, cond)
+	if f.Limit > 0 {
+		args = append(args, f.Limit, f.Offset)
+		q += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+	}
+	rows, err := r.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var out []models.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, u)
+	}
+	return out, total, rows.Err()
+}
+
+// ListWithManagers: seperti List (tanpa paginasi) dalam satu query, plus
+// username atasan tiap user (manager id → username) untuk export.
+func (r *UserRepo) ListWithManagers(ctx context.Context, f UserFilter) ([]models.User, map[string]string, error) {
+	cond, args := f.where()
+	rows, err := r.DB.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s, COALESCE((SELECT m.username FROM users m WHERE m.id = users.manager_id),'')
+		FROM users%s ORDER BY username`, userCols, cond), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var out []models.User
+	managers := map[string]string{}
+	for rows.Next() {
+		var mgr string
+		u, err := scanUser(extraScanner{rows, []any{&mgr}})
+		if err != nil {
+			return nil, nil, err
+		}
+		if u.ManagerID != "" {
+			managers[u.ManagerID] = mgr
+		}
+		out = append(out, u)
+	}
+	return out, managers, rows.Err()
+}
+
+// extraScanner: rowScanner untuk userCols + kolom tambahan di belakangnya.
+type extraScanner struct {
+	rows  rowScanner
+	extra []any
+}
+
+func (e extraScanner) Scan(dest ...any) error {
+	return e.rows.Scan(append(dest, e.extra...)...)
+}
+
+// where: klausa WHERE + argumen dari filter (tanpa paginasi).
+func (f UserFilter) where() (string, []any) {
+	var where []string
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Query != "" {
+		add("(username ILIKE $%[1]d OR full_name ILIKE $%[1]d OR nip ILIKE $%[1]d)", "%"+f.Query+"%")
+	}
+	if f.Jabatan != "" {
+		add("jabatan = $%d", f.Jabatan)
+	}
+	if f.Office != "" {
+		add("office = $%d", f.Office)
+	}
+	switch f.Status {
+	case "active":
+		where = append(where, "is_active = TRUE")
+	case "inactive":
+		where = append(where, "is_active = FALSE")
+	}
+	cond := ""
+	if len(where) > 0 {
+		cond = " WHERE " + strings.Join(where, " AND ")
+	}
+	return cond, args
+}
+
+// UserPatch: field yang boleh diubah; nil = tidak diubah.
+// Untuk field opsional (office, data HR), "" berarti dikosongkan.
+// Tanggal memakai format "yyyy-mm-dd".
+type UserPatch struct {
+	Jabatan      *string
+	Office       *string
+	Role         *string
+	PasswordHash *string
+
+	FullName        *string
+	NIP             *string
+	Email           *string
+	Phone           *string
+	Department      *string
+	HireDate        *string
+	EmploymentType  *string
+	ContractEndDate *string
+
+	ManagerID    *string
+	ScheduleCode *string
+
+	PhotoBase64 *string // foto profil (JPEG base64 ter-normalisasi); "" = hapus
+}
+
+// Update: ubah sebagian field user (termasuk foto, dalam satu statement). sql.ErrNoRows kalau id tidak ada.
+func (r *UserRepo) Update(ctx context.Context, id string, p UserPatch) (models.User, error) {
+	sets := []string{"updated_at = NOW()"}
+	args := []any{id}
+	set := func(col string, v *string, expr string) {
+		if v == nil {
+			return
+		}
+		args = append(args, *v)
+		sets = append(sets, fmt.Sprintf("%s = "+expr, col, len(args)))
+	}
+	const (
+		plain    = "$%d"
+		nullable = "NULLIF($%d,'')"
+		date     = "NULLIF($%d,'')::date"
+	)
+	set("jabatan", p.Jabatan, plain)
+	set("office", p.Office, nullable)
+	set("role", p.Role, plain)
+	set("password_hash", p.PasswordHash, plain)
+	set("full_name", p.FullName, nullable)
+	set("nip", p.NIP, nullable)
+	set("email", p.Email, nullable)
+	set("phone", p.Phone, nullable)
+	set("department", p.Department, nullable)
+	set("hire_date", p.HireDate, date)
+	set("employment_type", p.EmploymentType, nullable)
+	set("contract_end_date", p.ContractEndDate, date)
+	set("manager_id", p.ManagerID, "NULLIF($%d,'')::uuid")
+	set("schedule_code", p.ScheduleCode, nullable)
+	set("photo_base64", p.PhotoBase64, nullable)
+
+	q := `UPDATE users SET ` + strings.Join(sets, ", ") + `
+	      WHERE id = $1
+	      RETURNING ` + userCols + `;`
+	return scanUser(r.DB.QueryRowContext(ctx, q, args...))
+}
+
+// SetActive: aktifkan / nonaktifkan user. sql.ErrNoRows kalau id tidak ada.
+func (r *UserRepo) SetActive(ctx context.Context, id string, active bool) (models.User, error) {
+	q := `UPDATE users SET
+	        is_active      = $2,
+	        deactivated_at = CASE WHEN $2 THEN NULL ELSE COALESCE(deactivated_at, NOW()) END,
+	        updated_at     = NOW()
+	      WHERE id = $1
+	      RETURNING ` + userCols + `;`
+	return scanUser(r.DB.QueryRowContext(ctx, q, id, active))
+}
+
+// GetPhoto: foto profil (base64 JPEG), "" kalau belum ada.
+func (r *UserRepo) GetPhoto(ctx context.Context, id string) (string, error) {
+	var b64 sql.NullString
+	err := r.DB.QueryRowContext(ctx,
+		`SELECT photo_base64 FROM users WHERE id=$1`, id).Scan(&b64)
+	return b64.String, err
+}
+
+// HomeLocation: lokasi rumah untuk WFH (Valid=false kalau belum didaftarkan).
+type HomeLocation struct {
+	Lat, Lng sql.NullFloat64
+	SetAt    sql.NullTime
+}
+
+func (r *UserRepo) GetHomeLocation(ctx context.Context, id string) (HomeLocation, error) {
+	var h HomeLocation
+	err := r.DB.QueryRowContext(ctx,
+		`SELECT home_lat, home_lng, home_set_at FROM users WHERE id=$1`, id).
+		Scan(&h.Lat, &h.Lng, &h.SetAt)
+	return h, err
+}
+
+func (r *UserRepo) SetHomeLocation(ctx context.Context, id string, lat, lng float64) (HomeLocation, error) {
+	var h HomeLocation
+	err := r.DB.QueryRowContext(ctx, `
+		UPDATE users SET home_lat=$2, home_lng=$3, home_set_at=NOW(), updated_at=NOW()
+		WHERE id=$1
+		RETURNING home_lat, home_lng, home_set_at`, id, lat, lng).
+		Scan(&h.Lat, &h.Lng, &h.SetAt)
+	return h, err
 }
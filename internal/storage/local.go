@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Local: simpan blob di filesystem. File dilayani oleh Local.ServeHTTP
+// (di-mount di /files/) dengan signature HMAC pada key + waktu kedaluwarsa.
+type Local struct {
+	Root    string
+	BaseURL string // prefix URL publik, mis. "/files" atau "https://api.example.com/files"
+	key     []byte
+}
+
+func NewLocal(root, baseURL string, signingKey []byte) (*Local, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("storage: create dir: %w", err)
+	}
+	return &Local{Root: root, BaseURL: baseURL, key: signingKey}, nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", fmt.Errorf("storage: invalid key %q", key)
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(key)), nil
+}
+
+func (l *Local) Put(_ context.Context, key string, data []byte, _ string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+	// tulis ke file sementara lalu rename → tidak ada file setengah jadi
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (l *Local) Get(_ context.Context, key string) ([]byte, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return b, err
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) SignedURL(_ context.Context, key string, ttl time.Duration) (string, error) {
+	if !validKey(key) {
+		return "", fmt.Errorf("storage: invalid key %q", key)
+	}
+	exp := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	q := url.Values{"exp": {exp}, "sig": {l.sign(key, exp)}}
+	return l.BaseURL + "/" + (&url.URL{Path: key}).EscapedPath() + "?" + q.Encode(), nil
+}
+
+func (l *Local) sign(key, exp string) string {
+	m := hmac.New(sha256.New, l.key)
+	m.Write([]byte(key + "\n" + exp))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// ServeHTTP: r.URL.Path = key (pasang dengan http.StripPrefix).
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Path
+	exp := r.URL.Query().Get("exp")
+	sig := r.URL.Query().Get("sig")
+
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || !hmac.Equal([]byte(sig), []byte(l.sign(key, exp))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+	if time.Now().Unix() > expUnix {
+		http.Error(w, "url expired", http.StatusForbidden)
+		return
+	}
+
+	b, err := l.Get(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "storage error", http.StatusInternalServerError)
+		return
+	}
+	ct := mime.TypeByExtension(filepath.Ext(key))
+	if ct == "" {
+		ct = http.DetectContentType(b)
+	}
+	w.Header().Set("Content-Type", ct)
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(max(expUnix-time.Now().Unix(), 0), 10))
+	_, _ = w.Write(b)
+}
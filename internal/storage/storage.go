@@ -0,0 +1,117 @@
+// Package storage: penyimpanan blob (foto absensi, bukti sakit) di luar
+// Postgres. DB hanya menyimpan object key; klien mengambil file lewat
+// signed URL yang berumur pendek.
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("storage: object not found")
+
+// Store: backend blob. Key memakai "/" sebagai pemisah, tanpa "/" di depan.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL: URL baca yang berlaku selama ttl.
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// FromEnv: pilih backend dari STORAGE_BACKEND (local | s3, default local).
+func FromEnv() (Store, error) {
+	switch b := env("STORAGE_BACKEND", "local"); b {
+	case "local":
+		return NewLocal(env("STORAGE_DIR", "data/blobs"), env("STORAGE_PUBLIC_URL", "/files"), signingKey())
+	case "s3":
+		pathStyle, _ := strconv.ParseBool(os.Getenv("S3_PATH_STYLE"))
+		return NewS3(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    env("S3_REGION", "us-east-1"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: pathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("storage: unknown STORAGE_BACKEND %q", b)
+	}
+}
+
+// URLTTL: umur signed URL (STORAGE_URL_TTL_SEC, default 300 detik).
+func URLTTL() time.Duration {
+	if n, err := strconv.Atoi(os.Getenv("STORAGE_URL_TTL_SEC")); err == nil && n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return 5 * time.Minute
+}
+
+// NewKey: key unik "<prefix>/<random>.<ext>".
+func NewKey(prefix, ext string) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + hex.EncodeToString(b) + "." + ext, nil
+}
+
+// ExtFor: ekstensi file untuk content type yang kita terima.
+func ExtFor(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return "jpg"
+	case "image/png":
+		return "png"
+	case "application/pdf":
+		return "pdf"
+	}
+	return "bin"
+}
+
+func signingKey() []byte {
+	if k := os.Getenv("STORAGE_SIGNING_KEY"); k != "" {
+		return []byte(k)
+	}
+	if k := os.Getenv("JWT_SECRET"); k != "" {
+		return []byte(k)
+	}
+	return []byte("dev-secret") // ganti di produksi
+}
+
+func env(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// VariantKey: key varian foto, mis. "a/b/c.jpg" + "thumb" → "a/b/c_thumb.jpg".
+// Size "" / "full" mengembalikan key asli.
+func VariantKey(key, size string) string {
+	if size == "" || size == "full" {
+		return key
+	}
+	ext := path.Ext(key)
+	return strings.TrimSuffix(key, ext) + "_" + size + ext
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"attendance/2025/08/01/u1/check_in_abc.jpg", true},
+		{"faces/u1.jpg", true},
+		{"a.b/c..d.jpg", true},
+		{"", false},
+		{"/etc/passwd", false},
+		{"../secret", false},
+		{"a/../../secret", false},
+		{"a/./b.jpg", false},
+		{"a//b.jpg", false},
+		{"a/b/", false},
+		{`a\..\b.jpg`, false},
+	}
+	for _, tt := range tests {
+		if got := validKey(tt.key); got != tt.want {
+			t.Errorf("validKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestVariantKey(t *testing.T) {
+	tests := []struct{ key, size, want string }{
+		{"a/b/c.jpg", "thumb", "a/b/c_thumb.jpg"},
+		{"a/b/c.jpg", "", "a/b/c.jpg"},
+		{"a/b/c.jpg", "full", "a/b/c.jpg"},
+		{"a/b/c", "medium", "a/b/c_medium"},
+	}
+	for _, tt := range tests {
+		if got := VariantKey(tt.key, tt.size); got != tt.want {
+			t.Errorf("VariantKey(%q, %q) = %q, want %q", tt.key, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestLocalSignedURL(t *testing.T) {
+	l, err := NewLocal(t.TempDir(), "/files", []byte("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := l.Put(ctx, "a/b.jpg", []byte("jpeg"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Put(ctx, "../b.jpg", []byte("x"), "image/jpeg"); err == nil {
+		t.Fatal("Put with traversal key: want error")
+	}
+
+	signed, err := l.SignedURL(ctx, "a/b.jpg", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _ := l.SignedURL(ctx, "a/b.jpg", -time.Minute)
+	other, _ := l.SignedURL(ctx, "a/c.jpg", time.Minute)
+
+	tests := []struct {
+		name string
+		url  string
+		want int
+	}{
+		{"valid", signed, http.StatusOK},
+		{"expired", expired, http.StatusForbidden},
+		{"signature for another key", strings.Replace(other, "a/c.jpg", "a/b.jpg", 1), http.StatusForbidden},
+		{"missing signature", "/files/a/b.jpg", http.StatusForbidden},
+	}
+	h := http.StripPrefix("/files/", l)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u.String(), nil))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path"
+	"time"
+
+	"absensi/internal/util/imgutil"
+)
+
+// hasVariants: hanya foto JPEG (hasil imgutil) yang punya varian; PDF dll tidak.
+func hasVariants(key string) bool { return path.Ext(key) == ".jpg" }
+
+// PutImage: simpan JPEG ter-normalisasi beserta varian thumb & medium.
+// Kalau salah satu gagal, yang sudah tertulis dihapus lagi.
+func PutImage(ctx context.Context, s Store, key string, jpg []byte) error {
+	variants, err := imgutil.Variants(jpg)
+	if err != nil {
+		return err
+	}
+	if err := s.Put(ctx, key, jpg, "image/jpeg"); err != nil {
+		return err
+	}
+	written := []string{key}
+	for size, b := range variants {
+		vk := VariantKey(key, size)
+		if err := s.Put(ctx, vk, b, "image/jpeg"); err != nil {
+			for _, k := range written {
+				_ = s.Delete(ctx, k)
+			}
+			return err
+		}
+		written = append(written, vk)
+	}
+	return nil
+}
+
+// DeleteImage: hapus foto beserta variannya.
+func DeleteImage(ctx context.Context, s Store, key string) error {
+	if hasVariants(key) {
+		for _, size := range imgutil.VariantSizes {
+			if err := s.Delete(ctx, VariantKey(key, size)); err != nil {
+				return err
+			}
+		}
+	}
+	return s.Delete(ctx, key)
+}
+
+// ImageURL: signed URL untuk varian size; file tanpa varian → key asli.
+func ImageURL(ctx context.Context, s Store, key, size string, ttl time.Duration) (string, error) {
+	if !hasVariants(key) {
+		size = imgutil.SizeFull
+	}
+	return s.SignedURL(ctx, VariantKey(key, size), ttl)
+}
+
+// EnsureVariant: buat varian dari foto full kalau belum ada (data lama dari
+// sebelum varian dibuat saat upload), lalu kembalikan key-nya.
+func EnsureVariant(ctx context.Context, s Store, key, size string) (string, error) {
+	if !hasVariants(key) || size == imgutil.SizeFull {
+		return key, nil
+	}
+	vk := VariantKey(key, size)
+	if _, err := s.Get(ctx, vk); err == nil {
+		return vk, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return "", err
+	}
+
+	full, err := s.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	b, err := imgutil.Variant(full, size)
+	if err != nil {
+		return "", err
+	}
+	if err := s.Put(ctx, vk, b, "image/jpeg"); err != nil {
+		return "", err
+	}
+	return vk, nil
+}
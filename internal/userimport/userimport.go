@@ -0,0 +1,364 @@
+// Package userimport: import/export data pegawai dari/ke CSV atau XLSX.
+// Dipakai oleh endpoint admin dan CLI cmd/userimport.
+package userimport
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"absensi/internal/models"
+	"absensi/internal/repo"
+	"absensi/internal/util"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Columns: urutan kolom file import/export. Header wajib punya "username";
+// kolom lain opsional, sel kosong = tidak diubah.
+var Columns = []string{
+	"username", "password", "full_name", "nip", "email", "phone",
+	"jabatan", "department", "office", "role", "employment_type",
+	"hire_date", "contract_end_date", "manager", "schedule", "active",
+}
+
+// FormatFromName: tebak format dari nama file / content type.
+func FormatFromName(name string) string {
+	low := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(low, ".xlsx"), strings.Contains(low, "spreadsheetml"):
+		return FormatXLSX
+	case strings.HasSuffix(low, ".csv"), strings.Contains(low, "csv"):
+		return FormatCSV
+	}
+	return strings.TrimPrefix(filepath.Ext(low), ".")
+}
+
+// Record: satu baris mentah (kolom → nilai), Line = nomor baris di file.
+type Record struct {
+	Line   int
+	Fields map[string]string
+}
+
+// Parse: baca file CSV / XLSX (sheet pertama) menjadi record.
+func Parse(r io.Reader, format string) ([]Record, error) {
+	var table [][]string
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.TrimLeadingSpace = true
+		rows, err := cr.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+		table = rows
+	case FormatXLSX:
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("read xlsx: %w", err)
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("xlsx has no sheet")
+		}
+		if table, err = f.GetRows(sheets[0]); err != nil {
+			return nil, fmt.Errorf("read xlsx: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q (csv|xlsx)", format)
+	}
+
+	if len(table) == 0 {
+		return nil, errors.New("empty file")
+	}
+
+	known := map[string]bool{}
+	for _, c := range Columns {
+		known[c] = true
+	}
+	header := make([]string, len(table[0]))
+	hasUsername := false
+	for i, h := range table[0] {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if h != "" && !known[h] {
+			return nil, fmt.Errorf("unknown column %q", h)
+		}
+		header[i] = h
+		hasUsername = hasUsername || h == "username"
+	}
+	if !hasUsername {
+		return nil, errors.New("missing column \"username\"")
+	}
+
+	var out []Record
+	for i, row := range table[1:] {
+		rec := Record{Line: i + 2, Fields: map[string]string{}}
+		empty := true
+		for j, v := range row {
+			if j >= len(header) || header[j] == "" {
+				continue
+			}
+			v = strings.TrimSpace(v)
+			if format == FormatCSV {
+				v = unescapeFormula(v)
+			}
+			if v != "" {
+				empty = false
+			}
+			rec.Fields[header[j]] = v
+		}
+		if !empty {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+func parseBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "1", "true", "yes", "ya", "aktif", "active":
+		return true, true
+	case "0", "false", "no", "tidak", "nonaktif", "inactive":
+		return false, true
+	}
+	return false, false
+}
+
+// Build: validasi field tiap record → repo.ImportUser. Baris yang gagal
+// validasi dikembalikan sebagai outcome "error". Kalau hashPasswords=false
+// (dry-run), password cukup ditandai ada tanpa di-bcrypt.
+func Build(recs []Record, hashPasswords bool) ([]repo.ImportUser, []repo.ImportOutcome) {
+	var rows []repo.ImportUser
+	var bad []repo.ImportOutcome
+	seen := map[string]int{}
+
+	for _, rec := range recs {
+		f := rec.Fields
+		var errs []string
+		fail := func(msg string) { errs = append(errs, msg) }
+
+		u := models.User{
+			Username:       f["username"],
+			FullName:       f["full_name"],
+			NIP:            f["nip"],
+			Email:          f["email"],
+			Phone:          f["phone"],
+			Jabatan:        f["jabatan"],
+			Department:     f["department"],
+			Office:         f["office"],
+			Role:           f["role"],
+			EmploymentType: f["employment_type"],
+			ScheduleCode:   f["schedule"],
+		}
+
+		if len(u.Username) < 3 {
+			fail("username must be at least 3 characters")
+		} else if prev, dup := seen[u.Username]; dup {
+			fail(fmt.Sprintf("duplicate username (also on line %d)", prev))
+		} else {
+			seen[u.Username] = rec.Line
+		}
+		if u.NIP != "" && !util.ValidNIP(u.NIP) {
+			fail("invalid nip")
+		}
+		if u.Email != "" && !util.ValidEmail(u.Email) {
+			fail("invalid email")
+		}
+		if u.Phone != "" && !util.ValidPhone(u.Phone) {
+			fail("invalid phone")
+		}
+		if u.Role != "" && u.Role != models.RoleEmployee && u.Role != models.RoleAdmin {
+			fail("invalid role")
+		}
+		if u.EmploymentType != "" && !models.ValidEmploymentType(u.EmploymentType) {
+			fail("invalid employment_type")
+		}
+		for col, dst := range map[string]*sql.NullTime{"hire_date": &u.HireDate, "contract_end_date": &u.ContractEndDate} {
+			if v := f[col]; v != "" {
+				t, err := time.Parse("2006-01-02", v)
+				if err != nil {
+					fail("invalid " + col + " (yyyy-mm-dd)")
+					continue
+				}
+				*dst = sql.NullTime{Time: t, Valid: true}
+			}
+		}
+		if u.EmploymentType == models.EmploymentTetap && u.ContractEndDate.Valid {
+			fail("contract_end_date not allowed for tetap")
+		}
+		if u.HireDate.Valid && u.ContractEndDate.Valid && u.ContractEndDate.Time.Before(u.HireDate.Time) {
+			fail("contract_end_date before hire_date")
+		}
+
+		row := repo.ImportUser{Line: rec.Line, User: u, ManagerRef: f["manager"]}
+		if row.ManagerRef != "" && row.ManagerRef == u.Username {
+			fail("user cannot be their own manager")
+		}
+		if v := f["active"]; v != "" {
+			b, ok := parseBool(v)
+			if !ok {
+				fail("invalid active (true/false)")
+			}
+			row.Active = &b
+		}
+		if pw := f["password"]; pw != "" {
+			switch {
+			case len(pw) < 6:
+				fail("password must be at least 6 characters")
+			case !hashPasswords:
+				row.PasswordHash = "-" // dry-run: cukup tandai ada
+			default:
+				hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+				if err != nil {
+					fail("hash password failed")
+				}
+				row.PasswordHash = string(hash)
+			}
+		}
+
+		if len(errs) > 0 {
+			bad = append(bad, repo.ImportOutcome{Line: rec.Line, Username: u.Username, Action: "error", Errors: errs})
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, bad
+}
+
+// Summary: rekap hasil import.
+type Summary struct {
+	Total   int `json:"total"`
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Errors  int `json:"errors"`
+}
+
+// Result: hasil lengkap import.
+type Result struct {
+	DryRun    bool                 `json:"dry_run"`
+	Committed bool                 `json:"committed"`
+	Summary   Summary              `json:"summary"`
+	Rows      []repo.ImportOutcome `json:"rows"`
+}
+
+// Run: validasi + upsert. Kalau ada baris yang gagal validasi, baris lain
+// tetap dicek ke DB (mode dry-run) supaya semua error terlihat sekaligus,
+// tapi tidak ada yang disimpan.
+func Run(ctx context.Context, users *repo.UserRepo, recs []Record, dryRun bool) (Result, error) {
+	rows, bad := Build(recs, !dryRun)
+
+	res := Result{DryRun: dryRun}
+	outcomes, committed, err := users.Import(ctx, rows, dryRun || len(bad) > 0)
+	if err != nil {
+		return Result{}, err
+	}
+	res.Committed = committed
+	res.Rows = append(outcomes, bad...)
+	sort.Slice(res.Rows, func(i, j int) bool { return res.Rows[i].Line < res.Rows[j].Line })
+
+	res.Summary.Total = len(res.Rows)
+	for _, o := range res.Rows {
+		switch o.Action {
+		case "create":
+			res.Summary.Created++
+		case "update":
+			res.Summary.Updated++
+		default:
+			res.Summary.Errors++
+		}
+	}
+	return res, nil
+}
+
+// formulaPrefix: awalan sel yang dieksekusi sebagai formula oleh Excel /
+// LibreOffice saat CSV dibuka (CSV injection).
+const formulaPrefix = "=+-@\t\r"
+
+// escapeFormula: sel CSV yang diawali formulaPrefix diberi awalan "'"
+// supaya dibaca sebagai teks. XLSX tidak perlu: sel ditulis sebagai string.
+func escapeFormula(v string) string {
+	if v != "" && strings.ContainsRune(formulaPrefix, rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// unescapeFormula: kebalikan escapeFormula untuk file hasil export.
+func unescapeFormula(v string) string {
+	if len(v) > 1 && v[0] == '\'' && strings.ContainsRune(formulaPrefix, rune(v[1])) {
+		return v[1:]
+	}
+	return v
+}
+
+// Export: tulis user dengan kolom yang sama seperti import (password selalu
+// kosong). usernames: id → username untuk kolom manager; nil = ambil dari users.
+func Export(w io.Writer, format string, users []models.User, usernames map[string]string) error {
+	if usernames == nil {
+		usernames = make(map[string]string, len(users))
+		for _, u := range users {
+			usernames[u.ID] = u.Username
+		}
+	}
+	date := func(nt sql.NullTime) string {
+		if nt.Valid {
+			return nt.Time.Format("2006-01-02")
+		}
+		return ""
+	}
+
+	table := [][]string{Columns}
+	for _, u := range users {
+		table = append(table, []string{
+			u.Username, "", u.FullName, u.NIP, u.Email, u.Phone,
+			u.Jabatan, u.Department, u.Office, u.Role, u.EmploymentType,
+			date(u.HireDate), date(u.ContractEndDate), usernames[u.ManagerID], u.ScheduleCode,
+			fmt.Sprint(u.IsActive),
+		})
+	}
+
+	switch format {
+	case FormatCSV:
+		for _, row := range table[1:] {
+			for j, v := range row {
+				row[j] = escapeFormula(v)
+			}
+		}
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(table); err != nil {
+			return err
+		}
+		return cw.Error()
+	case FormatXLSX:
+		f := excelize.NewFile()
+		defer f.Close()
+		sheet := f.GetSheetName(0)
+		for i, row := range table {
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			vals := make([]any, len(row))
+			for j, v := range row {
+				vals[j] = v
+			}
+			if err := f.SetSheetRow(sheet, cell, &vals); err != nil {
+				return err
+			}
+		}
+		return f.Write(w)
+	}
+	return fmt.Errorf("unsupported format %q (csv|xlsx)", format)
+}
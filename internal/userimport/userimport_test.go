@@ -0,0 +1,132 @@
+package userimport
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"absensi/internal/models"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []map[string]string
+		wantErr string
+	}{
+		{
+			name: "header case and bom, blank rows skipped",
+			in:   "\ufeffUsername, Full_Name\nbudi, Budi S\n,\nani,Ani\n",
+			want: []map[string]string{
+				{"username": "budi", "full_name": "Budi S"},
+				{"username": "ani", "full_name": "Ani"},
+			},
+		},
+		{
+			name: "escaped formula cells restored",
+			in:   "username,phone,department\nbudi,'+62811,'=SUM(A1)\n",
+			want: []map[string]string{
+				{"username": "budi", "phone": "+62811", "department": "=SUM(A1)"},
+			},
+		},
+		{
+			name: "plain apostrophe kept",
+			in:   "username,full_name\nbudi,'Budi'\n",
+			want: []map[string]string{{"username": "budi", "full_name": "'Budi'"}},
+		},
+		{name: "unknown column", in: "username,salary\nbudi,1\n", wantErr: `unknown column "salary"`},
+		{name: "missing username", in: "full_name\nBudi\n", wantErr: `missing column "username"`},
+		{name: "empty file", in: "", wantErr: "empty file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs, err := Parse(strings.NewReader(tt.in), FormatCSV)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recs) != len(tt.want) {
+				t.Fatalf("got %d records, want %d", len(recs), len(tt.want))
+			}
+			for i, rec := range recs {
+				for k, v := range tt.want[i] {
+					if rec.Fields[k] != v {
+						t.Errorf("record %d %s = %q, want %q", i, k, rec.Fields[k], v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]string
+		errs   []string // kosong = valid
+	}{
+		{"valid", map[string]string{"username": "budi", "role": "employee", "active": "ya", "hire_date": "2024-01-02"}, nil},
+		{"short username", map[string]string{"username": "bu"}, []string{"username must be at least 3 characters"}},
+		{"invalid role", map[string]string{"username": "budi", "role": "root"}, []string{"invalid role"}},
+		{"bad date", map[string]string{"username": "budi", "hire_date": "02/01/2024"}, []string{"invalid hire_date (yyyy-mm-dd)"}},
+		{"contract end for tetap", map[string]string{"username": "budi", "employment_type": "tetap", "contract_end_date": "2025-01-01"},
+			[]string{"contract_end_date not allowed for tetap"}},
+		{"contract end before hire", map[string]string{"username": "budi", "hire_date": "2025-01-01", "contract_end_date": "2024-01-01"},
+			[]string{"contract_end_date before hire_date"}},
+		{"own manager", map[string]string{"username": "budi", "manager": "budi"}, []string{"user cannot be their own manager"}},
+		{"invalid active", map[string]string{"username": "budi", "active": "maybe"}, []string{"invalid active (true/false)"}},
+		{"short password", map[string]string{"username": "budi", "password": "123"}, []string{"password must be at least 6 characters"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, bad := Build([]Record{{Line: 2, Fields: tt.fields}}, false)
+			if len(tt.errs) == 0 {
+				if len(bad) != 0 || len(rows) != 1 {
+					t.Fatalf("rows=%d bad=%v, want valid", len(rows), bad)
+				}
+				return
+			}
+			if len(bad) != 1 {
+				t.Fatalf("bad = %v, want 1 error row", bad)
+			}
+			if got := strings.Join(bad[0].Errors, "; "); got != strings.Join(tt.errs, "; ") {
+				t.Fatalf("errors = %q, want %q", got, tt.errs)
+			}
+		})
+	}
+
+	rows, bad := Build([]Record{
+		{Line: 2, Fields: map[string]string{"username": "budi"}},
+		{Line: 3, Fields: map[string]string{"username": "budi"}},
+	}, false)
+	if len(rows) != 1 || len(bad) != 1 || bad[0].Errors[0] != "duplicate username (also on line 2)" {
+		t.Fatalf("duplicate: rows=%d bad=%v", len(rows), bad)
+	}
+}
+
+func TestExportEscapesFormulas(t *testing.T) {
+	users := []models.User{{ID: "1", Username: "budi", Phone: "+62811", Department: "=HYPERLINK(\"x\")", FullName: "@admin"}}
+	var buf bytes.Buffer
+	if err := Export(&buf, FormatCSV, users, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"'+62811", `"'=HYPERLINK(""x"")"`, "'@admin"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("export missing %s:\n%s", want, buf.String())
+		}
+	}
+
+	recs, err := Parse(&buf, FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := recs[0].Fields; f["phone"] != "+62811" || f["full_name"] != "@admin" {
+		t.Fatalf("round trip = %v", f)
+	}
+}
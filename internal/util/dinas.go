@@ -0,0 +1,15 @@
+package util
+
+import "strconv"
+
+// Radius geofence tujuan dinas kalau pengajuan tidak menentukan sendiri
+// (DINAS_RADIUS_M, default 500 m), dan batas atasnya.
+const DinasMaxRadiusM = 50000
+
+func DinasRadiusM() float64 {
+	v, err := strconv.ParseFloat(mustEnv("DINAS_RADIUS_M", "500"), 64)
+	if err != nil || v <= 0 || v > DinasMaxRadiusM {
+		v = 500
+	}
+	return v
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"strconv"
+	"time"
+)
+
+// IdempotencyTTL: lama response disimpan untuk retry dengan Idempotency-Key
+// yang sama (IDEMPOTENCY_TTL_HOURS, default 24 jam).
+func IdempotencyTTL() time.Duration {
+	h, err := strconv.Atoi(mustEnv("IDEMPOTENCY_TTL_HOURS", "24"))
+	if err != nil || h <= 0 {
+		h = 24
+	}
+	return time.Duration(h) * time.Hour
+}
+
+// IdempotencyLease: lama key dianggap "sedang diproses" sebelum boleh diambil
+// alih retry (IDEMPOTENCY_LEASE_SECONDS, default 60 detik).
+func IdempotencyLease() time.Duration {
+	n, err := strconv.Atoi(mustEnv("IDEMPOTENCY_LEASE_SECONDS", "60"))
+	if err != nil || n <= 0 {
+		n = 60
+	}
+	return time.Duration(n) * time.Second
+}
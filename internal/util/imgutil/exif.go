@@ -0,0 +1,188 @@
+package imgutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"strings"
+	"time"
+
+	"github.com/disintegration/imaging"
+)
+
+// Meta: metadata kamera dari EXIF foto asli (sebelum di-encode ulang).
+type Meta struct {
+	CaptureTime time.Time // zero kalau tidak ada
+	HasOffset   bool      // CaptureTime punya zona waktu dari EXIF (OffsetTimeOriginal)
+	Make        string
+	Model       string
+	Software    string
+	Orientation int // 1..8, 0 = tidak ada
+}
+
+func (m Meta) Empty() bool {
+	return m.CaptureTime.IsZero() && m.Make == "" && m.Model == "" && m.Software == "" && m.Orientation == 0
+}
+
+const (
+	tagMake            = 0x010F
+	tagModel           = 0x0110
+	tagOrientation     = 0x0112
+	tagSoftware        = 0x0131
+	tagDateTime        = 0x0132
+	tagExifIFD         = 0x8769
+	tagDateTimeOrig    = 0x9003
+	tagOffsetTimeOrig  = 0x9011
+	exifTimeLayout     = "2006:01:02 15:04:05"
+	maxExifHeaderBytes = 128 << 10 // APP1 maks 64KB, plus segmen sebelumnya
+)
+
+// ParseExif: baca EXIF dari awal file JPEG. Bukan JPEG / tanpa EXIF → Meta kosong.
+// loc dipakai untuk waktu tanpa OffsetTimeOriginal (kebanyakan kamera HP).
+func ParseExif(head []byte, loc *time.Location) Meta {
+	tiff := findExifTIFF(head)
+	if tiff == nil {
+		return Meta{}
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return Meta{}
+	}
+	if order.Uint16(tiff[2:4]) != 42 {
+		return Meta{}
+	}
+
+	var m Meta
+	var dateTime, dateOrig, offsetOrig string
+	exifOff := uint32(0)
+	readIFD(tiff, order, order.Uint32(tiff[4:8]), func(tag, typ uint16, count uint32, val []byte) {
+		switch tag {
+		case tagMake:
+			m.Make = exifString(tiff, order, typ, count, val)
+		case tagModel:
+			m.Model = exifString(tiff, order, typ, count, val)
+		case tagSoftware:
+			m.Software = exifString(tiff, order, typ, count, val)
+		case tagDateTime:
+			dateTime = exifString(tiff, order, typ, count, val)
+		case tagOrientation:
+			if typ == 3 {
+				m.Orientation = int(order.Uint16(val))
+			}
+		case tagExifIFD:
+			exifOff = order.Uint32(val)
+		}
+	})
+	if exifOff > 0 {
+		readIFD(tiff, order, exifOff, func(tag, typ uint16, count uint32, val []byte) {
+			switch tag {
+			case tagDateTimeOrig:
+				dateOrig = exifString(tiff, order, typ, count, val)
+			case tagOffsetTimeOrig:
+				offsetOrig = exifString(tiff, order, typ, count, val)
+			}
+		})
+	}
+	if m.Orientation < 1 || m.Orientation > 8 {
+		m.Orientation = 0
+	}
+
+	ts := dateOrig
+	if ts == "" {
+		ts = dateTime
+	}
+	if ts != "" {
+		if offsetOrig != "" {
+			if t, err := time.Parse(exifTimeLayout+"-07:00", ts+offsetOrig); err == nil {
+				m.CaptureTime, m.HasOffset = t, true
+			}
+		}
+		if m.CaptureTime.IsZero() {
+			if t, err := time.ParseInLocation(exifTimeLayout, ts, loc); err == nil {
+				m.CaptureTime = t
+			}
+		}
+	}
+	return m
+}
+
+// findExifTIFF: isi TIFF dari segmen APP1 "Exif\0\0".
+func findExifTIFF(b []byte) []byte {
+	if len(b) < 4 || b[0] != 0xFF || b[1] != 0xD8 {
+		return nil
+	}
+	i := 2
+	for i+4 <= len(b) {
+		if b[i] != 0xFF {
+			return nil
+		}
+		marker := b[i+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan / end
+			return nil
+		}
+		n := int(binary.BigEndian.Uint16(b[i+2 : i+4]))
+		if n < 2 || i+2+n > len(b) {
+			return nil
+		}
+		seg := b[i+4 : i+2+n]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) && len(seg) >= 14 {
+			return seg[6:]
+		}
+		i += 2 + n
+	}
+	return nil
+}
+
+func readIFD(tiff []byte, order binary.ByteOrder, off uint32, fn func(tag, typ uint16, count uint32, val []byte)) {
+	if int(off)+2 > len(tiff) {
+		return
+	}
+	n := int(order.Uint16(tiff[off:]))
+	p := int(off) + 2
+	for i := 0; i < n && p+12 <= len(tiff); i, p = i+1, p+12 {
+		fn(order.Uint16(tiff[p:]), order.Uint16(tiff[p+2:]), order.Uint32(tiff[p+4:]), tiff[p+8:p+12])
+	}
+}
+
+func exifString(tiff []byte, order binary.ByteOrder, typ uint16, count uint32, val []byte) string {
+	if typ != 2 || count == 0 || count > 256 {
+		return ""
+	}
+	var raw []byte
+	if count <= 4 {
+		raw = val[:count]
+	} else {
+		off := order.Uint32(val)
+		if uint64(off)+uint64(count) > uint64(len(tiff)) {
+			return ""
+		}
+		raw = tiff[off : off+count]
+	}
+	return strings.TrimSpace(strings.TrimRight(string(raw), "\x00"))
+}
+
+// orient: putar / balik gambar sesuai tag Orientation EXIF.
+func orient(img image.Image, o int) image.Image {
+	switch o {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	}
+	return img
+}
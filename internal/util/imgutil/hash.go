@@ -0,0 +1,37 @@
+package imgutil
+
+import (
+	"image"
+	"math/bits"
+
+	"github.com/disintegration/imaging"
+)
+
+// DHash: difference hash 64-bit. Gambar dikecilkan ke 9x8 grayscale lalu
+// tiap bit = piksel kiri lebih terang dari kanannya. Tahan resize, kompresi
+// ulang, dan perubahan cahaya ringan; foto yang sama → jarak Hamming kecil.
+func DHash(img image.Image) uint64 {
+	g := imaging.Resize(imaging.Grayscale(img), 9, 8, imaging.Box)
+	var h uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			h <<= 1
+			if g.Pix[y*g.Stride+x*4] > g.Pix[y*g.Stride+(x+1)*4] {
+				h |= 1
+			}
+		}
+	}
+	return h
+}
+
+// DHashJPEG: DHash dari byte JPEG/PNG.
+func DHashJPEG(jpg []byte) (uint64, error) {
+	img, err := Decode(jpg)
+	if err != nil {
+		return 0, err
+	}
+	return DHash(img), nil
+}
+
+// HammingDistance: jumlah bit berbeda antara dua hash.
+func HammingDistance(a, b uint64) int { return bits.OnesCount64(a ^ b) }
@@ -1,13 +1,18 @@
 package imgutil
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"image"
 	"image/jpeg"
 	_ "image/png" // enable PNG decode
+	"io"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/disintegration/imaging"
 )
@@ -17,32 +22,142 @@ const maxSide = 1080
 // NormalizeBase64 resizes the image to max 1080px (keeping ratio) and
 // re-encodes as JPEG quality 85, returning clean base64 (tanpa data URL prefix).
 func NormalizeBase64(in string) (string, error) {
+	b, err := NormalizeJPEG(in)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// NormalizeJPEG: sama seperti NormalizeBase64 tapi mengembalikan byte JPEG
+// (untuk disimpan ke blob store).
+func NormalizeJPEG(in string) ([]byte, error) {
+	raw, err := DecodeRaw(in)
+	if err != nil {
+		return nil, err
+	}
+	return NormalizeReader(bytes.NewReader(raw))
+}
+
+// NormalizeReader: pipeline yang sama, langsung dari stream (upload
+// multipart) tanpa menampung file asli di memori.
+func NormalizeReader(r io.Reader) ([]byte, error) {
+	b, _, err := NormalizeReaderMeta(r, time.UTC)
+	return b, err
+}
+
+// NormalizeReaderMeta: NormalizeReader + metadata EXIF foto asli (yang
+// hilang setelah encode ulang). Gambar diputar sesuai Orientation EXIF.
+// loc: zona waktu untuk capture time EXIF tanpa offset.
+func NormalizeReaderMeta(r io.Reader, loc *time.Location) ([]byte, Meta, error) {
+	// simpan awal file untuk dibaca EXIF-nya; decoder membaca berurutan
+	// sehingga APP1 selalu ada di bagian awal ini
+	head := &capBuffer{max: maxExifHeaderBytes}
+	src, err := DecodeReader(io.TeeReader(r, head))
+	if err != nil {
+		return nil, Meta{}, err
+	}
+	meta := ParseExif(head.Bytes(), loc)
+
+	out, err := encodeJPEG(fit(orient(src, meta.Orientation), maxSide), 85)
+	if err != nil {
+		return nil, Meta{}, err
+	}
+	return out, meta, nil
+}
+
+// capBuffer: bytes.Buffer yang berhenti menyimpan setelah max byte.
+type capBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (c *capBuffer) Write(p []byte) (int, error) {
+	if room := c.max - c.Len(); room > 0 {
+		if len(p) > room {
+			c.Buffer.Write(p[:room])
+		} else {
+			c.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// fit: perkecil (tidak pernah memperbesar) sampai sisi terpanjang ≤ side.
+func fit(src image.Image, side int) image.Image {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= side && h <= side {
+		return src
+	}
+	if w >= h {
+		return imaging.Resize(src, side, 0, imaging.Lanczos)
+	}
+	return imaging.Resize(src, 0, side, imaging.Lanczos)
+}
+
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("encode jpeg: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeBase64 decodes a (data URL or plain) base64 JPEG/PNG into an image.
+func DecodeBase64(in string) (image.Image, error) {
+	raw, err := DecodeRaw(in)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(raw)
+}
+
+// DecodeRaw: base64 (data URL atau polos) → byte mentah, tanpa decode gambar.
+func DecodeRaw(in string) ([]byte, error) {
 	// potong "data:image/...;base64,"
 	if i := strings.Index(in, ","); i != -1 && strings.Contains(in[:i], "base64") {
 		in = in[i+1:]
 	}
-	raw, err := base64.StdEncoding.DecodeString(in)
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(in))
 	if err != nil {
-		return "", fmt.Errorf("decode base64: %w", err)
+		return nil, fmt.Errorf("decode base64: %w", err)
 	}
+	return raw, nil
+}
 
+// Decode: byte JPEG/PNG → image.
+func Decode(raw []byte) (image.Image, error) {
 	src, _, err := image.Decode(bytes.NewReader(raw))
 	if err != nil {
-		return "", fmt.Errorf("decode img: %w", err)
+		return nil, fmt.Errorf("decode img: %w", err)
 	}
+	return src, nil
+}
 
-	w, h := src.Bounds().Dx(), src.Bounds().Dy()
-	if w > maxSide || h > maxSide {
-		if w >= h {
-			src = imaging.Resize(src, maxSide, 0, imaging.Lanczos)
-		} else {
-			src = imaging.Resize(src, 0, maxSide, imaging.Lanczos)
-		}
+// DecodeReader: seperti Decode, tapi cek dulu content type dari byte awal
+// (bukan dari header klien) dan hanya menerima JPEG/PNG.
+func DecodeReader(r io.Reader) (image.Image, error) {
+	ct, r, err := Sniff(r)
+	if err != nil {
+		return nil, err
+	}
+	if ct != "image/jpeg" && ct != "image/png" {
+		return nil, fmt.Errorf("unsupported image type %s", ct)
+	}
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode img: %w", err)
 	}
+	return src, nil
+}
 
-	var buf bytes.Buffer
-	if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: 85}); err != nil {
-		return "", fmt.Errorf("encode jpeg: %w", err)
+// Sniff: content type dari 512 byte pertama; reader yang dikembalikan
+// tetap berisi seluruh data (termasuk byte yang sudah diintip).
+func Sniff(r io.Reader) (string, io.Reader, error) {
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", nil, err
 	}
-	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+	return http.DetectContentType(head), br, nil
 }
@@ -0,0 +1,49 @@
+package imgutil
+
+import "fmt"
+
+// Ukuran varian foto. Full = hasil normalisasi (maks 1080px).
+const (
+	SizeThumb  = "thumb"
+	SizeMedium = "medium"
+	SizeFull   = "full"
+)
+
+// VariantSizes: varian yang dibuat saat upload (selain full).
+var VariantSizes = []string{SizeThumb, SizeMedium}
+
+var variantSide = map[string]int{SizeThumb: 160, SizeMedium: 480}
+
+func ValidSize(size string) bool {
+	return size == SizeFull || variantSide[size] > 0
+}
+
+// Variant: JPEG ukuran size dari foto yang sudah dinormalisasi.
+func Variant(jpg []byte, size string) ([]byte, error) {
+	side := variantSide[size]
+	if side == 0 {
+		return nil, fmt.Errorf("unknown variant size %q", size)
+	}
+	src, err := Decode(jpg)
+	if err != nil {
+		return nil, err
+	}
+	return encodeJPEG(fit(src, side), 80)
+}
+
+// Variants: semua varian (thumb, medium) sekaligus, decode sekali.
+func Variants(jpg []byte) (map[string][]byte, error) {
+	src, err := Decode(jpg)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]byte, len(VariantSizes))
+	for _, size := range VariantSizes {
+		b, err := encodeJPEG(fit(src, variantSide[size]), 80)
+		if err != nil {
+			return nil, err
+		}
+		out[size] = b
+	}
+	return out, nil
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// InviteTTL: masa berlaku default undangan pendaftaran (default 72 jam;
+// nilai tidak valid / <= 0 → default).
+func InviteTTL() time.Duration {
+	hStr := mustEnv("INVITE_TTL_HOURS", "72")
+	h, err := strconv.Atoi(hStr)
+	if err != nil || h <= 0 {
+		h = 72
+	}
+	return time.Duration(h) * time.Hour
+}
+
+// InviteURL: link pendaftaran untuk dikirim ke calon pegawai.
+func InviteURL(token string) string {
+	base := mustEnv("INVITE_BASE_URL", "http://localhost:8080/invitations")
+	return base + "?token=" + url.QueryEscape(token)
+}
+
+// HashToken: SHA-256 hex, untuk token yang tidak boleh disimpan mentah.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
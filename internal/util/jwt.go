@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -47,7 +48,61 @@ func SignAccessToken(userID, username string) (string, time.Time, error) {
 	return signed, exp, err
 }
 
+// ImpersonationTTL: umur token "act as user" (default 15 menit, maks 60).
+func ImpersonationTTL() time.Duration {
+	minStr := mustEnv("IMPERSONATION_TTL_MIN", "15")
+	min, _ := strconv.Atoi(minStr)
+	if min <= 0 || min > 60 {
+		min = 15
+	}
+	return time.Duration(min) * time.Minute
+}
+
+// SignImpersonationToken: access token atas nama userID yang juga membawa
+// ID admin asli (claim "act") dan scope read-only / read-write.
+func SignImpersonationToken(adminID, userID, username string, ttl time.Duration, readOnly bool) (string, time.Time, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		secret = "dev-secret"
+	}
+	scope := "read write"
+	if readOnly {
+		scope = "read"
+	}
+	now := time.Now()
+	exp := now.Add(ttl)
+	claims := jwt.MapClaims{
+		"sub":   userID,
+		"usr":   username,
+		"act":   map[string]any{"sub": adminID},
+		"scope": scope,
+		"iat":   now.Unix(),
+		"exp":   exp.Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(secret))
+	return signed, exp, err
+}
+
+// AccessClaims: isi access token. ActorID terisi kalau token impersonation.
+type AccessClaims struct {
+	UserID   string
+	Username string
+	ActorID  string
+	ReadOnly bool
+}
+
+func (c AccessClaims) Impersonated() bool { return c.ActorID != "" }
+
 func ParseAccessToken(tokenStr string) (userID, username string, err error) {
+	c, err := ParseAccessClaims(tokenStr)
+	if err != nil {
+		return "", "", err
+	}
+	return c.UserID, c.Username, nil
+}
+
+func ParseAccessClaims(tokenStr string) (AccessClaims, error) {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
 		secret = "dev-secret"
@@ -59,16 +114,25 @@ func ParseAccessToken(tokenStr string) (userID, username string, err error) {
 		return []byte(secret), nil
 	})
 	if err != nil || !tok.Valid {
-		return "", "", errors.New("invalid token")
+		return AccessClaims{}, errors.New("invalid token")
 	}
 	claims, ok := tok.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", "", errors.New("invalid claims")
+		return AccessClaims{}, errors.New("invalid claims")
+	}
+	var c AccessClaims
+	c.UserID, _ = claims["sub"].(string)
+	c.Username, _ = claims["usr"].(string)
+	if c.UserID == "" {
+		return AccessClaims{}, errors.New("no sub")
 	}
-	sub, _ := claims["sub"].(string)
-	usr, _ := claims["usr"].(string)
-	if sub == "" {
-		return "", "", errors.New("no sub")
+	if act, ok := claims["act"].(map[string]any); ok {
+		c.ActorID, _ = act["sub"].(string)
+		if c.ActorID == "" {
+			return AccessClaims{}, errors.New("invalid act")
+		}
+		scope, _ := claims["scope"].(string)
+		c.ReadOnly = !strings.Contains(scope, "write")
 	}
-	return sub, usr, nil
+	return c, nil
 }
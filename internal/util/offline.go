@@ -0,0 +1,16 @@
+package util
+
+import (
+	"strconv"
+	"time"
+)
+
+// OfflineMaxAge: umur maksimal event offline saat diunggah
+// (OFFLINE_MAX_AGE_HOURS, default 72 jam).
+func OfflineMaxAge() time.Duration {
+	h, err := strconv.Atoi(mustEnv("OFFLINE_MAX_AGE_HOURS", "72"))
+	if err != nil || h <= 0 {
+		h = 72
+	}
+	return time.Duration(h) * time.Hour
+}
@@ -0,0 +1,45 @@
+package util
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// ExifMaxSkew: selisih maksimal capture time EXIF selfie vs waktu server
+// (EXIF_MAX_SKEW_MIN, default 10 menit). Lebih dari itu dianggap foto lama
+// dari galeri.
+func ExifMaxSkew() time.Duration {
+	min, err := strconv.Atoi(mustEnv("EXIF_MAX_SKEW_MIN", "10"))
+	if err != nil || min <= 0 {
+		min = 10
+	}
+	return time.Duration(min) * time.Minute
+}
+
+// ExifSkewAction: "flag" (default, masuk antrian review) atau "reject" (422).
+func ExifSkewAction() string {
+	if os.Getenv("EXIF_SKEW_ACTION") == "reject" {
+		return "reject"
+	}
+	return "flag"
+}
+
+// PhotoHashMaxDistance: jarak Hamming dHash maksimal untuk dianggap foto
+// yang sama (PHOTO_HASH_MAX_DISTANCE, default 6 dari 64 bit).
+func PhotoHashMaxDistance() int {
+	n, err := strconv.Atoi(mustEnv("PHOTO_HASH_MAX_DISTANCE", "6"))
+	if err != nil || n < 0 || n > 32 {
+		n = 6
+	}
+	return n
+}
+
+// PhotoHashLookback: jendela pencarian foto lama (PHOTO_HASH_LOOKBACK_DAYS, default 180).
+func PhotoHashLookback() time.Duration {
+	d, err := strconv.Atoi(mustEnv("PHOTO_HASH_LOOKBACK_DAYS", "180"))
+	if err != nil || d <= 0 {
+		d = 180
+	}
+	return time.Duration(d) * 24 * time.Hour
+}
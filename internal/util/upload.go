@@ -0,0 +1,13 @@
+package util
+
+import "strconv"
+
+// UploadMaxBytes: batas ukuran body upload (UPLOAD_MAX_MB, default 10 MB).
+// Berlaku untuk multipart; body JSON base64 diberi kelonggaran 4/3.
+func UploadMaxBytes() int64 {
+	mb, err := strconv.Atoi(mustEnv("UPLOAD_MAX_MB", "10"))
+	if err != nil || mb <= 0 {
+		mb = 10
+	}
+	return int64(mb) << 20
+}
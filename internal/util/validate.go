@@ -0,0 +1,22 @@
+package util
+
+import (
+	"net/mail"
+	"regexp"
+)
+
+var (
+	phoneRe = regexp.MustCompile(`^\+?[0-9][0-9 \-]{6,18}[0-9]$`)
+	nipRe   = regexp.MustCompile(`^[A-Za-z0-9.\-]{1,32}$`)
+)
+
+// ValidEmail: alamat email polos (tanpa display name).
+func ValidEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+func ValidPhone(s string) bool { return phoneRe.MatchString(s) }
+
+// ValidNIP: nomor induk pegawai, alfanumerik (boleh titik/strip), maks 32.
+func ValidNIP(s string) bool { return nipRe.MatchString(s) }
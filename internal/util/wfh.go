@@ -0,0 +1,26 @@
+package util
+
+import "strconv"
+
+// Kebijakan lokasi WFH (WFH_LOCATION_POLICY).
+const (
+	WFHPolicyHome     = "home"     // harus dalam radius lokasi rumah yang disetujui
+	WFHPolicyAnywhere = "anywhere" // cukup mengirim lokasi
+)
+
+// WFHPolicy: WFH_LOCATION_POLICY, default "home".
+func WFHPolicy() string {
+	if p := mustEnv("WFH_LOCATION_POLICY", WFHPolicyHome); p == WFHPolicyAnywhere {
+		return p
+	}
+	return WFHPolicyHome
+}
+
+// WFHRadiusM: radius dari lokasi rumah (WFH_HOME_RADIUS_M, default 200 m).
+func WFHRadiusM() float64 {
+	v, err := strconv.ParseFloat(mustEnv("WFH_HOME_RADIUS_M", "200"), 64)
+	if err != nil || v <= 0 {
+		v = 200
+	}
+	return v
+}
@@ -0,0 +1,17 @@
+-- 001: role & status user untuk admin user management.
+-- Admin pertama di-set manual:
+--   UPDATE users SET role = 'admin' WHERE username = '...';
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS role           TEXT        NOT NULL DEFAULT 'employee',
+  ADD COLUMN IF NOT EXISTS office         TEXT,
+  ADD COLUMN IF NOT EXISTS is_active      BOOLEAN     NOT NULL DEFAULT TRUE,
+  ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+ALTER TABLE users
+  ADD CONSTRAINT users_role_check CHECK (role IN ('employee', 'admin'));
+
+CREATE INDEX IF NOT EXISTS users_jabatan_idx ON users (jabatan);
+CREATE INDEX IF NOT EXISTS users_office_idx  ON users (office);
+CREATE INDEX IF NOT EXISTS users_active_idx  ON users (is_active);
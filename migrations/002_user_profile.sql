@@ -0,0 +1,20 @@
+-- 002: data master pegawai (HR) pada tabel users.
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS full_name         TEXT,
+  ADD COLUMN IF NOT EXISTS nip               TEXT,
+  ADD COLUMN IF NOT EXISTS email             TEXT,
+  ADD COLUMN IF NOT EXISTS phone             TEXT,
+  ADD COLUMN IF NOT EXISTS department        TEXT,
+  ADD COLUMN IF NOT EXISTS hire_date         DATE,
+  ADD COLUMN IF NOT EXISTS employment_type   TEXT,
+  ADD COLUMN IF NOT EXISTS contract_end_date DATE,
+  ADD COLUMN IF NOT EXISTS photo_base64      TEXT;
+
+ALTER TABLE users
+  ADD CONSTRAINT users_employment_type_check
+    CHECK (employment_type IS NULL OR employment_type IN ('tetap', 'kontrak', 'magang'));
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_nip_key   ON users (nip) WHERE nip IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (LOWER(email)) WHERE email IS NOT NULL;
+CREATE INDEX IF NOT EXISTS users_department_idx   ON users (department);
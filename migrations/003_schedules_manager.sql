@@ -0,0 +1,23 @@
+-- 003: jadwal kerja + atasan langsung per user (dipakai import/export pegawai).
+
+CREATE TABLE IF NOT EXISTS work_schedules (
+  code       TEXT PRIMARY KEY,
+  name       TEXT        NOT NULL,
+  start_time TIME        NOT NULL,
+  end_time   TIME        NOT NULL,
+  work_days  TEXT        NOT NULL DEFAULT '1,2,3,4,5', -- ISO weekday, 1=Senin
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO work_schedules (code, name, start_time, end_time)
+VALUES ('REG', 'Reguler 08:00-17:00', '08:00', '17:00')
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS manager_id    UUID REFERENCES users(id) ON DELETE SET NULL,
+  ADD COLUMN IF NOT EXISTS schedule_code TEXT REFERENCES work_schedules(code);
+
+ALTER TABLE users
+  ADD CONSTRAINT users_manager_not_self CHECK (manager_id IS NULL OR manager_id <> id);
+
+CREATE INDEX IF NOT EXISTS users_manager_idx ON users (manager_id);
@@ -0,0 +1,29 @@
+-- 004: onboarding via undangan admin (POST /register tidak lagi terbuka).
+-- Token hanya disimpan hash SHA-256-nya; token asli cuma dikirim sekali ke admin.
+
+CREATE TABLE IF NOT EXISTS user_invitations (
+  id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  token_hash        TEXT        NOT NULL UNIQUE,
+  username          TEXT,                 -- opsional: kalau diisi, wajib dipakai
+  jabatan           TEXT        NOT NULL,
+  role              TEXT        NOT NULL DEFAULT 'employee',
+  office            TEXT,
+  full_name         TEXT,
+  nip               TEXT,
+  email             TEXT,
+  phone             TEXT,
+  department        TEXT,
+  hire_date         DATE,
+  employment_type   TEXT,
+  contract_end_date DATE,
+  manager_id        UUID REFERENCES users(id) ON DELETE SET NULL,
+  schedule_code     TEXT REFERENCES work_schedules(code),
+  created_by        UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  expires_at        TIMESTAMPTZ NOT NULL,
+  used_at           TIMESTAMPTZ,
+  used_by           UUID REFERENCES users(id) ON DELETE SET NULL,
+  revoked_at        TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS user_invitations_created_idx ON user_invitations (created_at DESC);
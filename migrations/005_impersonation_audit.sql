@@ -0,0 +1,21 @@
+-- 005: audit log untuk token "act as user" (impersonation) admin/helpdesk.
+-- Satu baris per request yang memakai token impersonation, plus baris
+-- action='start' saat token diterbitkan.
+
+CREATE TABLE IF NOT EXISTS impersonation_audit (
+  id         BIGSERIAL PRIMARY KEY,
+  admin_id   UUID        NOT NULL REFERENCES users(id),
+  user_id    UUID        NOT NULL REFERENCES users(id),
+  action     TEXT        NOT NULL DEFAULT 'request', -- start | request
+  method     TEXT        NOT NULL,
+  path       TEXT        NOT NULL,
+  status     INT         NOT NULL,
+  read_only  BOOLEAN     NOT NULL,
+  reason     TEXT,
+  ip         TEXT,
+  user_agent TEXT,
+  at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS impersonation_audit_admin_idx ON impersonation_audit (admin_id, at DESC);
+CREATE INDEX IF NOT EXISTS impersonation_audit_user_idx  ON impersonation_audit (user_id, at DESC);
@@ -0,0 +1,32 @@
+-- 006: verifikasi wajah selfie + antrian review absensi yang mencurigakan.
+
+CREATE TABLE IF NOT EXISTS face_enrollments (
+  user_id      UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  photo_base64 TEXT        NOT NULL,
+  enrolled_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+  enrolled_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE attendance_days
+  ADD COLUMN IF NOT EXISTS check_in_face_score   REAL,
+  ADD COLUMN IF NOT EXISTS check_in_face_status  TEXT, -- match | mismatch | not_enrolled
+  ADD COLUMN IF NOT EXISTS check_out_face_score  REAL,
+  ADD COLUMN IF NOT EXISTS check_out_face_status TEXT;
+
+-- Antrian review admin. Dipakai juga oleh pemeriksaan lain (reason berbeda).
+CREATE TABLE IF NOT EXISTS attendance_flags (
+  id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id     UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  date        DATE        NOT NULL,
+  event       TEXT        NOT NULL, -- check_in | check_out
+  reason      TEXT        NOT NULL, -- face_mismatch, ...
+  details     JSONB       NOT NULL DEFAULT '{}'::jsonb,
+  status      TEXT        NOT NULL DEFAULT 'pending', -- pending | approved | rejected
+  note        TEXT,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  resolved_at TIMESTAMPTZ,
+  resolved_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS attendance_flags_status_idx ON attendance_flags (status, created_at DESC);
+CREATE INDEX IF NOT EXISTS attendance_flags_user_idx   ON attendance_flags (user_id, date);
@@ -0,0 +1,17 @@
+-- 007: challenge liveness sekali pakai untuk selfie check-in / check-out.
+
+CREATE TABLE IF NOT EXISTS liveness_challenges (
+  id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id    UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  action     TEXT        NOT NULL, -- turn_left | turn_right | nod
+  frames     INT         NOT NULL,
+  issued_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMPTZ NOT NULL,
+  used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS liveness_challenges_user_idx ON liveness_challenges (user_id, expires_at);
+
+ALTER TABLE attendance_days
+  ADD COLUMN IF NOT EXISTS check_in_liveness  TEXT, -- passed | disabled
+  ADD COLUMN IF NOT EXISTS check_out_liveness TEXT;
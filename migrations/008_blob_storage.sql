@@ -0,0 +1,16 @@
+-- 008: foto absensi & bukti sakit pindah ke blob store; DB hanya simpan key.
+-- Kolom *_b64 / proof_base64 lama dikosongkan oleh `blobmigrate`.
+
+ALTER TABLE attendance_days
+  ADD COLUMN IF NOT EXISTS check_in_photo_key  TEXT,
+  ADD COLUMN IF NOT EXISTS check_out_photo_key TEXT;
+
+ALTER TABLE leave_requests
+  ADD COLUMN IF NOT EXISTS proof_key TEXT;
+
+-- base64 boleh kosong sekarang (data baru hanya mengisi key)
+ALTER TABLE attendance_days
+  ALTER COLUMN check_in_photo_b64  DROP NOT NULL,
+  ALTER COLUMN check_out_photo_b64 DROP NOT NULL;
+ALTER TABLE leave_requests
+  ALTER COLUMN proof_base64 DROP NOT NULL;
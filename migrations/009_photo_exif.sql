@@ -0,0 +1,5 @@
+-- 009: metadata EXIF selfie (capture time, perangkat, orientasi) per event.
+
+ALTER TABLE attendance_days
+  ADD COLUMN IF NOT EXISTS check_in_exif  JSONB,
+  ADD COLUMN IF NOT EXISTS check_out_exif JSONB;
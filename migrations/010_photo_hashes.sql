@@ -0,0 +1,14 @@
+-- 010: perceptual hash (dHash 64-bit) tiap foto absensi untuk deteksi
+-- selfie yang dipakai ulang.
+
+CREATE TABLE IF NOT EXISTS photo_hashes (
+  id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id    UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  date       DATE        NOT NULL,
+  event      TEXT        NOT NULL, -- check_in | check_out
+  hash       BIGINT      NOT NULL, -- uint64 disimpan sebagai bit pattern int64
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS photo_hashes_created_idx ON photo_hashes (created_at);
+CREATE INDEX IF NOT EXISTS photo_hashes_hash_idx    ON photo_hashes (hash);
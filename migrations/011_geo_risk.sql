@@ -0,0 +1,9 @@
+-- 011: sinyal lokasi dari perangkat (akurasi, provider, mock, ...) dan skor
+-- risiko spoofing GPS per event, untuk review HR.
+
+ALTER TABLE attendance_days
+  ADD COLUMN IF NOT EXISTS check_in_risk_score  SMALLINT,
+  ADD COLUMN IF NOT EXISTS check_in_geo         JSONB,
+  ADD COLUMN IF NOT EXISTS check_out_risk_score SMALLINT,
+  ADD COLUMN IF NOT EXISTS check_out_geo        JSONB;
+
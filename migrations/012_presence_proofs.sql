@@ -0,0 +1,21 @@
+-- 012: bukti kehadiran selain GPS. Admin mendaftarkan BSSID Wi-Fi dan ID
+-- beacon BLE per kantor (cocok dengan users.office; '' = berlaku untuk semua
+-- kantor). Absen di luar radius tetap sah kalau hasil scan perangkat cocok.
+
+CREATE TABLE IF NOT EXISTS presence_proofs (
+  id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  office     TEXT        NOT NULL DEFAULT '',
+  kind       TEXT        NOT NULL CHECK (kind IN ('wifi','ble')),
+  identifier TEXT        NOT NULL, -- BSSID aa:bb:cc:dd:ee:ff / beacon uuid:major:minor (lowercase)
+  label      TEXT,
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (office, kind, identifier)
+);
+
+-- metode bukti kehadiran tiap event: gps | wifi | ble
+ALTER TABLE attendance_days
+  ADD COLUMN IF NOT EXISTS check_in_presence      TEXT,
+  ADD COLUMN IF NOT EXISTS check_in_presence_ref  TEXT,
+  ADD COLUMN IF NOT EXISTS check_out_presence     TEXT,
+  ADD COLUMN IF NOT EXISTS check_out_presence_ref TEXT;
@@ -0,0 +1,10 @@
+-- 013: secret QR kiosk per kantor (users.office). Kode QR berputar tiap 30
+-- detik dihitung dari secret ini; lihat internal/kioskqr.
+
+CREATE TABLE IF NOT EXISTS qr_kiosks (
+  office     TEXT PRIMARY KEY,
+  secret     BYTEA       NOT NULL,
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  rotated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
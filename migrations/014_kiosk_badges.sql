@@ -0,0 +1,25 @@
+-- 014: perangkat kiosk (tablet + pembaca RFID/NFC) dengan kredensial sendiri,
+-- dan pemetaan UID kartu → pegawai.
+
+CREATE TABLE IF NOT EXISTS kiosk_devices (
+  id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  name         TEXT        NOT NULL,
+  office       TEXT        NOT NULL CHECK (office <> ''), -- kiosk terikat ke satu kantor
+  secret_hash  TEXT        NOT NULL,            -- sha256 hex dari secret device
+  lat          DOUBLE PRECISION,                -- lokasi tetap kiosk (opsional)
+  lng          DOUBLE PRECISION,
+  created_by   UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_seen_at TIMESTAMPTZ,
+  revoked_at   TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS badges (
+  uid        TEXT PRIMARY KEY, -- hex uppercase tanpa pemisah
+  user_id    UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  label      TEXT,
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS badges_user_idx ON badges (user_id);
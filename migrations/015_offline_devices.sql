@@ -0,0 +1,22 @@
+-- 015: absen offline. Perangkat user mendaftarkan public key ed25519; event
+-- yang direkam tanpa sinyal ditandatangani lalu diunggah belakangan.
+-- check_*_at = waktu kejadian (captured), check_*_received_at = waktu diterima server.
+
+CREATE TABLE IF NOT EXISTS user_devices (
+  id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id      UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name         TEXT        NOT NULL,
+  public_key   BYTEA       NOT NULL, -- ed25519, 32 byte
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_used_at TIMESTAMPTZ,
+  revoked_at   TIMESTAMPTZ,
+  UNIQUE (public_key)
+);
+
+CREATE INDEX IF NOT EXISTS user_devices_user_idx ON user_devices (user_id);
+
+ALTER TABLE attendance_days
+  ADD COLUMN IF NOT EXISTS check_in_received_at  TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS check_in_device_id    UUID,
+  ADD COLUMN IF NOT EXISTS check_out_received_at TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS check_out_device_id   UUID;
@@ -0,0 +1,22 @@
+-- 016: header Idempotency-Key untuk POST/PUT/PATCH/DELETE. Response pertama
+-- disimpan dan diputar ulang untuk retry dengan key yang sama sampai expires_at.
+-- Proses crash / timeout sebelum response disimpan → setelah locked_until
+-- lewat, retry boleh mengambil alih key (bukan 409 sampai expires_at).
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  scope        TEXT        NOT NULL, -- user id (token) / hash kredensial kiosk
+  key          TEXT        NOT NULL,
+  method       TEXT        NOT NULL,
+  path         TEXT        NOT NULL,
+  request_hash TEXT        NOT NULL, -- sha256 body
+  status       INT,                  -- NULL = request pertama masih diproses
+  content_type TEXT,
+  body         BYTEA,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  expires_at   TIMESTAMPTZ NOT NULL,
+  locked_until TIMESTAMPTZ,          -- lease request yang sedang diproses
+  lease_token  UUID,                 -- pemegang lease; Complete/Release harus cocok
+  PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_idx ON idempotency_keys (expires_at);
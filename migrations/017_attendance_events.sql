@@ -0,0 +1,40 @@
+-- 017: log kejadian absensi per hari (check-in/out, kunjungan klien,
+-- istirahat) dan jadi sumber kebenaran. Jam masuk/pulang di attendance_days
+-- hanya cache (check_in pertama / check_out terakhir) yang ditulis ulang
+-- dalam transaksi yang sama setiap ada event; kolom detail (foto, wajah,
+-- EXIF, ...) tetap di attendance_days.
+
+CREATE TABLE IF NOT EXISTS attendance_events (
+  id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id    UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  date       DATE        NOT NULL, -- tanggal kantor
+  type       TEXT        NOT NULL CHECK (type IN ('check_in','check_out','visit','break_start','break_end')),
+  at         TIMESTAMPTZ NOT NULL,
+  lat        DOUBLE PRECISION,
+  lng        DOUBLE PRECISION,
+  distance_m DOUBLE PRECISION,
+  photo_key  TEXT,
+  note       TEXT,
+  presence   TEXT,                 -- gps | wifi | ble | qr | kiosk
+  details    JSONB,                -- sinyal tambahan (risiko lokasi, dst.)
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS attendance_events_user_date_idx ON attendance_events (user_id, date, at);
+
+-- backfill dari ringkasan yang sudah ada
+INSERT INTO attendance_events (user_id, date, type, at, lat, lng, distance_m, photo_key, presence)
+SELECT d.user_id, d.date, 'check_in', d.check_in_at, d.check_in_lat, d.check_in_lng,
+       d.check_in_distance_m, d.check_in_photo_key, d.check_in_presence
+FROM attendance_days d
+WHERE d.check_in_at IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM attendance_events e
+                  WHERE e.user_id = d.user_id AND e.date = d.date AND e.type = 'check_in');
+
+INSERT INTO attendance_events (user_id, date, type, at, lat, lng, distance_m, photo_key, presence)
+SELECT d.user_id, d.date, 'check_out', d.check_out_at, d.check_out_lat, d.check_out_lng,
+       d.check_out_distance_m, d.check_out_photo_key, d.check_out_presence
+FROM attendance_days d
+WHERE d.check_out_at IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM attendance_events e
+                  WHERE e.user_id = d.user_id AND e.date = d.date AND e.type = 'check_out');
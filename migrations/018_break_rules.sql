@@ -0,0 +1,18 @@
+-- 018: aturan istirahat per jadwal kerja.
+-- break_min_minutes: break_end sebelum ini ditolak (0 = bebas)
+-- break_max_minutes: istirahat lebih lama masuk antrian review (NULL = tanpa batas)
+-- break_outside:     boleh istirahat di luar geofence kantor (makan siang di luar)
+
+ALTER TABLE work_schedules
+  ADD COLUMN IF NOT EXISTS break_min_minutes INT     NOT NULL DEFAULT 0 CHECK (break_min_minutes >= 0),
+  ADD COLUMN IF NOT EXISTS break_max_minutes INT     CHECK (break_max_minutes IS NULL OR break_max_minutes > 0),
+  ADD COLUMN IF NOT EXISTS break_outside     BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE work_schedules
+  ADD CONSTRAINT work_schedules_break_range
+  CHECK (break_max_minutes IS NULL OR break_max_minutes >= break_min_minutes);
+
+-- Reguler 08:00-17:00 = 8 jam kerja + 1 jam istirahat
+UPDATE work_schedules
+SET break_max_minutes = 60, break_outside = TRUE
+WHERE code = 'REG' AND break_max_minutes IS NULL;
@@ -0,0 +1,20 @@
+-- 019: kerja dari rumah (WFH) dengan persetujuan atasan.
+-- Lokasi rumah didaftarkan user sendiri lalu disalin ke tiap pengajuan WFH
+-- (wfh_lat/wfh_lng) supaya yang disetujui atasan adalah lokasi itu.
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS home_lat    DOUBLE PRECISION,
+  ADD COLUMN IF NOT EXISTS home_lng    DOUBLE PRECISION,
+  ADD COLUMN IF NOT EXISTS home_set_at TIMESTAMPTZ;
+
+ALTER TABLE leave_requests
+  ADD COLUMN IF NOT EXISTS wfh_lat    DOUBLE PRECISION,
+  ADD COLUMN IF NOT EXISTS wfh_lng    DOUBLE PRECISION,
+  ADD COLUMN IF NOT EXISTS decided_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS leave_requests_user_kind_idx
+  ON leave_requests (user_id, kind, start_date);
+
+-- mode kerja hari itu (ditentukan saat check-in)
+ALTER TABLE attendance_days
+  ADD COLUMN IF NOT EXISTS mode TEXT NOT NULL DEFAULT 'office' CHECK (mode IN ('office','wfh'));
@@ -0,0 +1,12 @@
+-- 020: perjalanan dinas (leave_requests kind 'dinas'). Keperluan disimpan di
+-- reason; check-in selama dinas dicek terhadap geofence tujuan, bukan kantor.
+
+ALTER TABLE leave_requests
+  ADD COLUMN IF NOT EXISTS destination   TEXT,
+  ADD COLUMN IF NOT EXISTS dest_lat      DOUBLE PRECISION,
+  ADD COLUMN IF NOT EXISTS dest_lng      DOUBLE PRECISION,
+  ADD COLUMN IF NOT EXISTS dest_radius_m DOUBLE PRECISION;
+
+ALTER TABLE attendance_days DROP CONSTRAINT IF EXISTS attendance_days_mode_check;
+ALTER TABLE attendance_days
+  ADD CONSTRAINT attendance_days_mode_check CHECK (mode IN ('office','wfh','dinas'));
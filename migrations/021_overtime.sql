@@ -0,0 +1,28 @@
+-- 021: lembur. 'pre' = pengajuan sebelum lembur, 'claim' = klaim setelah
+-- lembur terjadi. Terkait attendance_days lewat (user_id, date); menit yang
+-- dibayar dihitung dari check-out aktual, dibatasi approved_minutes.
+-- schedule_end / work_days: jadwal kerja user yang disimpan saat pengajuan
+-- diputuskan, supaya pergantian jadwal tidak mengubah hari libur / jam lembur
+-- yang sudah disetujui (NULL pada baris yang sudah diputuskan = tanpa jadwal, REG).
+
+CREATE TABLE IF NOT EXISTS overtime_requests (
+  id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id          UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  date             DATE        NOT NULL,
+  kind             TEXT        NOT NULL CHECK (kind IN ('pre','claim')),
+  minutes          INT         NOT NULL CHECK (minutes > 0),
+  reason           TEXT,
+  status           TEXT        NOT NULL DEFAULT 'pending' CHECK (status IN ('pending','approved','rejected')),
+  approved_minutes INT         CHECK (approved_minutes IS NULL OR approved_minutes > 0),
+  decided_by       UUID REFERENCES users(id) ON DELETE SET NULL,
+  decided_at       TIMESTAMPTZ,
+  decision_note    TEXT,
+  schedule_end     TEXT,       -- HH:MM
+  work_days        TEXT,       -- ISO weekday dipisah koma, seperti work_schedules
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- satu pengajuan aktif per user per hari
+CREATE UNIQUE INDEX IF NOT EXISTS overtime_requests_active_uidx
+  ON overtime_requests (user_id, date) WHERE status IN ('pending','approved');
+CREATE INDEX IF NOT EXISTS overtime_requests_date_idx ON overtime_requests (date, status);
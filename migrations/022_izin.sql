@@ -0,0 +1,15 @@
+-- 022: izin sebagian hari (leave_requests kind 'izin'); start_date = end_date,
+-- rentang jam di start_time/end_time (jam kantor).
+--   late_arrival: datang terlambat, jam masuk yang diharapkan = end_time
+--   early_leave:  pulang lebih awal, jam pulang yang diharapkan = start_time
+--   step_out:     keluar sementara di tengah jam kerja
+
+ALTER TABLE leave_requests
+  ADD COLUMN IF NOT EXISTS izin_type  TEXT CHECK (izin_type IN ('late_arrival','early_leave','step_out')),
+  ADD COLUMN IF NOT EXISTS start_time TIME,
+  ADD COLUMN IF NOT EXISTS end_time   TIME;
+
+ALTER TABLE leave_requests DROP CONSTRAINT IF EXISTS leave_requests_izin_times;
+ALTER TABLE leave_requests
+  ADD CONSTRAINT leave_requests_izin_times
+  CHECK (kind <> 'izin' OR (izin_type IS NOT NULL AND start_time < end_time AND start_date = end_date));
@@ -0,0 +1,45 @@
+-- 023: kebijakan cuti per jenis (kind), menggantikan kuota tetap 12 hari.
+-- Kebijakan yang berlaku = baris paling spesifik yang cocok dengan user:
+-- jabatan + employment_type > jabatan > employment_type > umum (keduanya NULL).
+-- probation_months: hak cuti baru ada setelah hire_date + n bulan
+-- (UU 13/2003 pasal 79: 12 bulan; default 0 = perilaku lama).
+-- Baru cuti tahunan yang memakai kuota (sakit tidak dibatasi). Kebijakan umum
+-- cuti (tanpa jabatan / employment_type) tidak boleh dihapus: tanpa baris itu
+-- user yang tidak cocok kebijakan lain mendapat 0 hari.
+
+CREATE TABLE IF NOT EXISTS leave_policies (
+  id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  kind             TEXT        NOT NULL DEFAULT 'cuti' CHECK (kind = 'cuti'),
+  jabatan          TEXT,  -- NULL = semua jabatan (dicocokkan case-insensitive)
+  employment_type  TEXT CHECK (employment_type IN ('tetap','kontrak','magang')), -- NULL = semua
+  annual_days      INT         NOT NULL CHECK (annual_days >= 0),
+  probation_months INT         NOT NULL DEFAULT 0 CHECK (probation_months >= 0),
+  updated_by       UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS leave_policies_scope_uidx
+  ON leave_policies (kind, lower(COALESCE(jabatan,'')), COALESCE(employment_type,''));
+
+INSERT INTO leave_policies (kind, annual_days)
+SELECT 'cuti', 12
+WHERE NOT EXISTS (
+  SELECT 1 FROM leave_policies WHERE kind = 'cuti' AND jabatan IS NULL AND employment_type IS NULL
+);
+
+-- Override per user: jatah tahunan pengganti (year NULL = setiap tahun;
+-- baris dengan year spesifik menang). Override tidak terikat masa percobaan.
+CREATE TABLE IF NOT EXISTS leave_policy_overrides (
+  id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id     UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind        TEXT        NOT NULL DEFAULT 'cuti' CHECK (kind = 'cuti'),
+  year        INT,
+  annual_days INT         NOT NULL CHECK (annual_days >= 0),
+  note        TEXT,
+  updated_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+  updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS leave_policy_overrides_uidx
+  ON leave_policy_overrides (user_id, kind, COALESCE(year, 0));